@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"appa_subscriptions/internal/config"
+	"appa_subscriptions/internal/domains"
+	"appa_subscriptions/internal/jobs"
+	"appa_subscriptions/internal/services"
+	"appa_subscriptions/pkg/blobstore"
+	"appa_subscriptions/pkg/db"
+	"appa_subscriptions/pkg/db/repositories"
+	"appa_subscriptions/pkg/exchange"
+	"appa_subscriptions/pkg/mailgun"
+	"appa_subscriptions/pkg/shopify"
+)
+
+// app holds the connections and services shared by the server and the one-off commands
+type app struct {
+	cfg    *config.Config
+	db     *gorm.DB
+	loc    *time.Location
+	logger *zap.Logger
+
+	notificationService  domains.NotificationService
+	webhookService       domains.WebhookService
+	orderService         domains.OrderService
+	adminService         domains.AdminService
+	catalogService       domains.CatalogService
+	pricingService       domains.PricingService
+	quoteService         domains.QuoteService
+	underwritingService  domains.UnderwritingService
+	coverageService      domains.CoverageService
+	claimService         domains.ClaimService
+	limitService         domains.LimitPeriodService
+	cancellationService  domains.CancellationService
+	planChangeService    domains.PlanChangeService
+	reactivationService  domains.ReactivationService
+	discountService      domains.DiscountService
+	exchangeRateService  domains.ExchangeRateService
+	reportService        domains.ReportService
+	manualPaymentService domains.ManualPaymentService
+	reconService         domains.ReconciliationService
+	jobHandler           *jobs.JobHandler
+}
+
+// connectDB opens the database of the configuration
+func connectDB(cfg *config.Config, logger *zap.Logger) (*gorm.DB, error) {
+	sslmode := cfg.SSLMode
+	fmt.Printf("sslmode -> %s\n", sslmode)
+	if len(sslmode) > 0 {
+		sslmode = "sslmode=" + sslmode
+	}
+
+	//connect the database
+	connStr := fmt.Sprintf("host=%s port=%s user=%s "+
+		"password=%s dbname=%s %s",
+		cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPassword, cfg.DBName, sslmode)
+	// gorm connect
+	gormDB, err := db.NewDBSQLHandler(connStr)
+	if err != nil {
+		logger.Error(err.Error(), zap.Any("host", cfg.DBHost), zap.Any("port", cfg.DBPort), zap.Any("user", cfg.DBUser), zap.Any("dbname", cfg.DBName))
+		return nil, err
+	}
+
+	return gormDB, nil
+}
+
+// newApp creates the repositories and services on the database
+func newApp(cfg *config.Config, gormDB *gorm.DB, logger *zap.Logger) (*app, error) {
+	loc, err := time.LoadLocation("America/Caracas")
+	if err != nil {
+		return nil, fmt.Errorf("could not load Venezuela time zone: %w", err)
+	}
+
+	// Initialize API clients / repositories
+	shopifyCliente := shopify.NewRepository(
+		cfg.ShopifyStoreName, cfg.ShopifyAPIVersion, cfg.ShopifyAdminToken, logger,
+	)
+	paymentInstallmentRepo := repositories.NewPaymentInstallmentRepository(loc, logger)
+	userRepo := repositories.NewUserRepository(gormDB, logger)
+	petRepo := repositories.NewPetRepository(gormDB, logger)
+	policyRepo := repositories.NewPolicyRepository(gormDB, loc, logger)
+	planRepo := repositories.NewPlanRepository(gormDB, logger)
+	petAttributeRepo := repositories.NewPetAttributeRepository(gormDB, logger)
+	jobRunRepo := repositories.NewJobRunRepository(gormDB, logger)
+	catalogCache := repositories.NewCatalogCache(gormDB, petAttributeRepo, planRepo, cfg.CatalogCacheTTL, logger)
+
+	muClient := mailgun.NewClient(cfg.MailgunAPIKey)
+
+	// Initialize resources
+	muRepository := mailgun.NewRepository(muClient, cfg.MailgunDomain, cfg.MailgunSender, logger)
+	rateSource := exchange.NewBCVSource(cfg.ExchangeRateURL, cfg.ExchangeRateInsecureTLS, logger)
+	blobStore, err := blobstore.NewLocalStore(cfg.BlobStoreDir, logger)
+	if err != nil {
+		return nil, fmt.Errorf("could not open blob store %s: %w", cfg.BlobStoreDir, err)
+	}
+
+	// Initialize services
+	a := &app{cfg: cfg, db: gormDB, loc: loc, logger: logger}
+	a.pricingService = services.NewPricingService(gormDB, loc, logger)
+	a.underwritingService = services.NewUnderwritingService(gormDB, loc, logger)
+	a.coverageService = services.NewCoverageService(gormDB, loc, logger)
+	a.discountService = services.NewDiscountService(gormDB, logger)
+	a.exchangeRateService = services.NewExchangeRateService(gormDB, rateSource, loc, logger)
+	a.webhookService = services.NewWebhookService(
+		gormDB, loc, shopifyCliente, paymentInstallmentRepo, userRepo, petRepo, policyRepo,
+		catalogCache.Plans(), catalogCache.PetAttributes(),
+		a.pricingService, a.underwritingService, a.coverageService, a.discountService, a.exchangeRateService, logger,
+	)
+	a.orderService = services.NewOrderService(
+		gormDB, shopifyCliente, paymentInstallmentRepo, policyRepo, a.discountService, a.exchangeRateService, muRepository,
+		loc, logger,
+	)
+	a.notificationService = services.NewNotificationService(muRepository, logger)
+	a.adminService = services.NewAdminService(userRepo, policyRepo, logger)
+	a.quoteService = services.NewQuoteService(gormDB, a.pricingService, a.underwritingService, loc, logger)
+	a.catalogService = services.NewCatalogService(
+		gormDB, shopifyCliente, catalogCache, cfg.ShopifyCatalogQuery, loc, logger,
+	)
+	a.claimService = services.NewClaimService(gormDB, a.coverageService, loc, logger)
+	a.limitService = services.NewLimitPeriodService(gormDB, cfg.LimitRenewalPendingAction, loc, logger)
+	a.cancellationService = services.NewCancellationService(gormDB, shopifyCliente, paymentInstallmentRepo, policyRepo, loc, logger)
+	a.planChangeService = services.NewPlanChangeService(gormDB, a.pricingService, loc, logger)
+	a.reactivationService = services.NewReactivationService(
+		gormDB, shopifyCliente, paymentInstallmentRepo, policyRepo, a.coverageService, a.exchangeRateService, loc, logger,
+	)
+	a.reportService = services.NewReportService(gormDB, loc, logger)
+	a.manualPaymentService = services.NewManualPaymentService(gormDB, shopifyCliente, paymentInstallmentRepo, blobStore, loc, logger)
+	a.reconService = services.NewReconciliationService(
+		gormDB, shopifyCliente, paymentInstallmentRepo, a.manualPaymentService, loc, logger,
+	)
+
+	// Jobs
+	a.jobHandler = jobs.NewJobHandler(
+		a.orderService, a.catalogService, a.limitService, a.cancellationService, a.planChangeService,
+		a.exchangeRateService, a.reconService, jobRunRepo, logger,
+	)
+
+	return a, nil
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"appa_subscriptions/internal/config"
+	"appa_subscriptions/pkg/db/migrations"
+)
+
+const usage = `usage: server <command> [arguments]
+
+commands:
+  serve                       start the HTTP server and the scheduled jobs (default)
+  migrate up | down [steps] | status
+                              apply, roll back or list the database migrations
+  seed                        apply the seeds again
+  job run <name> [--dry-run] [--date YYYY-MM-DD]
+                              run a job once, or print what it would do on the date
+  webhook replay <id>         process again a webhook event, or every event of a Shopify order
+  reconcile                   reconcile pending installments with Shopify orders
+  user show <email>           print a user with their pets and policies
+
+jobs: ScheduledOrders, NextPaymentInstallmentCreate, ReminderPendingPolicies, CatalogSync,
+      LimitPeriodRenewal, DueCancellations, ExchangeRateSync, PaymentReconciliation`
+
+// runCommand runs the command of the arguments, with no arguments it serves
+func runCommand(ctx context.Context, cfg *config.Config, args []string, logger *zap.Logger) error {
+	command := "serve"
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+
+	switch command {
+	case "serve", "migrate", "seed", "job", "webhook", "reconcile", "user":
+	default:
+		return fmt.Errorf("unknown command %q\n%s", command, usage)
+	}
+
+	gormDB, err := connectDB(cfg, logger)
+	if err != nil {
+		return err
+	}
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := sqlDB.Close(); err != nil {
+			fmt.Printf("error db body: %v\n", err)
+		}
+	}()
+
+	switch command {
+	case "migrate":
+		return runMigrate(ctx, gormDB, args, logger)
+	case "seed":
+		migrator, err := migrations.NewMigrator(gormDB, logger)
+		if err != nil {
+			return err
+		}
+		seeded, err := migrator.Seed(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("applied %d seeds\n", seeded)
+		return nil
+	}
+
+	a, err := newApp(cfg, gormDB, logger)
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "job":
+		return runJob(ctx, a, args)
+	case "webhook":
+		return runWebhook(ctx, a, args)
+	case "reconcile":
+		return runReconcile(ctx, a, args)
+	case "user":
+		return runUser(ctx, a, args)
+	}
+
+	return serve(a)
+}
+
+const jobUsage = "usage: job run <name> [--dry-run] [--date YYYY-MM-DD]"
+
+// runJob runs a job once, or prints its plan on a dry run
+func runJob(ctx context.Context, a *app, args []string) error {
+	flags := flag.NewFlagSet("job run", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	dryRun := flags.Bool("dry-run", false, "print what the job would do without doing it")
+	date := flags.String("date", "", "day of the dry run, today by default")
+
+	positional, err := parseArgs(flags, args)
+	if err != nil || len(positional) != 2 || positional[0] != "run" {
+		return errors.New(jobUsage)
+	}
+	name := positional[1]
+	if *date != "" && !*dryRun {
+		return errors.New("--date is only available on a dry run")
+	}
+
+	if *dryRun {
+		day := time.Now().In(a.loc)
+		if *date != "" {
+			day, err = time.ParseInLocation("2006-01-02", *date, a.loc)
+			if err != nil {
+				return errors.New("--date must be a date in YYYY-MM-DD format")
+			}
+		}
+
+		plan, err := a.jobHandler.DryRun(ctx, name, day)
+		if err != nil {
+			return err
+		}
+		return printJSON(plan)
+	}
+
+	// the notifications are sent in the background, wait for them before exiting
+	defer a.notificationService.Close()
+	if err := a.jobHandler.Run(ctx, name); err != nil {
+		return err
+	}
+	fmt.Printf("job %s finished\n", name)
+	return nil
+}
+
+const webhookUsage = "usage: webhook replay <id>"
+
+// runWebhook replays the recorded webhook events of an event or Shopify order id
+func runWebhook(ctx context.Context, a *app, args []string) error {
+	if len(args) != 2 || args[0] != "replay" {
+		return errors.New(webhookUsage)
+	}
+
+	defer a.notificationService.Close()
+	events, err := a.webhookService.ReplayEvents(ctx, args[1])
+	if err != nil {
+		return err
+	}
+	for _, event := range events {
+		fmt.Printf("replayed %s %s\n", event.ID, event.Topic)
+	}
+	return nil
+}
+
+// runReconcile reconciles the payments and prints the report
+func runReconcile(ctx context.Context, a *app, args []string) error {
+	if len(args) != 0 {
+		return errors.New("usage: reconcile")
+	}
+
+	report, err := a.reconService.ReconcilePayments(ctx)
+	if err != nil {
+		return err
+	}
+	return printJSON(report)
+}
+
+const userUsage = "usage: user show <email>"
+
+// runUser prints a user with their pets and policies
+func runUser(ctx context.Context, a *app, args []string) error {
+	if len(args) != 2 || args[0] != "show" {
+		return errors.New(userUsage)
+	}
+
+	user, policies, err := a.adminService.GetUserByEmail(ctx, args[1])
+	if err != nil {
+		return err
+	}
+	return printJSON(map[string]any{
+		"user":     user,
+		"policies": policies,
+	})
+}
+
+// parseArgs parses the flags wherever they are among the arguments and returns the rest
+func parseArgs(flags *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for len(args) > 0 {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		args = flags.Args()
+		if len(args) == 0 {
+			break
+		}
+		if !strings.HasPrefix(args[0], "-") {
+			positional = append(positional, args[0])
+		}
+		args = args[1:]
+	}
+	return positional, nil
+}
+
+// printJSON writes the value to stdout as indented JSON
+func printJSON(v any) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+
+	"appa_subscriptions/internal/handlers"
+	"appa_subscriptions/internal/jobs"
+	"appa_subscriptions/internal/routers"
+	"appa_subscriptions/pkg/money"
+)
+
+// serve starts the HTTP server and, unless debugging, the scheduled jobs
+func serve(a *app) error {
+	cfg, logger := a.cfg, a.logger
+
+	router := gin.Default()
+	router.Use(gin.Recovery())
+
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterCustomTypeFunc(money.ValidationValue, money.Amount{})
+	}
+
+	if cfg.Debug == "1" {
+		router.Use(cors.Default())
+	} else {
+		router.Use(cors.New(cors.Config{
+			AllowOrigins: cfg.CORSAllowedOrigins,
+			AllowMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+			AllowHeaders: []string{"Content-Type", "Authorization"},
+		}))
+	}
+
+	router.GET("/healthz", func(c *gin.Context) {
+		c.JSON(200, gin.H{
+			"status": "OK",
+		})
+	})
+
+	// Initialize handlers
+	webhookHandler := handlers.NewWebhookHandler(a.webhookService)
+	adminHandler := handlers.NewAdminHandler(a.adminService)
+	catalogHandler := handlers.NewCatalogHandler(a.catalogService)
+	pricingHandler := handlers.NewPricingHandler(a.pricingService)
+	quoteHandler := handlers.NewQuoteHandler(a.quoteService)
+	underwritingHandler := handlers.NewUnderwritingHandler(a.underwritingService)
+	coverageHandler := handlers.NewCoverageHandler(a.coverageService, a.loc)
+	claimHandler := handlers.NewClaimHandler(a.claimService)
+	cancellationHandler := handlers.NewCancellationHandler(a.cancellationService)
+	reactivationHandler := handlers.NewReactivationHandler(a.reactivationService)
+	planChangeHandler := handlers.NewPlanChangeHandler(a.planChangeService)
+	discountHandler := handlers.NewDiscountHandler(a.discountService)
+	exchangeRateHandler := handlers.NewExchangeRateHandler(a.exchangeRateService, a.loc)
+	reportHandler := handlers.NewReportHandler(a.reportService)
+	manualPaymentHandler := handlers.NewManualPaymentHandler(a.manualPaymentService)
+	reconciliationHandler := handlers.NewReconciliationHandler(a.reconService)
+	jobHandler := handlers.NewJobHandler(a.jobHandler, a.loc)
+
+	// Initialize routes
+	webhookRouter := routers.NewWebhookRoutes(webhookHandler)
+	adminRouter := routers.NewAdminRoutes(adminHandler)
+	catalogRouter := routers.NewCatalogRoutes(catalogHandler)
+	pricingRouter := routers.NewPricingRoutes(pricingHandler)
+	quoteRouter := routers.NewQuoteRoutes(quoteHandler)
+	underwritingRouter := routers.NewUnderwritingRoutes(underwritingHandler)
+	coverageRouter := routers.NewCoverageRoutes(coverageHandler)
+	claimRouter := routers.NewClaimRoutes(claimHandler)
+	cancellationRouter := routers.NewCancellationRoutes(cancellationHandler)
+	reactivationRouter := routers.NewReactivationRoutes(reactivationHandler)
+	planChangeRouter := routers.NewPlanChangeRoutes(planChangeHandler)
+	discountRouter := routers.NewDiscountRoutes(discountHandler)
+	exchangeRateRouter := routers.NewExchangeRateRoutes(exchangeRateHandler)
+	reportRouter := routers.NewReportRoutes(reportHandler)
+	manualPaymentRouter := routers.NewManualPaymentRoutes(manualPaymentHandler)
+	reconciliationRouter := routers.NewReconciliationRoutes(reconciliationHandler)
+	jobRouter := routers.NewJobRoutes(jobHandler)
+
+	// Set up routes
+	adminRouter.SetRouter(router)
+	catalogRouter.SetRouter(router)
+	pricingRouter.SetRouter(router)
+	quoteRouter.SetRouter(router)
+	underwritingRouter.SetRouter(router)
+	coverageRouter.SetRouter(router)
+	claimRouter.SetRouter(router)
+	cancellationRouter.SetRouter(router)
+	reactivationRouter.SetRouter(router)
+	planChangeRouter.SetRouter(router)
+	discountRouter.SetRouter(router)
+	exchangeRateRouter.SetRouter(router)
+	reportRouter.SetRouter(router)
+	manualPaymentRouter.SetRouter(router)
+	reconciliationRouter.SetRouter(router)
+	jobRouter.SetRouter(router)
+	webhookRouter.SetRouter(router, cfg.ShopifyHMACSecret)
+
+	// init config cron
+	c := cron.New(
+		cron.WithSeconds(),
+		cron.WithLocation(a.loc),
+	)
+
+	// Add TIIE job -> RUN | 08:30am | ALL DAYS |
+	err := a.jobHandler.Schedule(c, "0 30 8 * * *", jobs.JobScheduledOrders, a.jobHandler.HandleScheduledOrders)
+	if err != nil {
+		logger.Fatal("error adding job HandleScheduledOrders to cron", zap.Error(err))
+	}
+
+	// Add catalog sync job -> RUN | 06:00am | ALL DAYS |
+	err = a.jobHandler.Schedule(c, "0 0 6 * * *", jobs.JobCatalogSync, a.jobHandler.HandleCatalogSync)
+	if err != nil {
+		logger.Fatal("error adding job HandleCatalogSync to cron", zap.Error(err))
+	}
+
+	// Add limit period renewal job -> RUN | 00:15am | ALL DAYS |
+	err = a.jobHandler.Schedule(c, "0 15 0 * * *", jobs.JobLimitPeriodRenewal, a.jobHandler.HandleLimitPeriodRenewal)
+	if err != nil {
+		logger.Fatal("error adding job HandleLimitPeriodRenewal to cron", zap.Error(err))
+	}
+
+	// Add due cancellations job -> RUN | 00:05am | ALL DAYS |
+	err = a.jobHandler.Schedule(c, "0 5 0 * * *", jobs.JobDueCancellations, a.jobHandler.HandleDueCancellations)
+	if err != nil {
+		logger.Fatal("error adding job HandleDueCancellations to cron", zap.Error(err))
+	}
+
+	// Add exchange rate sync job -> RUN | 06:30am | ALL DAYS |
+	err = a.jobHandler.Schedule(c, "0 30 6 * * *", jobs.JobExchangeRateSync, a.jobHandler.HandleExchangeRateSync)
+	if err != nil {
+		logger.Fatal("error adding job HandleExchangeRateSync to cron", zap.Error(err))
+	}
+
+	// Add payment reconciliation job -> RUN | 02:00am | ALL DAYS |
+	err = a.jobHandler.Schedule(c, "0 0 2 * * *", jobs.JobPaymentReconciliation, a.jobHandler.HandlePaymentReconciliation)
+	if err != nil {
+		logger.Fatal("error adding job HandlePaymentReconciliation to cron", zap.Error(err))
+	}
+
+	// Add TIIE job -> RUN | 08:00am | ALL DAYS |
+	// err = a.jobHandler.Schedule(c, "0 0 8 * * *", jobs.JobReminderPendingPolicies, a.jobHandler.HandleReminderPendingPolicies)
+	// if err != nil {
+	// 	logger.Fatal("error adding job HandleScheduledOrders to cron", zap.Error(err))
+	// }
+
+	if cfg.Debug != "1" {
+		c.Start()
+		defer c.Stop()
+	}
+
+	return router.Run(":" + cfg.Port)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 )
 
 // Config holds the application configuration
@@ -24,10 +25,26 @@ type Config struct {
 	ShopifyAdminToken string
 	ShopifyStoreName  string
 	ShopifyHMACSecret string
+	// Search query selecting the plan products, all products when empty
+	ShopifyCatalogQuery string
 
 	// CORS
 	CORSAllowedOrigins []string
 
+	// Action applied on the renewal date to policies not in good standing: renew, defer or expire
+	LimitRenewalPendingAction string
+
+	// Page the official USD/VES exchange rate is read from
+	ExchangeRateURL string
+	// Skip the TLS verification of the exchange rate page, its certificate chain is often incomplete
+	ExchangeRateInsecureTLS bool
+
+	// Directory the uploaded files, like payment proofs, are stored in
+	BlobStoreDir string
+
+	// How long the pet attributes and plans are cached before they are read again
+	CatalogCacheTTL time.Duration
+
 	// Mailgun API credentials
 	MailgunDomain string
 	MailgunAPIKey string
@@ -62,13 +79,43 @@ func Load() (*Config, error) {
 		ShopifyStoreName:  os.Getenv("SHOPIFY_STORE_NAME"),
 		ShopifyHMACSecret: os.Getenv("SHOPIFY_HMAC_SECRET"),
 
+		ShopifyCatalogQuery: os.Getenv("SHOPIFY_CATALOG_QUERY"),
+
 		CORSAllowedOrigins: corsOrigins,
 
+		LimitRenewalPendingAction: os.Getenv("LIMIT_RENEWAL_PENDING_ACTION"),
+
+		ExchangeRateURL:         os.Getenv("EXCHANGE_RATE_URL"),
+		ExchangeRateInsecureTLS: os.Getenv("EXCHANGE_RATE_INSECURE_TLS") == "1",
+
+		BlobStoreDir: os.Getenv("BLOB_STORE_DIR"),
+
 		MailgunDomain: os.Getenv("MAILGUN_DOMAIN"),
 		MailgunAPIKey: os.Getenv("MAILGUN_API_KEY"),
 		MailgunSender: os.Getenv("MAILGUN_SENDER"),
 	}
 
+	if cfg.LimitRenewalPendingAction == "" {
+		cfg.LimitRenewalPendingAction = "defer"
+	}
+
+	if cfg.ExchangeRateURL == "" {
+		cfg.ExchangeRateURL = "https://www.bcv.org.ve/"
+	}
+
+	if cfg.BlobStoreDir == "" {
+		cfg.BlobStoreDir = "./storage"
+	}
+
+	cfg.CatalogCacheTTL = time.Hour
+	if ttl := os.Getenv("CATALOG_CACHE_TTL"); ttl != "" {
+		parsed, err := time.ParseDuration(ttl)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CATALOG_CACHE_TTL: %w", err)
+		}
+		cfg.CatalogCacheTTL = parsed
+	}
+
 	if err := validate(cfg); err != nil {
 		return nil, err
 	}
@@ -111,6 +158,12 @@ func validate(cfg *Config) error {
 		return fmt.Errorf("ShopifyHMACSecret is not configured")
 	}
 
+	switch cfg.LimitRenewalPendingAction {
+	case "renew", "defer", "expire":
+	default:
+		return fmt.Errorf("LimitRenewalPendingAction must be renew, defer or expire")
+	}
+
 	if cfg.MailgunDomain == "" {
 		return fmt.Errorf("MailgunDomain is not configured")
 	}
@@ -0,0 +1,58 @@
+package domains
+
+import "errors"
+
+var (
+	// ErrPlanPriceNotFound is returned when no price is configured for a plan and pet profile
+	ErrPlanPriceNotFound = errors.New("plan price not found")
+	// ErrInvalidPetProfile is returned when a pet profile cannot be mapped to the catalog
+	ErrInvalidPetProfile = errors.New("invalid pet profile")
+	// ErrPolicyNotUnderReview is returned when resolving a policy that is not held for manual review
+	ErrPolicyNotUnderReview = errors.New("policy is not held for manual review")
+	// ErrPolicyNotFound is returned when the policy does not exist
+	ErrPolicyNotFound = errors.New("policy not found")
+	// ErrPlanNotFound is returned when the plan does not exist
+	ErrPlanNotFound = errors.New("plan not found")
+	// ErrClaimNotFound is returned when the claim does not exist
+	ErrClaimNotFound = errors.New("claim not found")
+	// ErrInvalidClaim is returned when a claim request cannot be accepted
+	ErrInvalidClaim = errors.New("invalid claim")
+	// ErrInvalidClaimTransition is returned when a claim cannot move to the requested state
+	ErrInvalidClaimTransition = errors.New("invalid claim status transition")
+	// ErrClaimNotCovered is returned when the policy does not cover the claim
+	ErrClaimNotCovered = errors.New("claim is not covered by the policy")
+	// ErrInsufficientBalance is returned when the policy has no remaining balance for the claim
+	ErrInsufficientBalance = errors.New("policy remaining balance is insufficient")
+	// ErrPolicyAlreadyCancelled is returned when cancelling a policy that is already cancelled
+	ErrPolicyAlreadyCancelled = errors.New("policy is already cancelled")
+	// ErrPolicyNotCancelled is returned when reactivating a policy that is not cancelled
+	ErrPolicyNotCancelled = errors.New("policy is not cancelled")
+	// ErrHealthDeclarationRequired is returned when reactivating without a new health declaration
+	ErrHealthDeclarationRequired = errors.New("a new health declaration is required")
+	// ErrPolicyNotActive is returned when the operation requires an active policy
+	ErrPolicyNotActive = errors.New("policy is not active")
+	// ErrInvalidPlanChange is returned when the policy cannot move to the requested plan
+	ErrInvalidPlanChange = errors.New("invalid plan change")
+	// ErrDiscountRuleNotFound is returned when the discount rule does not exist
+	ErrDiscountRuleNotFound = errors.New("discount rule not found")
+	// ErrExchangeRateNotFound is returned when no exchange rate is available for a date
+	ErrExchangeRateNotFound = errors.New("exchange rate not found")
+	// ErrInstallmentNotFound is returned when the payment installment does not exist
+	ErrInstallmentNotFound = errors.New("payment installment not found")
+	// ErrInstallmentNotPayable is returned when paying an installment that is not pending or overdue
+	ErrInstallmentNotPayable = errors.New("payment installment is not awaiting payment")
+	// ErrInvalidPaymentProof is returned when the payment proof is missing, too large or not an image or PDF
+	ErrInvalidPaymentProof = errors.New("invalid payment proof")
+	// ErrDuplicatePaymentReference is returned when a payment reference was already reported
+	ErrDuplicatePaymentReference = errors.New("payment reference already reported")
+	// ErrManualPaymentNotFound is returned when the manual payment does not exist
+	ErrManualPaymentNotFound = errors.New("manual payment not found")
+	// ErrManualPaymentNotPending is returned when reviewing a manual payment that was already reviewed
+	ErrManualPaymentNotPending = errors.New("manual payment was already reviewed")
+	// ErrUserNotFound is returned when the user does not exist
+	ErrUserNotFound = errors.New("user not found")
+	// ErrWebhookEventNotFound is returned when no webhook was recorded with the id or for the order
+	ErrWebhookEventNotFound = errors.New("webhook event not found")
+	// ErrUnknownJob is returned when running a job that does not exist
+	ErrUnknownJob = errors.New("unknown job")
+)
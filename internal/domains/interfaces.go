@@ -2,19 +2,133 @@ package domains
 
 import (
 	"appa_subscriptions/internal/models"
+	"appa_subscriptions/pkg/blobstore"
+	dbModels "appa_subscriptions/pkg/db/models"
+	"appa_subscriptions/pkg/money"
 	"context"
+	"io"
+	"time"
+
+	"gorm.io/gorm"
 )
 
 type WebhookService interface {
 	OrderCreated(webhook models.Webhook)
 	OrderPaid(webhook models.Webhook)
+	RecordEvent(ctx context.Context, event *dbModels.WebhookEvent) error
+	ReplayEvents(ctx context.Context, id string) ([]dbModels.WebhookEvent, error)
 }
 
 type OrderService interface {
 	NextPaymentInstallmentCreate(ctx context.Context) error
 	ReminderPendingPolicies(ctx context.Context) error
+	PlanNextPaymentInstallments(ctx context.Context, date time.Time) (*models.BillingPlan, error)
+	PlanReminderPendingPolicies(ctx context.Context, date time.Time) (*models.ReminderPlan, error)
+}
+
+type NotificationService interface {
+	Close()
 }
 
 type AdminService interface {
 	CheckEmailExists(email string) (bool, error)
+	GetUserByEmail(ctx context.Context, email string) (*dbModels.User, []dbModels.Policy, error)
+}
+
+type CatalogService interface {
+	SyncCatalog(ctx context.Context) (*models.CatalogSyncReport, error)
+	PlanCatalogSync(ctx context.Context) (*models.CatalogSyncPlan, error)
+}
+
+type PricingService interface {
+	QuotePlanPrice(ctx context.Context, req models.PlanPriceQuoteRequest) (*models.PlanPriceQuote, error)
+	VerifyLineItemPrice(tx *gorm.DB, ctx context.Context, orderID string, item models.LineItem, req models.PlanPriceQuoteRequest) error
+}
+
+type QuoteService interface {
+	Quote(ctx context.Context, req models.QuoteRequest) (*models.QuoteResponse, error)
+}
+
+type UnderwritingService interface {
+	Evaluate(tx *gorm.DB, ctx context.Context, input models.UnderwritingInput) (*models.UnderwritingDecision, error)
+	RecordDecision(tx *gorm.DB, ctx context.Context, policyID string, decision *models.UnderwritingDecision) error
+	ResolveReview(ctx context.Context, policyID string, req models.UnderwritingReviewRequest) (*dbModels.PolicyUnderwritingDecision, error)
+}
+
+type CoverageService interface {
+	ComputeEligibility(tx *gorm.DB, ctx context.Context, policy *dbModels.Policy, from time.Time) error
+	IsEligible(ctx context.Context, policyID, coverageType string, date time.Time) (*models.CoverageEligibility, error)
+	SetWaitingPeriod(ctx context.Context, planID string, req models.WaitingPeriodRequest) (*dbModels.PlanWaitingPeriod, error)
+}
+
+type ClaimService interface {
+	SubmitClaim(ctx context.Context, req models.SubmitClaimRequest) (*dbModels.Claim, error)
+	GetClaim(ctx context.Context, claimID string) (*dbModels.Claim, error)
+	StartReview(ctx context.Context, claimID string, req models.ClaimReviewRequest) (*dbModels.Claim, error)
+	ApproveClaim(ctx context.Context, claimID string, req models.ApproveClaimRequest) (*dbModels.Claim, error)
+	RejectClaim(ctx context.Context, claimID string, req models.RejectClaimRequest) (*dbModels.Claim, error)
+	MarkClaimPaid(ctx context.Context, claimID string) (*dbModels.Claim, error)
+}
+
+type LimitPeriodService interface {
+	RenewLimitPeriods(ctx context.Context) (*models.LimitRenewalReport, error)
+	PlanLimitPeriodRenewals(ctx context.Context, date time.Time) (*models.LimitRenewalPlan, error)
+}
+
+type CancellationService interface {
+	CancelPolicy(ctx context.Context, policyID string, req models.CancelPolicyRequest) ([]dbModels.Policy, error)
+	CancelUserPolicies(ctx context.Context, userID string, req models.CancelPolicyRequest) ([]dbModels.Policy, error)
+	CompleteDueCancellations(ctx context.Context) error
+	PlanDueCancellations(ctx context.Context, date time.Time) (*models.DueCancellationPlan, error)
+}
+
+type ReactivationService interface {
+	ReactivatePolicy(ctx context.Context, policyID string, req models.ReactivatePolicyRequest) (*models.PolicyReactivationResponse, error)
+}
+
+type PlanChangeService interface {
+	ChangePlan(ctx context.Context, policyID string, req models.ChangePlanRequest) (*dbModels.PolicyPlanChange, error)
+	ApplyScheduledChanges(ctx context.Context) error
+	PlanScheduledChanges(ctx context.Context, date time.Time) (*models.PlanChangePlan, error)
+	GetPlanHistory(ctx context.Context, policyID string) ([]dbModels.PolicyPlanChange, error)
+}
+
+type DiscountService interface {
+	RecurringOrderDiscount(ctx context.Context, userID string) (*models.OrderDiscount, error)
+	RegisterCoupons(tx *gorm.DB, ctx context.Context, userID, orderID string, codes []models.Discount) error
+	RecordInstallmentDiscount(tx *gorm.DB, ctx context.Context, installmentID string, discount *models.OrderDiscount, amount money.Amount) error
+	ListRules(ctx context.Context) ([]dbModels.DiscountRule, error)
+	CreateRule(ctx context.Context, req models.DiscountRuleRequest) (*dbModels.DiscountRule, error)
+	UpdateRule(ctx context.Context, ruleID string, req models.DiscountRuleRequest) (*dbModels.DiscountRule, error)
+}
+
+type ExchangeRateService interface {
+	RateFor(ctx context.Context, date time.Time) (*dbModels.ExchangeRate, error)
+	SyncRate(ctx context.Context) (*dbModels.ExchangeRate, error)
+	PlanRateSync(ctx context.Context, date time.Time) (*models.ExchangeRateSyncPlan, error)
+	SetManualRate(ctx context.Context, req models.ManualExchangeRateRequest) (*dbModels.ExchangeRate, error)
+	ListRates(ctx context.Context, from, to time.Time) ([]dbModels.ExchangeRate, error)
+}
+
+type ReportService interface {
+	InstallmentReport(ctx context.Context, req models.InstallmentReportRequest) (*models.InstallmentReport, error)
+}
+
+type ReconciliationService interface {
+	ReconcilePayments(ctx context.Context) (*models.ReconciliationReport, error)
+	PlanReconciliation(ctx context.Context, date time.Time) (*models.ReconciliationPlan, error)
+	ListDiscrepancies(ctx context.Context, req models.PaymentDiscrepancyRequest) ([]dbModels.PaymentDiscrepancy, error)
+}
+
+type ManualPaymentService interface {
+	SubmitManualPayment(ctx context.Context, installmentID string, req models.SubmitManualPaymentRequest, proof models.ManualPaymentProof) (*dbModels.ManualPayment, error)
+	ListManualPayments(ctx context.Context, status string) ([]dbModels.ManualPayment, error)
+	GetProof(ctx context.Context, paymentID string) (io.ReadCloser, *blobstore.Object, error)
+	ApproveManualPayment(ctx context.Context, paymentID string, req models.ApproveManualPaymentRequest) (*dbModels.ManualPayment, error)
+	RejectManualPayment(ctx context.Context, paymentID string, req models.RejectManualPaymentRequest) (*dbModels.ManualPayment, error)
+	MarkApprovedOrdersPaid(ctx context.Context) (int, error)
+}
+
+type JobService interface {
+	DryRun(ctx context.Context, name string, date time.Time) (*models.JobPlan, error)
 }
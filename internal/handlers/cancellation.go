@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"appa_subscriptions/internal/domains"
+	"appa_subscriptions/internal/models"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CancellationHandler struct {
+	service domains.CancellationService
+}
+
+// NewCancellationHandler creates a new instance of CancellationHandler
+func NewCancellationHandler(service domains.CancellationService) *CancellationHandler {
+	return &CancellationHandler{
+		service: service,
+	}
+}
+
+// HandleCancelPolicy cancels a policy
+func (h *CancellationHandler) HandleCancelPolicy(c *gin.Context) {
+	var req models.CancelPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	policies, err := h.service.CancelPolicy(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		writeCancellationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"policies": policies})
+}
+
+// HandleCancelUserPolicies cancels every policy of a user
+func (h *CancellationHandler) HandleCancelUserPolicies(c *gin.Context) {
+	var req models.CancelPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	policies, err := h.service.CancelUserPolicies(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		writeCancellationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"policies": policies})
+}
+
+func writeCancellationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domains.ErrPolicyNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, domains.ErrPolicyAlreadyCancelled):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
+}
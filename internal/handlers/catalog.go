@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"appa_subscriptions/internal/domains"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CatalogHandler struct {
+	service domains.CatalogService
+}
+
+// NewCatalogHandler creates a new instance of CatalogHandler
+func NewCatalogHandler(service domains.CatalogService) *CatalogHandler {
+	return &CatalogHandler{
+		service: service,
+	}
+}
+
+// HandleSyncCatalog runs the catalog sync and returns its report
+func (h *CatalogHandler) HandleSyncCatalog(c *gin.Context) {
+	report, err := h.service.SyncCatalog(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
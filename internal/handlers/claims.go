@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"appa_subscriptions/internal/domains"
+	"appa_subscriptions/internal/models"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ClaimHandler struct {
+	service domains.ClaimService
+}
+
+// NewClaimHandler creates a new instance of ClaimHandler
+func NewClaimHandler(service domains.ClaimService) *ClaimHandler {
+	return &ClaimHandler{
+		service: service,
+	}
+}
+
+// HandleSubmitClaim registers a claim for a policy
+func (h *ClaimHandler) HandleSubmitClaim(c *gin.Context) {
+	var req models.SubmitClaimRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claim, err := h.service.SubmitClaim(c.Request.Context(), req)
+	if err != nil {
+		writeClaimError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, claim)
+}
+
+// HandleGetClaim returns a claim with its attachments
+func (h *ClaimHandler) HandleGetClaim(c *gin.Context) {
+	claim, err := h.service.GetClaim(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeClaimError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, claim)
+}
+
+// HandleStartReview moves a submitted claim into review
+func (h *ClaimHandler) HandleStartReview(c *gin.Context) {
+	var req models.ClaimReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claim, err := h.service.StartReview(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		writeClaimError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, claim)
+}
+
+// HandleApproveClaim approves a claim against the policy remaining balance
+func (h *ClaimHandler) HandleApproveClaim(c *gin.Context) {
+	var req models.ApproveClaimRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claim, err := h.service.ApproveClaim(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		writeClaimError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, claim)
+}
+
+// HandleRejectClaim rejects a claim
+func (h *ClaimHandler) HandleRejectClaim(c *gin.Context) {
+	var req models.RejectClaimRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claim, err := h.service.RejectClaim(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		writeClaimError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, claim)
+}
+
+// HandleMarkClaimPaid records the payment of an approved claim
+func (h *ClaimHandler) HandleMarkClaimPaid(c *gin.Context) {
+	claim, err := h.service.MarkClaimPaid(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeClaimError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, claim)
+}
+
+func writeClaimError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domains.ErrInvalidClaim):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, domains.ErrClaimNotFound), errors.Is(err, domains.ErrPolicyNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, domains.ErrInvalidClaimTransition), errors.Is(err, domains.ErrInsufficientBalance):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, domains.ErrClaimNotCovered):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"appa_subscriptions/internal/domains"
+	"appa_subscriptions/internal/models"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CoverageHandler struct {
+	service domains.CoverageService
+	loc     *time.Location
+}
+
+// NewCoverageHandler creates a new instance of CoverageHandler
+func NewCoverageHandler(service domains.CoverageService, loc *time.Location) *CoverageHandler {
+	return &CoverageHandler{
+		service: service,
+		loc:     loc,
+	}
+}
+
+// HandleIsEligible tells whether a policy covers a coverage type on a date, today by default
+func (h *CoverageHandler) HandleIsEligible(c *gin.Context) {
+	coverageType := c.Query("coverage")
+	if coverageType == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "coverage is required"})
+		return
+	}
+
+	date := time.Now().In(h.loc)
+	if dateStr := c.Query("date"); dateStr != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", dateStr, h.loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "date must be formatted as YYYY-MM-DD"})
+			return
+		}
+		date = parsed
+	}
+
+	eligibility, err := h.service.IsEligible(c.Request.Context(), c.Param("id"), coverageType, date)
+	if errors.Is(err, domains.ErrPolicyNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, eligibility)
+}
+
+// HandleSetWaitingPeriod sets the waiting period of a plan for a coverage type
+func (h *CoverageHandler) HandleSetWaitingPeriod(c *gin.Context) {
+	var req models.WaitingPeriodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	waitingPeriod, err := h.service.SetWaitingPeriod(c.Request.Context(), c.Param("id"), req)
+	if errors.Is(err, domains.ErrPlanNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, waitingPeriod)
+}
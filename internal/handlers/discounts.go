@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"appa_subscriptions/internal/domains"
+	"appa_subscriptions/internal/models"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DiscountHandler struct {
+	service domains.DiscountService
+}
+
+// NewDiscountHandler creates a new instance of DiscountHandler
+func NewDiscountHandler(service domains.DiscountService) *DiscountHandler {
+	return &DiscountHandler{
+		service: service,
+	}
+}
+
+// HandleListRules returns the discount rules
+func (h *DiscountHandler) HandleListRules(c *gin.Context) {
+	rules, err := h.service.ListRules(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"rules": rules})
+}
+
+// HandleCreateRule creates a discount rule
+func (h *DiscountHandler) HandleCreateRule(c *gin.Context) {
+	var req models.DiscountRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := h.service.CreateRule(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+// HandleUpdateRule replaces a discount rule
+func (h *DiscountHandler) HandleUpdateRule(c *gin.Context) {
+	var req models.DiscountRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := h.service.UpdateRule(c.Request.Context(), c.Param("id"), req)
+	if errors.Is(err, domains.ErrDiscountRuleNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"appa_subscriptions/internal/domains"
+	"appa_subscriptions/internal/models"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ExchangeRateHandler struct {
+	service domains.ExchangeRateService
+	loc     *time.Location
+}
+
+// NewExchangeRateHandler creates a new instance of ExchangeRateHandler
+func NewExchangeRateHandler(service domains.ExchangeRateService, loc *time.Location) *ExchangeRateHandler {
+	return &ExchangeRateHandler{
+		service: service,
+		loc:     loc,
+	}
+}
+
+// HandleListRates returns the stored exchange rates, the last 30 days by default
+func (h *ExchangeRateHandler) HandleListRates(c *gin.Context) {
+	to := time.Now().In(h.loc)
+	from := to.AddDate(0, 0, -30)
+
+	if value := c.Query("from"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", value, h.loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be a date in YYYY-MM-DD format"})
+			return
+		}
+		from = parsed
+	}
+	if value := c.Query("to"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", value, h.loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be a date in YYYY-MM-DD format"})
+			return
+		}
+		to = parsed
+	}
+
+	rates, err := h.service.ListRates(c.Request.Context(), from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"rates": rates})
+}
+
+// HandleSetManualRate stores an exchange rate entered by staff
+func (h *ExchangeRateHandler) HandleSetManualRate(c *gin.Context) {
+	var req models.ManualExchangeRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rate, err := h.service.SetManualRate(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, rate)
+}
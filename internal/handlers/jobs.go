@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"appa_subscriptions/internal/domains"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JobHandler struct {
+	service domains.JobService
+	loc     *time.Location
+}
+
+// NewJobHandler creates a new instance of JobHandler
+func NewJobHandler(service domains.JobService, loc *time.Location) *JobHandler {
+	return &JobHandler{
+		service: service,
+		loc:     loc,
+	}
+}
+
+// HandleDryRun returns what a job would do on a date, today by default, without doing it
+func (h *JobHandler) HandleDryRun(c *gin.Context) {
+	date := time.Now().In(h.loc)
+	if value := c.Query("date"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", value, h.loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "date must be a date in YYYY-MM-DD format"})
+			return
+		}
+		date = parsed
+	}
+
+	plan, err := h.service.DryRun(c.Request.Context(), c.Param("name"), date)
+	if errors.Is(err, domains.ErrUnknownJob) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"appa_subscriptions/internal/domains"
+	"appa_subscriptions/internal/models"
+	"appa_subscriptions/pkg/blobstore"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ManualPaymentHandler struct {
+	service domains.ManualPaymentService
+}
+
+// NewManualPaymentHandler creates a new instance of ManualPaymentHandler
+func NewManualPaymentHandler(service domains.ManualPaymentService) *ManualPaymentHandler {
+	return &ManualPaymentHandler{
+		service: service,
+	}
+}
+
+// HandleSubmitManualPayment records a payment made outside Shopify checkout. It takes a
+// multipart form with the payment fields and the proof file.
+func (h *ManualPaymentHandler) HandleSubmitManualPayment(c *gin.Context) {
+	var req models.SubmitManualPaymentRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fileHeader, err := c.FormFile("proof")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "proof file is required"})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "proof file cannot be read"})
+		return
+	}
+	defer file.Close()
+
+	payment, err := h.service.SubmitManualPayment(c.Request.Context(), c.Param("id"), req, models.ManualPaymentProof{
+		FileName:    fileHeader.Filename,
+		ContentType: fileHeader.Header.Get("Content-Type"),
+		Size:        fileHeader.Size,
+		Content:     file,
+	})
+	if err != nil {
+		writeManualPaymentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, payment)
+}
+
+// HandleListManualPayments returns the manual payments, filtered by the status query parameter
+func (h *ManualPaymentHandler) HandleListManualPayments(c *gin.Context) {
+	payments, err := h.service.ListManualPayments(c.Request.Context(), c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"manualPayments": payments})
+}
+
+// HandleGetProof serves the proof file of a manual payment
+func (h *ManualPaymentHandler) HandleGetProof(c *gin.Context) {
+	content, object, err := h.service.GetProof(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeManualPaymentError(c, err)
+		return
+	}
+	defer content.Close()
+
+	c.DataFromReader(http.StatusOK, object.Size, object.ContentType, content, nil)
+}
+
+// HandleApproveManualPayment approves a manual payment and marks its installment paid
+func (h *ManualPaymentHandler) HandleApproveManualPayment(c *gin.Context) {
+	var req models.ApproveManualPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	payment, err := h.service.ApproveManualPayment(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		writeManualPaymentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, payment)
+}
+
+// HandleRejectManualPayment rejects a manual payment
+func (h *ManualPaymentHandler) HandleRejectManualPayment(c *gin.Context) {
+	var req models.RejectManualPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	payment, err := h.service.RejectManualPayment(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		writeManualPaymentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, payment)
+}
+
+func writeManualPaymentError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domains.ErrInstallmentNotFound),
+		errors.Is(err, domains.ErrManualPaymentNotFound),
+		errors.Is(err, blobstore.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, domains.ErrInvalidPaymentProof):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, domains.ErrInstallmentNotPayable),
+		errors.Is(err, domains.ErrManualPaymentNotPending),
+		errors.Is(err, domains.ErrDuplicatePaymentReference):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"appa_subscriptions/internal/domains"
+	"appa_subscriptions/internal/models"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PlanChangeHandler struct {
+	service domains.PlanChangeService
+}
+
+// NewPlanChangeHandler creates a new instance of PlanChangeHandler
+func NewPlanChangeHandler(service domains.PlanChangeService) *PlanChangeHandler {
+	return &PlanChangeHandler{
+		service: service,
+	}
+}
+
+// HandleChangePlan moves a policy to another plan
+func (h *PlanChangeHandler) HandleChangePlan(c *gin.Context) {
+	var req models.ChangePlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	change, err := h.service.ChangePlan(c.Request.Context(), c.Param("id"), req)
+	switch {
+	case errors.Is(err, domains.ErrPolicyNotFound), errors.Is(err, domains.ErrPlanNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, domains.ErrPolicyNotActive):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, domains.ErrInvalidPlanChange), errors.Is(err, domains.ErrPlanPriceNotFound):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, change)
+}
+
+// HandleGetPlanHistory returns the plan changes of a policy
+func (h *PlanChangeHandler) HandleGetPlanHistory(c *gin.Context) {
+	changes, err := h.service.GetPlanHistory(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"planChanges": changes})
+}
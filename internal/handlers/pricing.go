@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"appa_subscriptions/internal/domains"
+	"appa_subscriptions/internal/models"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PricingHandler struct {
+	service domains.PricingService
+}
+
+// NewPricingHandler creates a new instance of PricingHandler
+func NewPricingHandler(service domains.PricingService) *PricingHandler {
+	return &PricingHandler{
+		service: service,
+	}
+}
+
+// HandleQuotePlanPrice returns the expected monthly price of a plan for a pet profile
+func (h *PricingHandler) HandleQuotePlanPrice(c *gin.Context) {
+	var req models.PlanPriceQuoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	quote, err := h.service.QuotePlanPrice(c.Request.Context(), req)
+	if errors.Is(err, domains.ErrPlanPriceNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, quote)
+}
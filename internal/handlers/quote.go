@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"appa_subscriptions/internal/domains"
+	"appa_subscriptions/internal/models"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type QuoteHandler struct {
+	service domains.QuoteService
+}
+
+// NewQuoteHandler creates a new instance of QuoteHandler
+func NewQuoteHandler(service domains.QuoteService) *QuoteHandler {
+	return &QuoteHandler{
+		service: service,
+	}
+}
+
+// HandleQuote returns the plans a pet is eligible for with their prices
+func (h *QuoteHandler) HandleQuote(c *gin.Context) {
+	var req models.QuoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	quote, err := h.service.Quote(c.Request.Context(), req)
+	if errors.Is(err, domains.ErrInvalidPetProfile) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, quote)
+}
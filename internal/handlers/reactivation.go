@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"appa_subscriptions/internal/domains"
+	"appa_subscriptions/internal/models"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReactivationHandler struct {
+	service domains.ReactivationService
+}
+
+// NewReactivationHandler creates a new instance of ReactivationHandler
+func NewReactivationHandler(service domains.ReactivationService) *ReactivationHandler {
+	return &ReactivationHandler{
+		service: service,
+	}
+}
+
+// HandleReactivatePolicy reactivates a cancelled policy
+func (h *ReactivationHandler) HandleReactivatePolicy(c *gin.Context) {
+	var req models.ReactivatePolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := h.service.ReactivatePolicy(c.Request.Context(), c.Param("id"), req)
+	switch {
+	case errors.Is(err, domains.ErrHealthDeclarationRequired):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, domains.ErrPolicyNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, domains.ErrPolicyNotCancelled):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"appa_subscriptions/internal/domains"
+	"appa_subscriptions/internal/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReconciliationHandler struct {
+	service domains.ReconciliationService
+}
+
+// NewReconciliationHandler creates a new instance of ReconciliationHandler
+func NewReconciliationHandler(service domains.ReconciliationService) *ReconciliationHandler {
+	return &ReconciliationHandler{
+		service: service,
+	}
+}
+
+// HandleReconcilePayments runs the reconciliation with Shopify now, outside the nightly job
+func (h *ReconciliationHandler) HandleReconcilePayments(c *gin.Context) {
+	report, err := h.service.ReconcilePayments(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// HandleListDiscrepancies returns the payment discrepancies reported on a day
+func (h *ReconciliationHandler) HandleListDiscrepancies(c *gin.Context) {
+	var req models.PaymentDiscrepancyRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	discrepancies, err := h.service.ListDiscrepancies(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, discrepancies)
+}
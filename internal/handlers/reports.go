@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"appa_subscriptions/internal/domains"
+	"appa_subscriptions/internal/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReportHandler struct {
+	service domains.ReportService
+}
+
+// NewReportHandler creates a new instance of ReportHandler
+func NewReportHandler(service domains.ReportService) *ReportHandler {
+	return &ReportHandler{
+		service: service,
+	}
+}
+
+// HandleInstallmentReport returns the installments due in a date range in USD or VES
+func (h *ReportHandler) HandleInstallmentReport(c *gin.Context) {
+	var req models.InstallmentReportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := h.service.InstallmentReport(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"appa_subscriptions/internal/domains"
+	"appa_subscriptions/internal/models"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UnderwritingHandler struct {
+	service domains.UnderwritingService
+}
+
+// NewUnderwritingHandler creates a new instance of UnderwritingHandler
+func NewUnderwritingHandler(service domains.UnderwritingService) *UnderwritingHandler {
+	return &UnderwritingHandler{
+		service: service,
+	}
+}
+
+// HandleResolveReview approves or rejects a policy held for manual review
+func (h *UnderwritingHandler) HandleResolveReview(c *gin.Context) {
+	var req models.UnderwritingReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	decision, err := h.service.ResolveReview(c.Request.Context(), c.Param("id"), req)
+	if errors.Is(err, domains.ErrPolicyNotUnderReview) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, decision)
+}
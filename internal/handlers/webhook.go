@@ -3,11 +3,16 @@ package handlers
 import (
 	"appa_subscriptions/internal/domains"
 	"appa_subscriptions/internal/models"
+	dbModels "appa_subscriptions/pkg/db/models"
+	"encoding/json"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
 
+const shopifyWebhookIDHeader = "X-Shopify-Webhook-Id"
+
 type WebhookHandler struct {
 	webhookService domains.WebhookService
 }
@@ -20,24 +25,56 @@ func NewWebhookHandler(webhookService domains.WebhookService) *WebhookHandler {
 
 // HandleWebhookOrderCreated handles the order created webhook
 func (h *WebhookHandler) HandleWebhookOrderCreated(c *gin.Context) {
-	var webhook models.Webhook
-	if err := c.ShouldBindJSON(&webhook); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	webhook, ok := h.receive(c, dbModels.WebhookTopicOrderCreated)
+	if !ok {
 		return
 	}
 
-	go h.webhookService.OrderCreated(webhook)
+	go h.webhookService.OrderCreated(*webhook)
 	c.Status(http.StatusOK)
 }
 
 // HandleWebhookOrderPaid handles the order paid webhook
 func (h *WebhookHandler) HandleWebhookOrderPaid(c *gin.Context) {
-	var webhook models.Webhook
-	if err := c.ShouldBindJSON(&webhook); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	webhook, ok := h.receive(c, dbModels.WebhookTopicOrderPaid)
+	if !ok {
 		return
 	}
 
-	go h.webhookService.OrderPaid(webhook)
+	go h.webhookService.OrderPaid(*webhook)
 	c.Status(http.StatusOK)
 }
+
+// receive decodes the webhook and records it so it can be replayed. When it cannot be recorded
+// Shopify gets an error and delivers it again.
+func (h *WebhookHandler) receive(c *gin.Context, topic string) (*models.Webhook, bool) {
+	payload, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	var webhook models.Webhook
+	if err := json.Unmarshal(payload, &webhook); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	event := dbModels.WebhookEvent{
+		Topic:   topic,
+		Payload: string(payload),
+	}
+	if webhookID := c.GetHeader(shopifyWebhookIDHeader); webhookID != "" {
+		event.ShopifyWebhookID = &webhookID
+	}
+	if webhook.ID != 0 {
+		orderID := strconv.Itoa(webhook.ID)
+		event.ShopifyOrderID = &orderID
+	}
+	if err := h.webhookService.RecordEvent(c.Request.Context(), &event); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return nil, false
+	}
+
+	return &webhook, true
+}
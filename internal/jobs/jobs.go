@@ -2,22 +2,215 @@ package jobs
 
 import (
 	"appa_subscriptions/internal/domains"
+	"appa_subscriptions/internal/models"
+	dbModels "appa_subscriptions/pkg/db/models"
+	"appa_subscriptions/pkg/db/repositories"
 	"context"
+	"fmt"
+	"os"
+	"time"
 
+	"github.com/robfig/cron/v3"
 	"go.uber.org/zap"
 )
 
+// Jobs that can be run once by name, outside the schedule
+const (
+	JobScheduledOrders              = "ScheduledOrders"
+	JobNextPaymentInstallmentCreate = "NextPaymentInstallmentCreate"
+	JobReminderPendingPolicies      = "ReminderPendingPolicies"
+	JobCatalogSync                  = "CatalogSync"
+	JobLimitPeriodRenewal           = "LimitPeriodRenewal"
+	JobDueCancellations             = "DueCancellations"
+	JobExchangeRateSync             = "ExchangeRateSync"
+	JobPaymentReconciliation        = "PaymentReconciliation"
+)
+
+// specParser parses the cron specs of the jobs, which start with the seconds field
+var specParser = cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// slotLookback is how far back lastSlot looks for the slot a run fired for
+const slotLookback = 8 * 24 * time.Hour
+
 type JobHandler struct {
-	ordersService domains.OrderService
-	logger        *zap.Logger
+	ordersService  domains.OrderService
+	catalogService domains.CatalogService
+	limitService   domains.LimitPeriodService
+	cancelService  domains.CancellationService
+	planService    domains.PlanChangeService
+	rateService    domains.ExchangeRateService
+	reconService   domains.ReconciliationService
+	runs           repositories.JobRunRepository
+	instance       string
+	logger         *zap.Logger
+}
+
+func NewJobHandler(
+	ordersService domains.OrderService,
+	catalogService domains.CatalogService,
+	limitService domains.LimitPeriodService,
+	cancelService domains.CancellationService,
+	planService domains.PlanChangeService,
+	rateService domains.ExchangeRateService,
+	reconService domains.ReconciliationService,
+	runs repositories.JobRunRepository,
+	logger *zap.Logger,
+) *JobHandler {
+	instance, err := os.Hostname()
+	if err != nil {
+		instance = "unknown"
+	}
+
+	return &JobHandler{
+		ordersService:  ordersService,
+		catalogService: catalogService,
+		limitService:   limitService,
+		cancelService:  cancelService,
+		planService:    planService,
+		rateService:    rateService,
+		reconService:   reconService,
+		runs:           runs,
+		instance:       instance,
+		logger:         logger,
+	}
 }
 
-func NewJobHandler(ordersService domains.OrderService, logger *zap.Logger) *JobHandler {
-	return &JobHandler{ordersService: ordersService, logger: logger}
+// Schedule adds the job with the name to the cron on the spec, with seconds, so each run
+// executes on a single instance
+func (h *JobHandler) Schedule(c *cron.Cron, spec, name string, job func()) error {
+	schedule, err := specParser.Parse(spec)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.AddFunc(spec, h.scheduled(name, schedule, c.Location(), job))
+	return err
 }
 
-// HandleScheduledOrders handles the scheduling of orders
+// scheduled wraps the job so a run is keyed by the job and the slot of the schedule it fired
+// for. An instance firing late resolves to the same slot as the others and skips the run.
+func (h *JobHandler) scheduled(name string, schedule cron.Schedule, loc *time.Location, job func()) func() {
+	return func() {
+		ctx := context.Background()
+		scheduledAt := lastSlot(schedule, time.Now().In(loc))
+		logger := h.logger.With(zap.String("job", name), zap.Time("scheduled_at", scheduledAt))
+
+		unlock, locked, err := h.runs.TryLock(ctx, name, scheduledAt)
+		if err != nil {
+			logger.Error("skipping scheduled job, could not take its lock", zap.Error(err))
+			return
+		}
+		if !locked {
+			logger.Info("skipping scheduled job, running on another instance")
+			return
+		}
+		defer unlock()
+
+		run := &dbModels.JobRun{Job: name, ScheduledAt: scheduledAt, Instance: h.instance}
+		started, err := h.runs.Start(ctx, run)
+		if err != nil {
+			logger.Error("skipping scheduled job, could not record its run", zap.Error(err))
+			return
+		}
+		if !started {
+			logger.Info("skipping scheduled job, already ran on another instance")
+			return
+		}
+
+		job()
+
+		if err := h.runs.Finish(ctx, run); err != nil {
+			logger.Warn("scheduled job finished but its run could not be recorded", zap.Error(err))
+		}
+	}
+}
+
+// lastSlot returns the latest time at or before now the schedule fires at. The schedules fire at
+// most weekly, so the slot is found within the lookback.
+func lastSlot(schedule cron.Schedule, now time.Time) time.Time {
+	slot := now.Truncate(time.Minute)
+	for next := schedule.Next(now.Add(-slotLookback)); !next.After(now); next = schedule.Next(next) {
+		slot = next
+	}
+	return slot
+}
+
+// Run runs the job with the name once
+func (h *JobHandler) Run(ctx context.Context, name string) error {
+	var err error
+	switch name {
+	case JobScheduledOrders:
+		if err := h.planService.ApplyScheduledChanges(ctx); err != nil {
+			return err
+		}
+		err = h.ordersService.NextPaymentInstallmentCreate(ctx)
+	case JobNextPaymentInstallmentCreate:
+		err = h.ordersService.NextPaymentInstallmentCreate(ctx)
+	case JobReminderPendingPolicies:
+		err = h.ordersService.ReminderPendingPolicies(ctx)
+	case JobCatalogSync:
+		_, err = h.catalogService.SyncCatalog(ctx)
+	case JobLimitPeriodRenewal:
+		_, err = h.limitService.RenewLimitPeriods(ctx)
+	case JobDueCancellations:
+		err = h.cancelService.CompleteDueCancellations(ctx)
+	case JobExchangeRateSync:
+		_, err = h.rateService.SyncRate(ctx)
+	case JobPaymentReconciliation:
+		_, err = h.reconService.ReconcilePayments(ctx)
+	default:
+		return fmt.Errorf("%w: %s", domains.ErrUnknownJob, name)
+	}
+
+	return err
+}
+
+// DryRun returns what the job with the name would do if it ran on the day of the date, at the
+// current time of day, without calling Shopify, sending mail or writing. Plan changes applied
+// by ScheduledOrders are not reflected in the billing plan, which uses the current plans.
+func (h *JobHandler) DryRun(ctx context.Context, name string, date time.Time) (*models.JobPlan, error) {
+	now := time.Now().In(date.Location())
+	at := time.Date(date.Year(), date.Month(), date.Day(), now.Hour(), now.Minute(), now.Second(), 0, date.Location())
+
+	plan := &models.JobPlan{Job: name, Date: at.Format("2006-01-02")}
+	var err error
+	switch name {
+	case JobScheduledOrders:
+		if plan.PlanChanges, err = h.planService.PlanScheduledChanges(ctx, at); err != nil {
+			return nil, err
+		}
+		plan.Billing, err = h.ordersService.PlanNextPaymentInstallments(ctx, at)
+	case JobNextPaymentInstallmentCreate:
+		plan.Billing, err = h.ordersService.PlanNextPaymentInstallments(ctx, at)
+	case JobReminderPendingPolicies:
+		plan.Reminders, err = h.ordersService.PlanReminderPendingPolicies(ctx, at)
+	case JobCatalogSync:
+		plan.Catalog, err = h.catalogService.PlanCatalogSync(ctx)
+	case JobLimitPeriodRenewal:
+		plan.LimitRenewals, err = h.limitService.PlanLimitPeriodRenewals(ctx, at)
+	case JobDueCancellations:
+		plan.Cancellations, err = h.cancelService.PlanDueCancellations(ctx, at)
+	case JobExchangeRateSync:
+		plan.ExchangeRate, err = h.rateService.PlanRateSync(ctx, at)
+	case JobPaymentReconciliation:
+		plan.Reconciliation, err = h.reconService.PlanReconciliation(ctx, at)
+	default:
+		return nil, fmt.Errorf("%w: %s", domains.ErrUnknownJob, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return plan, nil
+}
+
+// HandleScheduledOrders handles the scheduling of orders. Plan changes due today are applied
+// first so the orders bill the new plans.
 func (h *JobHandler) HandleScheduledOrders() {
+	if err := h.planService.ApplyScheduledChanges(context.Background()); err != nil {
+		h.logger.Error("failed to apply scheduled plan changes", zap.Error(err))
+	}
+
 	if err := h.ordersService.NextPaymentInstallmentCreate(context.Background()); err != nil {
 		h.logger.Error("failed to schedule orders", zap.Error(err))
 		return
@@ -31,3 +224,43 @@ func (h *JobHandler) HandleReminderPendingPolicies() {
 		return
 	}
 }
+
+// HandleCatalogSync handles the sync of plans and pet attributes from Shopify
+func (h *JobHandler) HandleCatalogSync() {
+	if _, err := h.catalogService.SyncCatalog(context.Background()); err != nil {
+		h.logger.Error("failed to sync catalog", zap.Error(err))
+		return
+	}
+}
+
+// HandleLimitPeriodRenewal handles the renewal of the policies annual limit periods
+func (h *JobHandler) HandleLimitPeriodRenewal() {
+	if _, err := h.limitService.RenewLimitPeriods(context.Background()); err != nil {
+		h.logger.Error("failed to renew limit periods", zap.Error(err))
+		return
+	}
+}
+
+// HandleDueCancellations handles the cancellation of policies whose end of period cancellation is due
+func (h *JobHandler) HandleDueCancellations() {
+	if err := h.cancelService.CompleteDueCancellations(context.Background()); err != nil {
+		h.logger.Error("failed to complete due cancellations", zap.Error(err))
+		return
+	}
+}
+
+// HandleExchangeRateSync handles the sync of the official exchange rate of the day
+func (h *JobHandler) HandleExchangeRateSync() {
+	if _, err := h.rateService.SyncRate(context.Background()); err != nil {
+		h.logger.Error("failed to sync exchange rate", zap.Error(err))
+		return
+	}
+}
+
+// HandlePaymentReconciliation handles the reconciliation of the pending installments with Shopify
+func (h *JobHandler) HandlePaymentReconciliation() {
+	if _, err := h.reconService.ReconcilePayments(context.Background()); err != nil {
+		h.logger.Error("failed to reconcile payments", zap.Error(err))
+		return
+	}
+}
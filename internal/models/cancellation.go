@@ -0,0 +1,26 @@
+package models
+
+// Cancellation reason codes
+const (
+	CancellationReasonCustomerRequest = "customer_request"
+	CancellationReasonNonPayment      = "non_payment"
+	CancellationReasonPetDeceased     = "pet_deceased"
+	CancellationReasonFraud           = "fraud"
+	CancellationReasonDuplicate       = "duplicate"
+	CancellationReasonOther           = "other"
+)
+
+// Cancellation effective dates
+const (
+	// CancellationImmediate cancels the policy today
+	CancellationImmediate = "immediate"
+	// CancellationEndOfPeriod keeps the policy covered until its next payment date
+	CancellationEndOfPeriod = "end_of_period"
+)
+
+// CancelPolicyRequest cancels a policy, or every policy of a user
+type CancelPolicyRequest struct {
+	Reason    string `json:"reason" binding:"required,oneof=customer_request non_payment pet_deceased fraud duplicate other"`
+	Effective string `json:"effective" binding:"required,oneof=immediate end_of_period"`
+	Notes     string `json:"notes"`
+}
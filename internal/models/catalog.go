@@ -0,0 +1,29 @@
+package models
+
+// CatalogSyncReport summarises a catalog sync run
+type CatalogSyncReport struct {
+	Products          int            `json:"products"`
+	Variants          int            `json:"variants"`
+	PlansCreated      int            `json:"plansCreated"`
+	PlansUpdated      int            `json:"plansUpdated"`
+	AttributesCreated int            `json:"attributesCreated"`
+	AttributesUpdated int            `json:"attributesUpdated"`
+	PricesChanged     int            `json:"pricesChanged"`
+	Issues            []CatalogIssue `json:"issues"`
+}
+
+// CatalogIssue describes a product or variant that does not map to a plan or pet attribute
+type CatalogIssue struct {
+	ProductID string `json:"productId"`
+	VariantID string `json:"variantId,omitempty"`
+	Reason    string `json:"reason"`
+}
+
+// AddIssue flags a product or variant in the report
+func (r *CatalogSyncReport) AddIssue(productID, variantID, reason string) {
+	r.Issues = append(r.Issues, CatalogIssue{
+		ProductID: productID,
+		VariantID: variantID,
+		Reason:    reason,
+	})
+}
@@ -0,0 +1,40 @@
+package models
+
+import "appa_subscriptions/pkg/money"
+
+// SubmitClaimRequest is a claim submitted for a policy
+type SubmitClaimRequest struct {
+	PolicyID      string                   `json:"policyId" binding:"required"`
+	CoverageType  string                   `json:"coverageType" binding:"required,oneof=accident illness"`
+	VetClinic     string                   `json:"vetClinic" binding:"required"`
+	Diagnosis     string                   `json:"diagnosis" binding:"required"`
+	IncidentDate  string                   `json:"incidentDate" binding:"required"`
+	InvoiceAmount money.Amount             `json:"invoiceAmount" binding:"required,gt=0"`
+	Attachments   []ClaimAttachmentRequest `json:"attachments" binding:"dive"`
+}
+
+// ClaimAttachmentRequest is a document supporting a claim, such as the vet invoice
+type ClaimAttachmentRequest struct {
+	FileName    string `json:"fileName" binding:"required"`
+	URL         string `json:"url" binding:"required,url"`
+	ContentType string `json:"contentType"`
+}
+
+// ClaimReviewRequest moves a claim into review
+type ClaimReviewRequest struct {
+	ReviewedBy string `json:"reviewedBy" binding:"required"`
+	Notes      string `json:"notes"`
+}
+
+// ApproveClaimRequest approves a claim for the given amount
+type ApproveClaimRequest struct {
+	ApprovedAmount money.Amount `json:"approvedAmount" binding:"required,gt=0"`
+	ReviewedBy     string       `json:"reviewedBy" binding:"required"`
+	Notes          string       `json:"notes"`
+}
+
+// RejectClaimRequest rejects a claim
+type RejectClaimRequest struct {
+	Reason     string `json:"reason" binding:"required"`
+	ReviewedBy string `json:"reviewedBy" binding:"required"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// CoverageEligibility tells whether a policy covers a coverage type on a date
+type CoverageEligibility struct {
+	PolicyID     string     `json:"policyId"`
+	CoverageType string     `json:"coverageType"`
+	Date         time.Time  `json:"date"`
+	Eligible     bool       `json:"eligible"`
+	EligibleFrom *time.Time `json:"eligibleFrom,omitempty"`
+	Reason       string     `json:"reason,omitempty"`
+}
+
+// WaitingPeriodRequest sets the waiting period of a plan for a coverage type
+type WaitingPeriodRequest struct {
+	CoverageType string `json:"coverageType" binding:"required,oneof=accident illness"`
+	Days         *int   `json:"days" binding:"required,min=0"`
+}
@@ -0,0 +1,20 @@
+package models
+
+// OrderDiscount is the discount applied to a recurring order
+type OrderDiscount struct {
+	Code       string
+	Percentage float64
+	// CouponID is the customer coupon the discount comes from, if any
+	CouponID string
+}
+
+// DiscountRuleRequest creates or updates a discount rule
+type DiscountRuleRequest struct {
+	Name       string  `json:"name" binding:"required"`
+	RuleType   string  `json:"ruleType" binding:"required,oneof=multi_pet coupon"`
+	Code       string  `json:"code" binding:"required_if=RuleType coupon"`
+	MinPets    int     `json:"minPets" binding:"required_if=RuleType multi_pet,omitempty,gt=1"`
+	Cycles     int     `json:"cycles" binding:"required_if=RuleType coupon,omitempty,gt=0"`
+	Percentage float64 `json:"percentage" binding:"required,gt=0,lte=100"`
+	Active     *bool   `json:"active"`
+}
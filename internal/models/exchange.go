@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"appa_subscriptions/pkg/money"
+)
+
+// ManualExchangeRateRequest records an official rate entered by staff, used when the rate
+// source is not available
+type ManualExchangeRateRequest struct {
+	Rate          float64 `json:"rate" binding:"required,gt=0"`
+	EffectiveDate string  `json:"effectiveDate" binding:"required,datetime=2006-01-02"`
+	CreatedBy     string  `json:"createdBy" binding:"required"`
+}
+
+// InstallmentReportRequest filters the installments report
+type InstallmentReportRequest struct {
+	From     string `form:"from" binding:"required,datetime=2006-01-02"`
+	To       string `form:"to" binding:"required,datetime=2006-01-02"`
+	Currency string `form:"currency" binding:"omitempty,oneof=USD VES"`
+	Status   string `form:"status"`
+}
+
+// InstallmentReportRow is an installment with its amounts in the report currency. Amount is
+// nil when the installment has no exchange rate to convert it with.
+type InstallmentReportRow struct {
+	ID                  string        `json:"id"`
+	ShopifyOrderName    string        `json:"shopifyOrderName"`
+	DueDate             time.Time     `json:"dueDate"`
+	Status              string        `json:"status"`
+	PaidAt              *time.Time    `json:"paidAt,omitempty"`
+	ShopAmount          money.Amount  `json:"shopAmount"`
+	ShopCurrency        string        `json:"shopCurrency"`
+	ExchangeRate        *float64      `json:"exchangeRate,omitempty"`
+	ExchangeRateDate    *time.Time    `json:"exchangeRateDate,omitempty"`
+	Amount              *money.Amount `json:"amount"`
+	PresentmentAmount   *money.Amount `json:"presentmentAmount,omitempty"`
+	PresentmentCurrency *string       `json:"presentmentCurrency,omitempty"`
+}
+
+// InstallmentReport lists the installments due in a date range in one currency
+type InstallmentReport struct {
+	From         string                 `json:"from"`
+	To           string                 `json:"to"`
+	Currency     string                 `json:"currency"`
+	Installments []InstallmentReportRow `json:"installments"`
+	Total        money.Amount           `json:"total"`
+	// Unconverted counts the installments left out of Total for lack of an exchange rate
+	Unconverted int `json:"unconverted"`
+}
@@ -0,0 +1,161 @@
+package models
+
+import "appa_subscriptions/pkg/money"
+
+// BillingPlan is what a recurring billing run would do on a date, computed without creating
+// orders, writing to the database or sending mail
+type BillingPlan struct {
+	Date   string         `json:"date"`
+	Orders []PlannedOrder `json:"orders"`
+	// EstimatedTotal adds up the estimated totals of the orders
+	EstimatedTotal money.Amount `json:"estimatedTotal"`
+}
+
+// PlannedOrder is the recurring order a billing run would create for a user. Amounts use the
+// current plan prices; Shopify taxes are not included.
+type PlannedOrder struct {
+	UserID     string          `json:"userId"`
+	Name       string          `json:"name"`
+	Email      string          `json:"email"`
+	BillingTag string          `json:"billingTag"`
+	DueDate    string          `json:"dueDate"`
+	Policies   []PlannedPolicy `json:"policies"`
+	Subtotal   money.Amount    `json:"subtotal"`
+	Proration  money.Amount    `json:"proration"`
+	Discount   money.Amount    `json:"discount"`
+	Codes      []string        `json:"codes,omitempty"`
+	// EstimatedTotal is the subtotal plus the prorations charged, less the discount
+	EstimatedTotal money.Amount        `json:"estimatedTotal"`
+	Notification   PlannedNotification `json:"notification"`
+	// Error is why the order could not be planned, the run would skip the user
+	Error string `json:"error,omitempty"`
+}
+
+// PlannedPolicy is a policy billed in a planned order
+type PlannedPolicy struct {
+	PolicyID    string `json:"policyId"`
+	PetName     string `json:"petName"`
+	VariantID   string `json:"variantId"`
+	NextPayment string `json:"nextPayment"`
+}
+
+// PlannedNotification is an email a run would send
+type PlannedNotification struct {
+	Template string `json:"template"`
+	To       string `json:"to"`
+}
+
+// ReminderPlan is what a payment reminder run would do on a date, computed without sending
+// mail or cancelling policies
+type ReminderPlan struct {
+	Date      string            `json:"date"`
+	Reminders []PlannedReminder `json:"reminders"`
+}
+
+// PlannedReminder is the reminder a run would send for an installment awaiting payment
+type PlannedReminder struct {
+	PaymentInstallmentID string              `json:"paymentInstallmentId"`
+	ShopifyOrderID       string              `json:"shopifyOrderId"`
+	Pets                 []string            `json:"pets"`
+	DaysLeft             int                 `json:"daysLeft"`
+	Notification         PlannedNotification `json:"notification"`
+	// CancelPolicyID is the policy the run would cancel for being past the grace period
+	CancelPolicyID string `json:"cancelPolicyId,omitempty"`
+}
+
+// JobPlan is what a job would do if it ran on a date. Only the sections of the job are set.
+type JobPlan struct {
+	Job            string                `json:"job"`
+	Date           string                `json:"date"`
+	PlanChanges    *PlanChangePlan       `json:"planChanges,omitempty"`
+	Billing        *BillingPlan          `json:"billing,omitempty"`
+	Reminders      *ReminderPlan         `json:"reminders,omitempty"`
+	Catalog        *CatalogSyncPlan      `json:"catalog,omitempty"`
+	LimitRenewals  *LimitRenewalPlan     `json:"limitRenewals,omitempty"`
+	Cancellations  *DueCancellationPlan  `json:"cancellations,omitempty"`
+	ExchangeRate   *ExchangeRateSyncPlan `json:"exchangeRate,omitempty"`
+	Reconciliation *ReconciliationPlan   `json:"reconciliation,omitempty"`
+}
+
+// PlanChangePlan is what applying the scheduled plan changes would do on a date
+type PlanChangePlan struct {
+	Date    string              `json:"date"`
+	Changes []PlannedPlanChange `json:"changes"`
+}
+
+// PlannedPlanChange is a scheduled plan change that is due
+type PlannedPlanChange struct {
+	PlanChangeID   string       `json:"planChangeId"`
+	PolicyID       string       `json:"policyId"`
+	FromPlanID     string       `json:"fromPlanId"`
+	ToPlanID       string       `json:"toPlanId"`
+	ToMonthlyPrice money.Amount `json:"toMonthlyPrice"`
+	EffectiveDate  string       `json:"effectiveDate"`
+	// Apply is false when the change would be cancelled, the policy is no longer active or
+	// on the plan it was changed from
+	Apply bool `json:"apply"`
+}
+
+// CatalogSyncPlan is what a catalog sync would start from. The products are read from Shopify
+// when the sync runs, so a dry run only reports the query and the plans stored.
+type CatalogSyncPlan struct {
+	ProductQuery string `json:"productQuery"`
+	Plans        int    `json:"plans"`
+}
+
+// LimitRenewalPlan is what a limit period renewal run would do on a date
+type LimitRenewalPlan struct {
+	Date     string                `json:"date"`
+	Policies []PlannedLimitRenewal `json:"policies"`
+}
+
+// PlannedLimitRenewal is a policy whose limit period ended
+type PlannedLimitRenewal struct {
+	PolicyID     string `json:"policyId"`
+	PolicyStatus string `json:"policyStatus"`
+	PeriodEnd    string `json:"periodEnd"`
+	// Action is one of LimitRenewalRenew, LimitRenewalDefer or LimitRenewalExpire
+	Action string `json:"action"`
+}
+
+// DueCancellationPlan is what completing the due cancellations would do on a date
+type DueCancellationPlan struct {
+	Date     string                 `json:"date"`
+	Policies []PlannedCancellation  `json:"policies"`
+	Voided   []PlannedVoidedPayment `json:"voided"`
+}
+
+// PlannedCancellation is a policy whose end of period cancellation is due
+type PlannedCancellation struct {
+	PolicyID      string `json:"policyId"`
+	EffectiveDate string `json:"effectiveDate"`
+}
+
+// PlannedVoidedPayment is an unpaid installment that would be voided, and its Shopify order
+// cancelled, because none of its policies stays active
+type PlannedVoidedPayment struct {
+	PaymentInstallmentID string `json:"paymentInstallmentId"`
+	ShopifyOrderID       string `json:"shopifyOrderId,omitempty"`
+}
+
+// ExchangeRateSyncPlan is what the exchange rate sync would start from on a date. The rate is
+// read from the source when the sync runs and only stored when the source has none for the day.
+type ExchangeRateSyncPlan struct {
+	Date string `json:"date"`
+	// Stored are the rates already stored for the day by source
+	Stored map[string]float64 `json:"stored"`
+}
+
+// ReconciliationPlan is what a payment reconciliation would check on a date. The orders are
+// read from Shopify when it runs, so a dry run cannot tell which installments would be fixed.
+type ReconciliationPlan struct {
+	Date         string `json:"date"`
+	Installments int    `json:"installments"`
+	// ShopifyOrderIDs are the orders of the installments that would be looked up
+	ShopifyOrderIDs []string `json:"shopifyOrderIds"`
+	// WithoutOrder are the installments that would be reported for having no order
+	WithoutOrder []string `json:"withoutOrder"`
+	// OrdersFrom and OrdersTo bound the recent Shopify orders checked for a local installment
+	OrdersFrom string `json:"ordersFrom"`
+	OrdersTo   string `json:"ordersTo"`
+}
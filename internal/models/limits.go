@@ -0,0 +1,20 @@
+package models
+
+// Actions applied on the renewal date to policies that are not in good standing
+const (
+	// LimitRenewalRenew rolls the policy over as if it were in good standing
+	LimitRenewalRenew = "renew"
+	// LimitRenewalDefer leaves the period open until the policy is back in good standing
+	LimitRenewalDefer = "defer"
+	// LimitRenewalExpire closes the period and leaves the policy without balance
+	LimitRenewalExpire = "expire"
+)
+
+// LimitRenewalReport summarises a limit period renewal run
+type LimitRenewalReport struct {
+	Due      int      `json:"due"`
+	Renewed  int      `json:"renewed"`
+	Deferred int      `json:"deferred"`
+	Expired  int      `json:"expired"`
+	Failed   []string `json:"failed"`
+}
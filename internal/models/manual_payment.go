@@ -0,0 +1,36 @@
+package models
+
+import (
+	"io"
+
+	"appa_subscriptions/pkg/money"
+)
+
+// SubmitManualPaymentRequest reports a payment made outside Shopify checkout for an installment
+type SubmitManualPaymentRequest struct {
+	Method      string       `form:"method" binding:"required,oneof=pago_movil zelle transfer"`
+	Reference   string       `form:"reference" binding:"required,max=64"`
+	Amount      money.Amount `form:"amount" binding:"required,gt=0"`
+	Currency    string       `form:"currency" binding:"required,oneof=USD VES"`
+	PaidOn      string       `form:"paidOn" binding:"required,datetime=2006-01-02"`
+	SubmittedBy string       `form:"submittedBy" binding:"required"`
+}
+
+// ManualPaymentProof is the uploaded screenshot or receipt of a manual payment
+type ManualPaymentProof struct {
+	FileName    string
+	ContentType string
+	Size        int64
+	Content     io.Reader
+}
+
+// ApproveManualPaymentRequest approves a manual payment
+type ApproveManualPaymentRequest struct {
+	ReviewedBy string `json:"reviewedBy" binding:"required"`
+}
+
+// RejectManualPaymentRequest rejects a manual payment
+type RejectManualPaymentRequest struct {
+	Reason     string `json:"reason" binding:"required"`
+	ReviewedBy string `json:"reviewedBy" binding:"required"`
+}
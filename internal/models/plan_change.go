@@ -0,0 +1,8 @@
+package models
+
+// ChangePlanRequest moves a policy to another plan of the same pet type
+type ChangePlanRequest struct {
+	PlanID      string `json:"planId" binding:"required"`
+	Effective   string `json:"effective" binding:"required,oneof=immediate next_billing"`
+	RequestedBy string `json:"requestedBy" binding:"required"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"appa_subscriptions/pkg/money"
+)
+
+// PlanPriceQuoteRequest identifies a plan and the pet profile it is priced for
+type PlanPriceQuoteRequest struct {
+	PlanID      string     `json:"planId" binding:"required"`
+	AgeRangeID  string     `json:"ageRangeId" binding:"required"`
+	SizeID      string     `json:"sizeId"`
+	ConditionID string     `json:"conditionId" binding:"required"`
+	Date        *time.Time `json:"date"`
+}
+
+// PlanPriceQuote is the expected monthly price of a plan for a pet profile
+type PlanPriceQuote struct {
+	PlanID           string       `json:"planId"`
+	PlanPriceID      string       `json:"planPriceId"`
+	MonthlyPrice     money.Amount `json:"monthlyPrice"`
+	ShopifyVariantID string       `json:"shopifyVariantId"`
+	EffectiveFrom    time.Time    `json:"effectiveFrom"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import "appa_subscriptions/pkg/money"
+
+// QuoteRequest is the pet profile sent by the storefront to get a quote. Breed is optional and
+// only checked against the underwriting rules. Size is required for pet types priced by size.
+type QuoteRequest struct {
+	PetType  string `json:"petType" binding:"required"`
+	Breed    string `json:"breed"`
+	Birthday string `json:"birthday" binding:"required"`
+	Size     string `json:"size"`
+	Neutered *bool  `json:"neutered" binding:"required"`
+	Gender   string `json:"gender"`
+}
+
+// QuoteResponse lists the plans a pet is eligible for
+type QuoteResponse struct {
+	PetType   string      `json:"petType"`
+	AgeMonths int         `json:"ageMonths"`
+	AgeRange  string      `json:"ageRange,omitempty"`
+	Plans     []QuotePlan `json:"plans"`
+}
+
+// QuotePlan is an eligible plan with its price for the pet profile and the underwriting decision
+// an order for it would get
+type QuotePlan struct {
+	PlanID           string               `json:"planId"`
+	Name             string               `json:"name"`
+	MonthlyPrice     money.Amount         `json:"monthlyPrice"`
+	AnnualLimit      money.Amount         `json:"annualLimit"`
+	WaitingPeriods   []QuoteWaitingPeriod `json:"waitingPeriods"`
+	ShopifyVariantID string               `json:"shopifyVariantId"`
+	Underwriting     UnderwritingDecision `json:"underwriting"`
+}
+
+// QuoteWaitingPeriod is the waiting period of a coverage type
+type QuoteWaitingPeriod struct {
+	CoverageType string `json:"coverageType"`
+	Days         int    `json:"days"`
+}
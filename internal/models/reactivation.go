@@ -0,0 +1,19 @@
+package models
+
+// ReactivatePolicyRequest reactivates a cancelled policy. The customer must declare the pet
+// health again; the declared conditions are pre-existing and not covered.
+type ReactivatePolicyRequest struct {
+	HealthDeclared        *bool    `json:"healthDeclared" binding:"required"`
+	PreexistingConditions []string `json:"preexistingConditions"`
+	KeepStartDate         bool     `json:"keepStartDate"`
+	RequestedBy           string   `json:"requestedBy" binding:"required"`
+}
+
+// PolicyReactivationResponse is the reactivated policy with the order that pays its first installment
+type PolicyReactivationResponse struct {
+	PolicyID             string `json:"policyId"`
+	Status               string `json:"status"`
+	PaymentInstallmentID string `json:"paymentInstallmentId"`
+	ShopifyOrderID       string `json:"shopifyOrderId"`
+	PayUrl               string `json:"payUrl"`
+}
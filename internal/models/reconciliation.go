@@ -0,0 +1,21 @@
+package models
+
+// ReconciliationReport summarizes a reconciliation of the installments with their Shopify orders
+type ReconciliationReport struct {
+	RunDate string `json:"runDate"`
+	// Checked counts the pending and overdue installments compared with Shopify
+	Checked int `json:"checked"`
+	// Fixed counts the installments marked paid because Shopify had them paid
+	Fixed int `json:"fixed"`
+	// OrdersMarkedPaid counts the Shopify orders of approved manual payments marked paid again
+	OrdersMarkedPaid int `json:"ordersMarkedPaid"`
+	// OrdersChecked counts the recent Shopify orders looked up locally
+	OrdersChecked int            `json:"ordersChecked"`
+	Discrepancies map[string]int `json:"discrepancies"`
+}
+
+// PaymentDiscrepancyRequest filters the discrepancies of a reconciliation run
+type PaymentDiscrepancyRequest struct {
+	Date string `form:"date" binding:"required,datetime=2006-01-02"`
+	Kind string `form:"kind" binding:"omitempty,oneof=amount_mismatch status_mismatch order_missing_locally installment_without_order"`
+}
@@ -0,0 +1,25 @@
+package models
+
+// UnderwritingInput is the pet and plan evaluated before a policy is issued
+type UnderwritingInput struct {
+	UserID    string
+	PetTypeID string
+	PlanID    string
+	Breed     string
+	Birthday  string
+	Neutered  string
+}
+
+// UnderwritingDecision is the outcome of evaluating the underwriting rules
+type UnderwritingDecision struct {
+	Decision   string   `json:"decision"`
+	Reasons    []string `json:"reasons"`
+	Exclusions []string `json:"exclusions"`
+}
+
+// UnderwritingReviewRequest resolves a policy held for manual review
+type UnderwritingReviewRequest struct {
+	Approve    *bool  `json:"approve" binding:"required"`
+	ReviewedBy string `json:"reviewedBy" binding:"required"`
+	Notes      string `json:"notes"`
+}
@@ -16,6 +16,7 @@ type PresentmentMoney struct {
 }
 
 type LineItem struct {
+	ID                 int                  `json:"id"`
 	ProductID          int                  `json:"product_id"`
 	CurrentQuantity    int                  `json:"current_quantity"`
 	Name               string               `json:"name"`
@@ -34,8 +35,9 @@ type DiscountAllocation struct {
 }
 
 type TaxLine struct {
-	Rate  float64 `json:"rate"`
-	Title string  `json:"title"`
+	Rate     float64  `json:"rate"`
+	Title    string   `json:"title"`
+	PriceSet PriceSet `json:"price_set"`
 }
 
 type Address struct {
@@ -81,7 +83,8 @@ type Webhook struct {
 	DefaultAddress           Address        `json:"default_address"`
 	ShippingLines            []ShippingLine `json:"shipping_lines"`
 	TaxLines                 []TaxLine      `json:"tax_lines"`
-	DiscountCodes            any            `json:"discount_codes"`
+	TaxesIncluded            bool           `json:"taxes_included"`
+	DiscountCodes            []Discount     `json:"discount_codes"`
 	// ShippingAddress          Address        `json:"shipping_address"`
 }
 
@@ -0,0 +1,24 @@
+package routers
+
+import (
+	"appa_subscriptions/internal/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CancellationRoutes struct {
+	handler *handlers.CancellationHandler
+}
+
+func NewCancellationRoutes(
+	handler *handlers.CancellationHandler,
+) *CancellationRoutes {
+	return &CancellationRoutes{
+		handler: handler,
+	}
+}
+
+func (r *CancellationRoutes) SetRouter(router *gin.Engine) {
+	router.POST("/admin/policies/:id/cancel", r.handler.HandleCancelPolicy)
+	router.POST("/admin/users/:id/policies/cancel", r.handler.HandleCancelUserPolicies)
+}
@@ -0,0 +1,23 @@
+package routers
+
+import (
+	"appa_subscriptions/internal/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CatalogRoutes struct {
+	handler *handlers.CatalogHandler
+}
+
+func NewCatalogRoutes(
+	handler *handlers.CatalogHandler,
+) *CatalogRoutes {
+	return &CatalogRoutes{
+		handler: handler,
+	}
+}
+
+func (r *CatalogRoutes) SetRouter(router *gin.Engine) {
+	router.POST("/admin/catalog/sync", r.handler.HandleSyncCatalog)
+}
@@ -0,0 +1,28 @@
+package routers
+
+import (
+	"appa_subscriptions/internal/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ClaimRoutes struct {
+	handler *handlers.ClaimHandler
+}
+
+func NewClaimRoutes(
+	handler *handlers.ClaimHandler,
+) *ClaimRoutes {
+	return &ClaimRoutes{
+		handler: handler,
+	}
+}
+
+func (r *ClaimRoutes) SetRouter(router *gin.Engine) {
+	router.POST("/claims", r.handler.HandleSubmitClaim)
+	router.GET("/admin/claims/:id", r.handler.HandleGetClaim)
+	router.POST("/admin/claims/:id/review", r.handler.HandleStartReview)
+	router.POST("/admin/claims/:id/approve", r.handler.HandleApproveClaim)
+	router.POST("/admin/claims/:id/reject", r.handler.HandleRejectClaim)
+	router.POST("/admin/claims/:id/pay", r.handler.HandleMarkClaimPaid)
+}
@@ -0,0 +1,24 @@
+package routers
+
+import (
+	"appa_subscriptions/internal/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CoverageRoutes struct {
+	handler *handlers.CoverageHandler
+}
+
+func NewCoverageRoutes(
+	handler *handlers.CoverageHandler,
+) *CoverageRoutes {
+	return &CoverageRoutes{
+		handler: handler,
+	}
+}
+
+func (r *CoverageRoutes) SetRouter(router *gin.Engine) {
+	router.GET("/admin/policies/:id/eligibility", r.handler.HandleIsEligible)
+	router.PUT("/admin/plans/:id/waiting-periods", r.handler.HandleSetWaitingPeriod)
+}
@@ -0,0 +1,25 @@
+package routers
+
+import (
+	"appa_subscriptions/internal/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DiscountRoutes struct {
+	handler *handlers.DiscountHandler
+}
+
+func NewDiscountRoutes(
+	handler *handlers.DiscountHandler,
+) *DiscountRoutes {
+	return &DiscountRoutes{
+		handler: handler,
+	}
+}
+
+func (r *DiscountRoutes) SetRouter(router *gin.Engine) {
+	router.GET("/admin/discount-rules", r.handler.HandleListRules)
+	router.POST("/admin/discount-rules", r.handler.HandleCreateRule)
+	router.PUT("/admin/discount-rules/:id", r.handler.HandleUpdateRule)
+}
@@ -0,0 +1,24 @@
+package routers
+
+import (
+	"appa_subscriptions/internal/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ExchangeRateRoutes struct {
+	handler *handlers.ExchangeRateHandler
+}
+
+func NewExchangeRateRoutes(
+	handler *handlers.ExchangeRateHandler,
+) *ExchangeRateRoutes {
+	return &ExchangeRateRoutes{
+		handler: handler,
+	}
+}
+
+func (r *ExchangeRateRoutes) SetRouter(router *gin.Engine) {
+	router.GET("/admin/exchange-rates", r.handler.HandleListRates)
+	router.POST("/admin/exchange-rates", r.handler.HandleSetManualRate)
+}
@@ -0,0 +1,23 @@
+package routers
+
+import (
+	"appa_subscriptions/internal/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JobRoutes struct {
+	handler *handlers.JobHandler
+}
+
+func NewJobRoutes(
+	handler *handlers.JobHandler,
+) *JobRoutes {
+	return &JobRoutes{
+		handler: handler,
+	}
+}
+
+func (r *JobRoutes) SetRouter(router *gin.Engine) {
+	router.GET("/admin/jobs/:name/dry-run", r.handler.HandleDryRun)
+}
@@ -0,0 +1,27 @@
+package routers
+
+import (
+	"appa_subscriptions/internal/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ManualPaymentRoutes struct {
+	handler *handlers.ManualPaymentHandler
+}
+
+func NewManualPaymentRoutes(
+	handler *handlers.ManualPaymentHandler,
+) *ManualPaymentRoutes {
+	return &ManualPaymentRoutes{
+		handler: handler,
+	}
+}
+
+func (r *ManualPaymentRoutes) SetRouter(router *gin.Engine) {
+	router.POST("/installments/:id/manual-payments", r.handler.HandleSubmitManualPayment)
+	router.GET("/admin/manual-payments", r.handler.HandleListManualPayments)
+	router.GET("/admin/manual-payments/:id/proof", r.handler.HandleGetProof)
+	router.POST("/admin/manual-payments/:id/approve", r.handler.HandleApproveManualPayment)
+	router.POST("/admin/manual-payments/:id/reject", r.handler.HandleRejectManualPayment)
+}
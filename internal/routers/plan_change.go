@@ -0,0 +1,24 @@
+package routers
+
+import (
+	"appa_subscriptions/internal/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PlanChangeRoutes struct {
+	handler *handlers.PlanChangeHandler
+}
+
+func NewPlanChangeRoutes(
+	handler *handlers.PlanChangeHandler,
+) *PlanChangeRoutes {
+	return &PlanChangeRoutes{
+		handler: handler,
+	}
+}
+
+func (r *PlanChangeRoutes) SetRouter(router *gin.Engine) {
+	router.POST("/admin/policies/:id/plan-change", r.handler.HandleChangePlan)
+	router.GET("/admin/policies/:id/plan-changes", r.handler.HandleGetPlanHistory)
+}
@@ -0,0 +1,23 @@
+package routers
+
+import (
+	"appa_subscriptions/internal/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PricingRoutes struct {
+	handler *handlers.PricingHandler
+}
+
+func NewPricingRoutes(
+	handler *handlers.PricingHandler,
+) *PricingRoutes {
+	return &PricingRoutes{
+		handler: handler,
+	}
+}
+
+func (r *PricingRoutes) SetRouter(router *gin.Engine) {
+	router.POST("/admin/quotes/price", r.handler.HandleQuotePlanPrice)
+}
@@ -0,0 +1,23 @@
+package routers
+
+import (
+	"appa_subscriptions/internal/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+type QuoteRoutes struct {
+	handler *handlers.QuoteHandler
+}
+
+func NewQuoteRoutes(
+	handler *handlers.QuoteHandler,
+) *QuoteRoutes {
+	return &QuoteRoutes{
+		handler: handler,
+	}
+}
+
+func (r *QuoteRoutes) SetRouter(router *gin.Engine) {
+	router.POST("/quotes", r.handler.HandleQuote)
+}
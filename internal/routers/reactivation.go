@@ -0,0 +1,23 @@
+package routers
+
+import (
+	"appa_subscriptions/internal/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReactivationRoutes struct {
+	handler *handlers.ReactivationHandler
+}
+
+func NewReactivationRoutes(
+	handler *handlers.ReactivationHandler,
+) *ReactivationRoutes {
+	return &ReactivationRoutes{
+		handler: handler,
+	}
+}
+
+func (r *ReactivationRoutes) SetRouter(router *gin.Engine) {
+	router.POST("/admin/policies/:id/reactivate", r.handler.HandleReactivatePolicy)
+}
@@ -0,0 +1,24 @@
+package routers
+
+import (
+	"appa_subscriptions/internal/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReconciliationRoutes struct {
+	handler *handlers.ReconciliationHandler
+}
+
+func NewReconciliationRoutes(
+	handler *handlers.ReconciliationHandler,
+) *ReconciliationRoutes {
+	return &ReconciliationRoutes{
+		handler: handler,
+	}
+}
+
+func (r *ReconciliationRoutes) SetRouter(router *gin.Engine) {
+	router.POST("/admin/reconciliation", r.handler.HandleReconcilePayments)
+	router.GET("/admin/reports/payment-discrepancies", r.handler.HandleListDiscrepancies)
+}
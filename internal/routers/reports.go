@@ -0,0 +1,23 @@
+package routers
+
+import (
+	"appa_subscriptions/internal/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReportRoutes struct {
+	handler *handlers.ReportHandler
+}
+
+func NewReportRoutes(
+	handler *handlers.ReportHandler,
+) *ReportRoutes {
+	return &ReportRoutes{
+		handler: handler,
+	}
+}
+
+func (r *ReportRoutes) SetRouter(router *gin.Engine) {
+	router.GET("/admin/reports/installments", r.handler.HandleInstallmentReport)
+}
@@ -0,0 +1,23 @@
+package routers
+
+import (
+	"appa_subscriptions/internal/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UnderwritingRoutes struct {
+	handler *handlers.UnderwritingHandler
+}
+
+func NewUnderwritingRoutes(
+	handler *handlers.UnderwritingHandler,
+) *UnderwritingRoutes {
+	return &UnderwritingRoutes{
+		handler: handler,
+	}
+}
+
+func (r *UnderwritingRoutes) SetRouter(router *gin.Engine) {
+	router.POST("/admin/policies/:id/underwriting-review", r.handler.HandleResolveReview)
+}
@@ -1,33 +1,53 @@
 package services
 
 import (
+	"context"
+
 	"go.uber.org/zap"
-	"gorm.io/gorm"
 
 	"appa_subscriptions/internal/domains"
 	dbModels "appa_subscriptions/pkg/db/models"
+	"appa_subscriptions/pkg/db/repositories"
 )
 
 type adminService struct {
-	db     *gorm.DB
-	logger *zap.Logger
+	users    repositories.UserRepository
+	policies repositories.PolicyRepository
+	logger   *zap.Logger
 }
 
 // NewAdminService creates a new instance of AdminService
-func NewAdminService(db *gorm.DB, logger *zap.Logger) domains.AdminService {
+func NewAdminService(
+	userRepo repositories.UserRepository,
+	policyRepo repositories.PolicyRepository,
+	logger *zap.Logger,
+) domains.AdminService {
 	return &adminService{
-		db:     db,
-		logger: logger,
+		users:    userRepo,
+		policies: policyRepo,
+		logger:   logger,
 	}
 }
 
 // CheckEmailExists checks if an email exists in the admin table
 func (s *adminService) CheckEmailExists(email string) (bool, error) {
-	var count int64
-	if err := s.db.Model(&dbModels.User{}).Where("email = ?", email).Count(&count).Error; err != nil {
-		s.logger.Error("failed to check email existence", zap.String("email", email), zap.Error(err))
-		return false, err
+	return s.users.EmailExists(context.Background(), email)
+}
+
+// GetUserByEmail returns the user with the email and their pets, with their policies
+func (s *adminService) GetUserByEmail(ctx context.Context, email string) (*dbModels.User, []dbModels.Policy, error) {
+	user, err := s.users.FindByEmail(ctx, email)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user == nil {
+		return nil, nil, domains.ErrUserNotFound
+	}
+
+	policies, err := s.policies.FindByUser(ctx, user.ID)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return count > 0, nil
+	return user, policies, nil
 }
@@ -0,0 +1,371 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"appa_subscriptions/internal/domains"
+	"appa_subscriptions/internal/models"
+	"appa_subscriptions/pkg/db"
+	dbModels "appa_subscriptions/pkg/db/models"
+	"appa_subscriptions/pkg/db/repositories"
+	"appa_subscriptions/pkg/shopify"
+)
+
+// shopifyCancelReasons maps the cancellation reasons to the Shopify order cancel reasons
+var shopifyCancelReasons = map[string]string{
+	models.CancellationReasonCustomerRequest: shopify.CancelReasonCustomer,
+	models.CancellationReasonNonPayment:      shopify.CancelReasonDeclined,
+	models.CancellationReasonFraud:           shopify.CancelReasonFraud,
+}
+
+type cancellationService struct {
+	db           *gorm.DB
+	shopify      shopify.Repository
+	installments repositories.PaymentInstallmentRepository
+	policies     repositories.PolicyRepository
+	loc          *time.Location
+	logger       *zap.Logger
+}
+
+// NewCancellationService creates a new instance of CancellationService
+func NewCancellationService(
+	db *gorm.DB,
+	shopifyRepo shopify.Repository,
+	installmentRepo repositories.PaymentInstallmentRepository,
+	policyRepo repositories.PolicyRepository,
+	loc *time.Location,
+	logger *zap.Logger,
+) domains.CancellationService {
+	return &cancellationService{
+		db:           db,
+		shopify:      shopifyRepo,
+		installments: installmentRepo,
+		policies:     policyRepo,
+		loc:          loc,
+		logger:       logger,
+	}
+}
+
+// CancelPolicy cancels a policy immediately or at the end of its billing period
+func (s *cancellationService) CancelPolicy(
+	ctx context.Context,
+	policyID string,
+	req models.CancelPolicyRequest,
+) ([]dbModels.Policy, error) {
+	var policy dbModels.Policy
+	err := s.db.WithContext(ctx).
+		Preload("User").
+		Preload("Pet").
+		Where("id = ?", policyID).
+		First(&policy).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domains.ErrPolicyNotFound
+	}
+	if err != nil {
+		s.logger.Error("getting policy", zap.Error(err), zap.String("policy_id", policyID))
+		return nil, err
+	}
+	if policy.Status == statusCancelled {
+		return nil, domains.ErrPolicyAlreadyCancelled
+	}
+
+	return s.cancelPolicies(ctx, []dbModels.Policy{policy}, req)
+}
+
+// CancelUserPolicies cancels every policy of a user that is not cancelled yet
+func (s *cancellationService) CancelUserPolicies(
+	ctx context.Context,
+	userID string,
+	req models.CancelPolicyRequest,
+) ([]dbModels.Policy, error) {
+	var policies []dbModels.Policy
+	err := s.db.WithContext(ctx).
+		Preload("User").
+		Preload("Pet").
+		Where("user_id = ? AND status <> ?", userID, statusCancelled).
+		Find(&policies).Error
+	if err != nil {
+		s.logger.Error("getting user policies", zap.Error(err), zap.String("user_id", userID))
+		return nil, err
+	}
+	if len(policies) == 0 {
+		return nil, domains.ErrPolicyNotFound
+	}
+
+	return s.cancelPolicies(ctx, policies, req)
+}
+
+// CompleteDueCancellations cancels the policies whose end of period cancellation is due
+func (s *cancellationService) CompleteDueCancellations(ctx context.Context) error {
+	today := time.Now().In(s.loc).Format("2006-01-02")
+
+	var policyIDs []string
+	err := s.db.WithContext(ctx).
+		Model(&dbModels.Policy{}).
+		Where("status = ? AND cancellation_effective_date <= ?", statusPendingCancellation, today).
+		Pluck("id", &policyIDs).Error
+	if err != nil {
+		s.logger.Error("getting due cancellations", zap.Error(err))
+		return err
+	}
+	if len(policyIDs) == 0 {
+		return nil
+	}
+
+	err = s.db.WithContext(ctx).
+		Model(&dbModels.Policy{}).
+		Where("id IN ? AND status = ?", policyIDs, statusPendingCancellation).
+		Updates(map[string]any{
+			"status":       statusCancelled,
+			"cancelled_at": time.Now().In(s.loc),
+		}).Error
+	if err != nil {
+		s.logger.Error("completing due cancellations", zap.Error(err), zap.Strings("policy_ids", policyIDs))
+		return err
+	}
+
+	s.voidOpenInstallments(ctx, policyIDs)
+
+	s.logger.Info("completed due cancellations", zap.Int("policies", len(policyIDs)))
+	return nil
+}
+
+// PlanDueCancellations returns the policies CompleteDueCancellations would cancel if it ran at
+// the date and the installments it would void, without calling Shopify or writing
+func (s *cancellationService) PlanDueCancellations(ctx context.Context, date time.Time) (*models.DueCancellationPlan, error) {
+	day := date.In(s.loc).Format("2006-01-02")
+
+	var policies []dbModels.Policy
+	err := s.db.WithContext(ctx).
+		Where("status = ? AND cancellation_effective_date <= ?", statusPendingCancellation, day).
+		Order("cancellation_effective_date").
+		Find(&policies).Error
+	if err != nil {
+		s.logger.Error("getting due cancellations", zap.Error(err))
+		return nil, err
+	}
+
+	plan := &models.DueCancellationPlan{
+		Date:     day,
+		Policies: make([]models.PlannedCancellation, 0, len(policies)),
+		Voided:   []models.PlannedVoidedPayment{},
+	}
+	if len(policies) == 0 {
+		return plan, nil
+	}
+
+	policyIDs := make([]string, 0, len(policies))
+	for _, policy := range policies {
+		policyIDs = append(policyIDs, policy.ID)
+		plan.Policies = append(plan.Policies, models.PlannedCancellation{
+			PolicyID:      policy.ID,
+			EffectiveDate: policy.CancellationEffectiveDate.Format("2006-01-02"),
+		})
+	}
+
+	installments, err := s.findVoidableInstallments(ctx, policyIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, installment := range installments {
+		plan.Voided = append(plan.Voided, models.PlannedVoidedPayment{
+			PaymentInstallmentID: installment.ID,
+			ShopifyOrderID:       installment.ShopifyOrderID,
+		})
+	}
+
+	return plan, nil
+}
+
+// cancelPolicies records the cancellation of the policies. Immediate cancellations are
+// cancelled today; end of period cancellations stay pending until the next payment date,
+// when CompleteDueCancellations cancels them. The customer is emailed once per user.
+func (s *cancellationService) cancelPolicies(
+	ctx context.Context,
+	policies []dbModels.Policy,
+	req models.CancelPolicyRequest,
+) ([]dbModels.Policy, error) {
+	var (
+		now       = time.Now().In(s.loc)
+		today     = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, s.loc)
+		cancelled []string
+		err       error
+	)
+
+	tx := s.db.Begin().WithContext(ctx)
+	for i := range policies {
+		policy := &policies[i]
+
+		effectiveDate := today
+		status := statusCancelled
+		if req.Effective == models.CancellationEndOfPeriod && policy.NextPayment.After(today) {
+			effectiveDate = policy.NextPayment
+			status = statusPendingCancellation
+		}
+
+		policy.Status = status
+		policy.CancellationReason = &req.Reason
+		policy.CancellationRequestedAt = &now
+		policy.CancellationEffectiveDate = &effectiveDate
+		if req.Notes != "" {
+			policy.CancellationNotes = &req.Notes
+		}
+		if status == statusCancelled {
+			policy.CancelledAt = &now
+			cancelled = append(cancelled, policy.ID)
+		}
+
+		err = tx.Model(&dbModels.Policy{}).
+			Where("id = ?", policy.ID).
+			Updates(map[string]any{
+				"status":                      policy.Status,
+				"cancellation_reason":         policy.CancellationReason,
+				"cancellation_notes":          policy.CancellationNotes,
+				"cancellation_requested_at":   policy.CancellationRequestedAt,
+				"cancellation_effective_date": policy.CancellationEffectiveDate.Format("2006-01-02"),
+				"cancelled_at":                policy.CancelledAt,
+			}).Error
+		if err != nil {
+			s.logger.Error("cancelling policy", zap.Error(err), zap.String("policy_id", policy.ID))
+			db.DBRollback(tx, &err)
+			return nil, err
+		}
+	}
+	db.DBRollback(tx, &err)
+
+	if len(cancelled) > 0 {
+		s.voidOpenInstallments(ctx, cancelled)
+	}
+
+	s.queueCancellationEmails(policies, today)
+
+	return policies, nil
+}
+
+// voidOpenInstallments cancels the unpaid installments of the cancelled policies, and their
+// Shopify orders, when every policy billed in the installment is cancelled. Installments that
+// still bill an active policy are left open.
+func (s *cancellationService) voidOpenInstallments(ctx context.Context, policyIDs []string) {
+	installments, err := s.findVoidableInstallments(ctx, policyIDs)
+	if err != nil {
+		return
+	}
+
+	for _, installment := range installments {
+		if installment.ShopifyOrderID != "" {
+			_, err := s.shopify.CancelOrder(ctx, shopify.CancelOrderRequest{
+				OrderID:   installment.ShopifyOrderID,
+				Reason:    s.shopifyCancelReason(ctx, installment.ID),
+				StaffNote: "policy cancelled",
+			})
+			if err != nil {
+				s.logger.Error("cancelling shopify order", zap.Error(err), zap.String("order_id", installment.ShopifyOrderID))
+				continue
+			}
+		}
+
+		// the repository logs the error, the other installments are still voided
+		_, _ = s.installments.MarkCancelled(s.db, ctx, []string{installment.ID})
+	}
+}
+
+// findVoidableInstallments returns the unpaid installments of the policies in which every
+// policy billed is cancelled, counting the policies as cancelled
+func (s *cancellationService) findVoidableInstallments(ctx context.Context, policyIDs []string) ([]dbModels.PaymentInstallment, error) {
+	var installments []dbModels.PaymentInstallment
+	for _, policyID := range policyIDs {
+		open, err := s.installments.FindByPolicy(s.db, ctx, policyID, dbModels.OpenInstallmentStatuses...)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, installment := range open {
+			if slices.ContainsFunc(installments, func(i dbModels.PaymentInstallment) bool { return i.ID == installment.ID }) {
+				continue
+			}
+
+			billed, err := s.policies.FindByInstallments(ctx, []string{installment.ID})
+			if err != nil {
+				return nil, err
+			}
+			stillBilled := slices.ContainsFunc(billed, func(policy dbModels.Policy) bool {
+				return policy.Status != statusCancelled && !slices.Contains(policyIDs, policy.ID)
+			})
+			if !stillBilled {
+				installments = append(installments, installment)
+			}
+		}
+	}
+
+	return installments, nil
+}
+
+// shopifyCancelReason returns the Shopify cancel reason matching the cancellation reason of the
+// policies billed in the installment
+func (s *cancellationService) shopifyCancelReason(ctx context.Context, installmentID string) string {
+	var reason string
+	err := s.db.WithContext(ctx).
+		Model(&dbModels.Policy{}).
+		Select("policies.cancellation_reason").
+		Joins("JOIN policies_payments pp ON pp.policy_id = policies.id").
+		Where("pp.payment_installment_id = ? AND policies.cancellation_reason IS NOT NULL", installmentID).
+		Limit(1).
+		Scan(&reason).Error
+	if err != nil {
+		s.logger.Warn("getting cancellation reason", zap.Error(err), zap.String("installment_id", installmentID))
+	}
+
+	if shopifyReason, ok := shopifyCancelReasons[reason]; ok {
+		return shopifyReason
+	}
+	return shopify.CancelReasonOther
+}
+
+// queueCancellationEmails queues one cancellation email per user with the cancelled pets
+func (s *cancellationService) queueCancellationEmails(policies []dbModels.Policy, today time.Time) {
+	var userIDs []string
+	userPolicies := make(map[string][]dbModels.Policy)
+	for _, policy := range policies {
+		if !slices.Contains(userIDs, policy.UserID) {
+			userIDs = append(userIDs, policy.UserID)
+		}
+		userPolicies[policy.UserID] = append(userPolicies[policy.UserID], policy)
+	}
+
+	for _, userID := range userIDs {
+		policies := userPolicies[userID]
+		if policies[0].User == nil {
+			s.logger.Warn("policy without user, skipping cancellation email", zap.String("policy_id", policies[0].ID))
+			continue
+		}
+
+		var (
+			pets     = make([]string, 0, len(policies))
+			daysLeft int
+		)
+		for _, policy := range policies {
+			if policy.Pet != nil {
+				pets = append(pets, policy.Pet.Name)
+			}
+			if days := int(policy.CancellationEffectiveDate.Sub(today).Hours() / 24); days > daysLeft {
+				daysLeft = days
+			}
+		}
+
+		notificationJobsQueue <- notificationJob{
+			vars: models.ConfirmationOrderEmailVars{
+				FirtsName: policies[0].User.Name,
+				PetsList:  pets,
+				DaysLeft:  daysLeft,
+			},
+			email:    policies[0].User.Email,
+			template: "cancellation",
+		}
+	}
+}
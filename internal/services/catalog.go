@@ -0,0 +1,443 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"appa_subscriptions/internal/domains"
+	"appa_subscriptions/internal/models"
+	"appa_subscriptions/pkg/db"
+	dbModels "appa_subscriptions/pkg/db/models"
+	"appa_subscriptions/pkg/db/repositories"
+	"appa_subscriptions/pkg/money"
+	"appa_subscriptions/pkg/shopify"
+)
+
+type catalogService struct {
+	db           *gorm.DB
+	shopify      shopify.Repository
+	catalogCache *repositories.CatalogCache
+	productQuery string
+	loc          *time.Location
+	logger       *zap.Logger
+}
+
+// petAttributeOptions maps the Shopify variant options to the pet attribute tables.
+// Optional options only apply to products that define them, e.g. felines are not sized.
+var petAttributeOptions = []struct {
+	option   string
+	table    string
+	required bool
+}{
+	{shopify.AgeOptionName, dbModels.PetAgeRange{}.TableName(), true},
+	{shopify.SizeOptionName, dbModels.PetSize{}.TableName(), false},
+	{shopify.ConditionOptionName, dbModels.PetCondition{}.TableName(), true},
+}
+
+// petAttributeRow is the common shape of the pet attribute tables
+type petAttributeRow struct {
+	ID        string
+	Name      string
+	PetTypeID string
+	ShopifyID string
+}
+
+// NewCatalogService creates a new instance of CatalogService
+func NewCatalogService(
+	db *gorm.DB,
+	shopifyRepo shopify.Repository,
+	catalogCache *repositories.CatalogCache,
+	productQuery string,
+	loc *time.Location,
+	logger *zap.Logger,
+) domains.CatalogService {
+	return &catalogService{
+		db:           db,
+		shopify:      shopifyRepo,
+		catalogCache: catalogCache,
+		productQuery: productQuery,
+		loc:          loc,
+		logger:       logger,
+	}
+}
+
+// SyncCatalog reads the plan products from Shopify and upserts the plans, the pet attributes
+// they are sold with and the price of every variant, flagging variants that cannot be mapped.
+func (s *catalogService) SyncCatalog(ctx context.Context) (report *models.CatalogSyncReport, err error) {
+	s.logger.Info("starting catalog sync")
+
+	products, err := s.shopify.GetProducts(ctx, s.productQuery)
+	if err != nil {
+		s.logger.Error("getting products from shopify", zap.Error(err))
+		return nil, err
+	}
+
+	var petTypes []dbModels.PetType
+	if err := s.db.WithContext(ctx).Find(&petTypes).Error; err != nil {
+		s.logger.Error("getting pet types", zap.Error(err))
+		return nil, err
+	}
+
+	report = &models.CatalogSyncReport{Issues: make([]models.CatalogIssue, 0)}
+	tx := s.db.Begin().WithContext(ctx)
+	// runs after the commit, the cached lookups read the synced catalog
+	defer s.catalogCache.Invalidate()
+	defer db.DBRollback(tx, &err)
+
+	attributesIDs := make(map[string]string)
+	for _, product := range products {
+		if !isPlanProduct(product) {
+			continue
+		}
+		report.Products++
+
+		petType := findProductPetType(product, petTypes)
+		if petType == nil {
+			report.AddIssue(product.ID, "", "pet type could not be determined from product tags or title")
+			continue
+		}
+
+		plan, err := s.upsertPlan(tx, ctx, product, petType.ID, report)
+		if err != nil {
+			return nil, err
+		}
+		if plan == nil {
+			continue
+		}
+
+		for _, variant := range product.Variants.Nodes {
+			report.Variants++
+
+			mapped := true
+			variantAttributes := make(map[string]string)
+			for _, attribute := range petAttributeOptions {
+				if !attribute.required && !hasProductOption(product, attribute.option) {
+					continue
+				}
+
+				selected := shopify.GetSelectedOption(variant.SelectedOptions, attribute.option)
+				if selected == nil || selected.Value == "" {
+					report.AddIssue(product.ID, variant.ID, fmt.Sprintf("variant has no %q option", attribute.option))
+					mapped = false
+					continue
+				}
+
+				key := fmt.Sprintf("%s|%s|%s", attribute.table, petType.ID, strings.ToLower(selected.Value))
+				attributeID, exist := attributesIDs[key]
+				if !exist {
+					attributeID, err = s.upsertPetAttribute(tx, ctx, attribute.table, petType.ID, selected, report)
+					if err != nil {
+						return nil, err
+					}
+					attributesIDs[key] = attributeID
+				}
+				variantAttributes[attribute.option] = attributeID
+			}
+
+			if !mapped {
+				continue
+			}
+			if err := s.upsertPlanPrice(tx, ctx, product, plan.ID, variant, variantAttributes, report); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, issue := range report.Issues {
+		s.logger.Warn("catalog item not mapped", zap.String("product_id", issue.ProductID), zap.String("variant_id", issue.VariantID), zap.String("reason", issue.Reason))
+	}
+	s.logger.Info("completed catalog sync", zap.Int("products", report.Products), zap.Int("variants", report.Variants), zap.Int("issues", len(report.Issues)))
+
+	return report, nil
+}
+
+// PlanCatalogSync returns what SyncCatalog would start from, without calling Shopify or writing.
+// The products are only known when the sync reads them.
+func (s *catalogService) PlanCatalogSync(ctx context.Context) (*models.CatalogSyncPlan, error) {
+	var plans int64
+	if err := s.db.WithContext(ctx).Model(&dbModels.Plan{}).Count(&plans).Error; err != nil {
+		s.logger.Error("counting plans", zap.Error(err))
+		return nil, err
+	}
+
+	return &models.CatalogSyncPlan{
+		ProductQuery: s.productQuery,
+		Plans:        int(plans),
+	}, nil
+}
+
+// upsertPlan creates or updates the plan sold by the product. It returns nil when the
+// product cannot be mapped to a plan.
+func (s *catalogService) upsertPlan(
+	tx *gorm.DB,
+	ctx context.Context,
+	product shopify.Product,
+	petTypeID string,
+	report *models.CatalogSyncReport,
+) (*dbModels.Plan, error) {
+	shopifyID := shopify.LegacyID(product.ID)
+
+	var plan dbModels.Plan
+	if err := tx.WithContext(ctx).Where(dbModels.Plan{ShopifyID: shopifyID}).Limit(1).Find(&plan).Error; err != nil {
+		s.logger.Error("getting plan", zap.Error(err), zap.String("shopify_id", shopifyID))
+		return nil, err
+	}
+
+	monthlyPrice, ok := lowestVariantPrice(product.Variants.Nodes)
+	if !ok {
+		report.AddIssue(product.ID, "", "product has no variant with a valid price")
+		return nil, nil
+	}
+
+	var annualLimit *money.Amount
+	if product.AnnualLimit != nil {
+		limit, err := money.Parse(product.AnnualLimit.Value)
+		if err != nil {
+			report.AddIssue(product.ID, "", fmt.Sprintf("invalid annual_limit metafield %q", product.AnnualLimit.Value))
+		} else {
+			annualLimit = &limit
+		}
+	}
+
+	if plan.ID == "" {
+		if annualLimit == nil {
+			report.AddIssue(product.ID, "", "new plan product has no custom.annual_limit metafield")
+			return nil, nil
+		}
+
+		plan = dbModels.Plan{
+			Name:         strings.ToLower(product.Title),
+			MonthlyPrice: monthlyPrice,
+			AnnualLimit:  *annualLimit,
+			ShopifyID:    shopifyID,
+			PetTypeID:    petTypeID,
+		}
+		if err := tx.WithContext(ctx).Omit("CreatedAt").Create(&plan).Error; err != nil {
+			s.logger.Error("creating plan", zap.Error(err), zap.String("shopify_id", shopifyID))
+			return nil, err
+		}
+		report.PlansCreated++
+		return &plan, nil
+	}
+
+	updates := map[string]any{
+		"name":          strings.ToLower(product.Title),
+		"monthly_price": monthlyPrice,
+		"pet_type_id":   petTypeID,
+	}
+	if annualLimit != nil {
+		updates["annual_limit"] = *annualLimit
+	}
+	if err := tx.WithContext(ctx).Model(&plan).Updates(updates).Error; err != nil {
+		s.logger.Error("updating plan", zap.Error(err), zap.String("plan_id", plan.ID))
+		return nil, err
+	}
+	report.PlansUpdated++
+
+	return &plan, nil
+}
+
+// upsertPetAttribute creates or updates a pet attribute of the given pet type from a variant option.
+// Rows are matched by Shopify option value id first and by name within the pet type otherwise.
+func (s *catalogService) upsertPetAttribute(
+	tx *gorm.DB,
+	ctx context.Context,
+	table, petTypeID string,
+	selected *shopify.SelectedOption,
+	report *models.CatalogSyncReport,
+) (string, error) {
+	var (
+		row       petAttributeRow
+		name      = strings.ToLower(selected.Value)
+		shopifyID string
+	)
+	if selected.OptionValue != nil {
+		shopifyID = shopify.LegacyID(selected.OptionValue.ID)
+	}
+
+	if shopifyID != "" {
+		err := tx.WithContext(ctx).Table(table).
+			Where("pet_type_id = ? AND shopify_id = ?", petTypeID, shopifyID).
+			Limit(1).Find(&row).Error
+		if err != nil {
+			s.logger.Error("getting pet attribute by shopify id", zap.Error(err), zap.String("table", table))
+			return "", err
+		}
+	}
+	if row.ID == "" {
+		err := tx.WithContext(ctx).Table(table).
+			Where("pet_type_id = ? AND name = ?", petTypeID, name).
+			Limit(1).Find(&row).Error
+		if err != nil {
+			s.logger.Error("getting pet attribute by name", zap.Error(err), zap.String("table", table))
+			return "", err
+		}
+	}
+
+	if row.ID == "" {
+		err := tx.WithContext(ctx).Raw(
+			fmt.Sprintf("INSERT INTO %s (name, pet_type_id, shopify_id) VALUES (?, ?, ?) RETURNING id", table),
+			name, petTypeID, shopifyID,
+		).Scan(&row.ID).Error
+		if err != nil {
+			s.logger.Error("creating pet attribute", zap.Error(err), zap.String("table", table), zap.String("name", name))
+			return "", err
+		}
+		report.AttributesCreated++
+		switch table {
+		case dbModels.PetAgeRange{}.TableName():
+			report.AddIssue("", "", fmt.Sprintf("new age range %q has no age bounds, quotes will not use it", name))
+		case dbModels.PetCondition{}.TableName():
+			report.AddIssue("", "", fmt.Sprintf("new condition %q has no gender or neutered status, quotes will not use it", name))
+		}
+		return row.ID, nil
+	}
+
+	if row.Name == name && (row.ShopifyID == shopifyID || shopifyID == "") {
+		return row.ID, nil
+	}
+
+	updates := map[string]any{"name": name}
+	if shopifyID != "" {
+		updates["shopify_id"] = shopifyID
+	}
+	if err := tx.WithContext(ctx).Table(table).Where("id = ?", row.ID).Updates(updates).Error; err != nil {
+		s.logger.Error("updating pet attribute", zap.Error(err), zap.String("table", table), zap.String("id", row.ID))
+		return "", err
+	}
+	report.AttributesUpdated++
+
+	return row.ID, nil
+}
+
+// upsertPlanPrice keeps the current price of a variant in the price matrix. A changed price
+// closes the current row and opens a new one, so past prices remain available by date.
+func (s *catalogService) upsertPlanPrice(
+	tx *gorm.DB,
+	ctx context.Context,
+	product shopify.Product,
+	planID string,
+	variant shopify.Variant,
+	variantAttributes map[string]string,
+	report *models.CatalogSyncReport,
+) error {
+	price, err := money.Parse(variant.Price)
+	if err != nil {
+		report.AddIssue(product.ID, variant.ID, fmt.Sprintf("invalid variant price %q", variant.Price))
+		return nil
+	}
+
+	var (
+		current   dbModels.PlanPrice
+		variantID = shopify.LegacyID(variant.ID)
+		now       = time.Now().In(s.loc)
+		sizeID    *string
+	)
+	if id, exist := variantAttributes[shopify.SizeOptionName]; exist {
+		sizeID = &id
+	}
+
+	query := tx.WithContext(ctx).
+		Where(
+			"plan_id = ? AND age_range_id = ? AND condition_id = ? AND effective_to IS NULL",
+			planID,
+			variantAttributes[shopify.AgeOptionName],
+			variantAttributes[shopify.ConditionOptionName],
+		)
+	if sizeID == nil {
+		query = query.Where("size_id IS NULL")
+	} else {
+		query = query.Where("size_id = ?", *sizeID)
+	}
+	if err := query.Limit(1).Find(&current).Error; err != nil {
+		s.logger.Error("getting current plan price", zap.Error(err), zap.String("variant_id", variantID))
+		return err
+	}
+
+	if current.ID != "" && current.MonthlyPrice.Equal(price) && current.ShopifyVariantID == variantID {
+		return nil
+	}
+
+	if current.ID != "" {
+		err := tx.WithContext(ctx).Model(&current).Update("effective_to", now).Error
+		if err != nil {
+			s.logger.Error("closing plan price", zap.Error(err), zap.String("plan_price_id", current.ID))
+			return err
+		}
+	}
+
+	planPrice := dbModels.PlanPrice{
+		PlanID:           planID,
+		AgeRangeID:       variantAttributes[shopify.AgeOptionName],
+		SizeID:           sizeID,
+		ConditionID:      variantAttributes[shopify.ConditionOptionName],
+		MonthlyPrice:     price,
+		ShopifyVariantID: variantID,
+		EffectiveFrom:    now,
+	}
+	if err := tx.WithContext(ctx).Create(&planPrice).Error; err != nil {
+		s.logger.Error("creating plan price", zap.Error(err), zap.String("variant_id", variantID))
+		return err
+	}
+	report.PricesChanged++
+
+	return nil
+}
+
+// hasProductOption reports whether the product defines the option
+func hasProductOption(product shopify.Product, name string) bool {
+	return slices.ContainsFunc(product.Options, func(option shopify.ProductOption) bool {
+		return option.Name == name
+	})
+}
+
+// isPlanProduct reports whether the product is sold with pet attribute options
+func isPlanProduct(product shopify.Product) bool {
+	return hasProductOption(product, shopify.AgeOptionName)
+}
+
+// findProductPetType finds the pet type of a product by its tags, or by its title otherwise
+func findProductPetType(product shopify.Product, petTypes []dbModels.PetType) *dbModels.PetType {
+	for i, petType := range petTypes {
+		for _, tag := range product.Tags {
+			if strings.EqualFold(tag, petType.Name) {
+				return &petTypes[i]
+			}
+		}
+	}
+
+	title := strings.ToLower(product.Title)
+	for i, petType := range petTypes {
+		if strings.Contains(title, petType.Name) {
+			return &petTypes[i]
+		}
+	}
+
+	return nil
+}
+
+// lowestVariantPrice returns the lowest price among the variants
+func lowestVariantPrice(variants []shopify.Variant) (money.Amount, bool) {
+	var (
+		lowest money.Amount
+		found  bool
+	)
+	for _, variant := range variants {
+		price, err := money.Parse(variant.Price)
+		if err != nil {
+			continue
+		}
+		if !found || price.LessThan(lowest) {
+			lowest = price
+			found = true
+		}
+	}
+
+	return lowest, found
+}
@@ -0,0 +1,344 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"appa_subscriptions/internal/domains"
+	"appa_subscriptions/internal/models"
+	"appa_subscriptions/pkg/db"
+	dbModels "appa_subscriptions/pkg/db/models"
+	"appa_subscriptions/pkg/money"
+)
+
+// claimTransitions lists the states each claim state can move to
+var claimTransitions = map[string][]string{
+	dbModels.ClaimSubmitted:         {dbModels.ClaimInReview, dbModels.ClaimApproved, dbModels.ClaimPartiallyApproved, dbModels.ClaimRejected},
+	dbModels.ClaimInReview:          {dbModels.ClaimApproved, dbModels.ClaimPartiallyApproved, dbModels.ClaimRejected},
+	dbModels.ClaimApproved:          {dbModels.ClaimPaid},
+	dbModels.ClaimPartiallyApproved: {dbModels.ClaimPaid},
+}
+
+type claimService struct {
+	db              *gorm.DB
+	coverageService domains.CoverageService
+	loc             *time.Location
+	logger          *zap.Logger
+}
+
+// NewClaimService creates a new instance of ClaimService
+func NewClaimService(
+	db *gorm.DB,
+	coverageService domains.CoverageService,
+	loc *time.Location,
+	logger *zap.Logger,
+) domains.ClaimService {
+	return &claimService{
+		db:              db,
+		coverageService: coverageService,
+		loc:             loc,
+		logger:          logger,
+	}
+}
+
+// SubmitClaim registers a claim for a policy. Claims the policy does not cover are
+// stored as rejected with the reason, so the customer can be told why.
+func (s *claimService) SubmitClaim(
+	ctx context.Context,
+	req models.SubmitClaimRequest,
+) (*dbModels.Claim, error) {
+	incidentDate, err := time.ParseInLocation("2006-01-02", req.IncidentDate, s.loc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: incident date must be formatted as YYYY-MM-DD", domains.ErrInvalidClaim)
+	}
+	if incidentDate.After(time.Now().In(s.loc)) {
+		return nil, fmt.Errorf("%w: incident date is in the future", domains.ErrInvalidClaim)
+	}
+
+	var policy dbModels.Policy
+	err = s.db.WithContext(ctx).Where("id = ?", req.PolicyID).First(&policy).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domains.ErrPolicyNotFound
+	}
+	if err != nil {
+		s.logger.Error("getting policy", zap.Error(err), zap.String("policy_id", req.PolicyID))
+		return nil, err
+	}
+
+	claim := dbModels.Claim{
+		PolicyID:      policy.ID,
+		CoverageType:  req.CoverageType,
+		VetClinic:     req.VetClinic,
+		Diagnosis:     req.Diagnosis,
+		IncidentDate:  incidentDate,
+		InvoiceAmount: req.InvoiceAmount,
+		Status:        dbModels.ClaimSubmitted,
+		SubmittedAt:   time.Now().In(s.loc),
+		Attachments:   make([]dbModels.ClaimAttachment, 0, len(req.Attachments)),
+	}
+	for _, attachment := range req.Attachments {
+		claimAttachment := dbModels.ClaimAttachment{
+			FileName: attachment.FileName,
+			URL:      attachment.URL,
+		}
+		if attachment.ContentType != "" {
+			claimAttachment.ContentType = &attachment.ContentType
+		}
+		claim.Attachments = append(claim.Attachments, claimAttachment)
+	}
+
+	reason, err := s.checkCoverage(ctx, &policy, &claim)
+	if err != nil {
+		return nil, err
+	}
+	if reason != "" {
+		claim.Status = dbModels.ClaimRejected
+		claim.RejectionReason = &reason
+	}
+
+	if err := s.db.WithContext(ctx).Create(&claim).Error; err != nil {
+		s.logger.Error("creating claim", zap.Error(err), zap.String("policy_id", policy.ID))
+		return nil, err
+	}
+
+	return &claim, nil
+}
+
+// GetClaim returns a claim with its attachments
+func (s *claimService) GetClaim(ctx context.Context, claimID string) (*dbModels.Claim, error) {
+	var claim dbModels.Claim
+	err := s.db.WithContext(ctx).Preload("Attachments").Where("id = ?", claimID).First(&claim).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domains.ErrClaimNotFound
+	}
+	if err != nil {
+		s.logger.Error("getting claim", zap.Error(err), zap.String("claim_id", claimID))
+		return nil, err
+	}
+
+	return &claim, nil
+}
+
+// StartReview moves a submitted claim into review
+func (s *claimService) StartReview(
+	ctx context.Context,
+	claimID string,
+	req models.ClaimReviewRequest,
+) (claim *dbModels.Claim, err error) {
+	tx := s.db.Begin().WithContext(ctx)
+	defer db.DBRollback(tx, &err)
+
+	claim, err = s.lockClaim(tx, claimID)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkClaimTransition(claim.Status, dbModels.ClaimInReview); err != nil {
+		return nil, err
+	}
+
+	claim.Status = dbModels.ClaimInReview
+	claim.ReviewedBy = &req.ReviewedBy
+	if req.Notes != "" {
+		claim.ReviewerNotes = &req.Notes
+	}
+	if err = tx.Omit(clause.Associations).Save(claim).Error; err != nil {
+		s.logger.Error("updating claim", zap.Error(err), zap.String("claim_id", claimID))
+		return nil, err
+	}
+
+	return claim, nil
+}
+
+// ApproveClaim approves a claim and deducts the approved amount from the policy remaining
+// balance in the same transaction. The amount is capped to the invoice and to the remaining
+// balance; a capped claim is partially approved.
+func (s *claimService) ApproveClaim(
+	ctx context.Context,
+	claimID string,
+	req models.ApproveClaimRequest,
+) (claim *dbModels.Claim, err error) {
+	tx := s.db.Begin().WithContext(ctx)
+	defer db.DBRollback(tx, &err)
+
+	claim, err = s.lockClaim(tx, claimID)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkClaimTransition(claim.Status, dbModels.ClaimApproved); err != nil {
+		return nil, err
+	}
+
+	var policy dbModels.Policy
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", claim.PolicyID).
+		First(&policy).Error
+	if err != nil {
+		s.logger.Error("locking policy", zap.Error(err), zap.String("policy_id", claim.PolicyID))
+		return nil, err
+	}
+
+	reason, err := s.checkCoverage(ctx, &policy, claim)
+	if err != nil {
+		return nil, err
+	}
+	if reason != "" {
+		err = fmt.Errorf("%w: %s", domains.ErrClaimNotCovered, reason)
+		return nil, err
+	}
+
+	approvedAmount := money.Min(money.Min(req.ApprovedAmount, claim.InvoiceAmount), policy.RemainingBalance)
+	if !approvedAmount.IsPositive() {
+		err = domains.ErrInsufficientBalance
+		return nil, err
+	}
+
+	result := tx.Model(&dbModels.Policy{}).
+		Where("id = ? AND remaining_balance >= ?", policy.ID, approvedAmount).
+		Update("remaining_balance", gorm.Expr("remaining_balance - ?", approvedAmount))
+	if result.Error != nil {
+		err = result.Error
+		s.logger.Error("deducting policy remaining balance", zap.Error(err), zap.String("policy_id", policy.ID))
+		return nil, err
+	}
+	if result.RowsAffected != 1 {
+		err = domains.ErrInsufficientBalance
+		return nil, err
+	}
+
+	now := time.Now().In(s.loc)
+	claim.Status = dbModels.ClaimApproved
+	if approvedAmount.LessThan(claim.InvoiceAmount) {
+		claim.Status = dbModels.ClaimPartiallyApproved
+	}
+	claim.ApprovedAmount = &approvedAmount
+	claim.ReviewedBy = &req.ReviewedBy
+	claim.ReviewedAt = &now
+	if req.Notes != "" {
+		claim.ReviewerNotes = &req.Notes
+	}
+	if err = tx.Omit(clause.Associations).Save(claim).Error; err != nil {
+		s.logger.Error("updating claim", zap.Error(err), zap.String("claim_id", claimID))
+		return nil, err
+	}
+
+	return claim, nil
+}
+
+// RejectClaim rejects a claim with a reason
+func (s *claimService) RejectClaim(
+	ctx context.Context,
+	claimID string,
+	req models.RejectClaimRequest,
+) (claim *dbModels.Claim, err error) {
+	tx := s.db.Begin().WithContext(ctx)
+	defer db.DBRollback(tx, &err)
+
+	claim, err = s.lockClaim(tx, claimID)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkClaimTransition(claim.Status, dbModels.ClaimRejected); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().In(s.loc)
+	claim.Status = dbModels.ClaimRejected
+	claim.RejectionReason = &req.Reason
+	claim.ReviewedBy = &req.ReviewedBy
+	claim.ReviewedAt = &now
+	if err = tx.Omit(clause.Associations).Save(claim).Error; err != nil {
+		s.logger.Error("updating claim", zap.Error(err), zap.String("claim_id", claimID))
+		return nil, err
+	}
+
+	return claim, nil
+}
+
+// MarkClaimPaid records the payment of an approved claim
+func (s *claimService) MarkClaimPaid(ctx context.Context, claimID string) (claim *dbModels.Claim, err error) {
+	tx := s.db.Begin().WithContext(ctx)
+	defer db.DBRollback(tx, &err)
+
+	claim, err = s.lockClaim(tx, claimID)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkClaimTransition(claim.Status, dbModels.ClaimPaid); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().In(s.loc)
+	claim.Status = dbModels.ClaimPaid
+	claim.PaidAt = &now
+	if err = tx.Omit(clause.Associations).Save(claim).Error; err != nil {
+		s.logger.Error("updating claim", zap.Error(err), zap.String("claim_id", claimID))
+		return nil, err
+	}
+
+	return claim, nil
+}
+
+// lockClaim reads a claim with its attachments and locks it until the transaction ends, so a
+// concurrent transition waits for it and then checks the status it left
+func (s *claimService) lockClaim(tx *gorm.DB, claimID string) (*dbModels.Claim, error) {
+	var claim dbModels.Claim
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("Attachments").
+		Where("id = ?", claimID).
+		First(&claim).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domains.ErrClaimNotFound
+	}
+	if err != nil {
+		s.logger.Error("locking claim", zap.Error(err), zap.String("claim_id", claimID))
+		return nil, err
+	}
+
+	return &claim, nil
+}
+
+// checkCoverage returns the reason the policy does not cover the claim, or an empty string
+// when it is covered: the incident must fall within the current limit period and after the
+// waiting period of its coverage type.
+func (s *claimService) checkCoverage(
+	ctx context.Context,
+	policy *dbModels.Policy,
+	claim *dbModels.Claim,
+) (string, error) {
+	if claim.IncidentDate.Before(policy.LimitPeriodStart) || !claim.IncidentDate.Before(policy.LimitPeriodEnd) {
+		return fmt.Sprintf(
+			"incident date is outside the limit period %s to %s",
+			policy.LimitPeriodStart.Format("2006-01-02"),
+			policy.LimitPeriodEnd.Format("2006-01-02"),
+		), nil
+	}
+
+	eligibility, err := s.coverageService.IsEligible(ctx, policy.ID, claim.CoverageType, claim.IncidentDate)
+	if err != nil {
+		return "", err
+	}
+	if !eligibility.Eligible {
+		return eligibility.Reason, nil
+	}
+
+	return "", nil
+}
+
+// checkClaimTransition validates that a claim can move from one state to another
+func checkClaimTransition(from, to string) error {
+	if to == dbModels.ClaimPartiallyApproved {
+		to = dbModels.ClaimApproved
+	}
+	if !slices.Contains(claimTransitions[from], to) {
+		return fmt.Errorf("%w: %s to %s", domains.ErrInvalidClaimTransition, from, to)
+	}
+
+	return nil
+}
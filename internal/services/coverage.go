@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"appa_subscriptions/internal/domains"
+	"appa_subscriptions/internal/models"
+	dbModels "appa_subscriptions/pkg/db/models"
+)
+
+// coveredPolicyStatuses are the policy statuses under which claims are covered
+var coveredPolicyStatuses = []string{statusActive, statusPendingCancellation}
+
+type coverageService struct {
+	db     *gorm.DB
+	loc    *time.Location
+	logger *zap.Logger
+}
+
+// NewCoverageService creates a new instance of CoverageService
+func NewCoverageService(db *gorm.DB, loc *time.Location, logger *zap.Logger) domains.CoverageService {
+	return &coverageService{
+		db:     db,
+		loc:    loc,
+		logger: logger,
+	}
+}
+
+// ComputeEligibility sets the date from which each coverage type of the policy plan is covered,
+// counting the plan waiting periods from the given date. It is called when a policy is created
+// and when it is reactivated, restarting the waiting periods.
+func (s *coverageService) ComputeEligibility(
+	tx *gorm.DB,
+	ctx context.Context,
+	policy *dbModels.Policy,
+	from time.Time,
+) error {
+	var waitingPeriods []dbModels.PlanWaitingPeriod
+	err := tx.WithContext(ctx).
+		Where(dbModels.PlanWaitingPeriod{PlanID: policy.PlanID}).
+		Find(&waitingPeriods).Error
+	if err != nil {
+		s.logger.Error("getting plan waiting periods", zap.Error(err), zap.String("plan_id", policy.PlanID))
+		return err
+	}
+
+	if len(waitingPeriods) == 0 {
+		s.logger.Warn("plan has no waiting periods defined", zap.String("plan_id", policy.PlanID))
+		return nil
+	}
+
+	from = from.In(s.loc)
+	startDate := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, s.loc)
+	eligibilities := make([]dbModels.PolicyCoverageEligibility, 0, len(waitingPeriods))
+	for _, waitingPeriod := range waitingPeriods {
+		eligibilities = append(eligibilities, dbModels.PolicyCoverageEligibility{
+			PolicyID:     policy.ID,
+			CoverageType: waitingPeriod.CoverageType,
+			WaitingDays:  waitingPeriod.Days,
+			EligibleFrom: startDate.AddDate(0, 0, waitingPeriod.Days),
+		})
+	}
+
+	err = tx.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "policy_id"}, {Name: "coverage_type"}},
+			DoUpdates: clause.AssignmentColumns([]string{"waiting_days", "eligible_from", "updated_at"}),
+		}).
+		Create(&eligibilities).Error
+	if err != nil {
+		s.logger.Error("saving policy coverage eligibility", zap.Error(err), zap.String("policy_id", policy.ID))
+		return err
+	}
+
+	return nil
+}
+
+// IsEligible tells whether the policy covers the coverage type on the given date
+func (s *coverageService) IsEligible(
+	ctx context.Context,
+	policyID, coverageType string,
+	date time.Time,
+) (*models.CoverageEligibility, error) {
+	var policy dbModels.Policy
+	err := s.db.WithContext(ctx).Select("id", "status").Where("id = ?", policyID).First(&policy).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domains.ErrPolicyNotFound
+	}
+	if err != nil {
+		s.logger.Error("getting policy", zap.Error(err), zap.String("policy_id", policyID))
+		return nil, err
+	}
+
+	eligibility := &models.CoverageEligibility{
+		PolicyID:     policyID,
+		CoverageType: coverageType,
+		Date:         date,
+	}
+
+	var coverage dbModels.PolicyCoverageEligibility
+	err = s.db.WithContext(ctx).
+		Where(dbModels.PolicyCoverageEligibility{PolicyID: policyID, CoverageType: coverageType}).
+		Limit(1).Find(&coverage).Error
+	if err != nil {
+		s.logger.Error("getting policy coverage eligibility", zap.Error(err), zap.String("policy_id", policyID))
+		return nil, err
+	}
+
+	switch {
+	case coverage.ID == "":
+		eligibility.Reason = "coverage type is not included in the policy plan"
+	case !slices.Contains(coveredPolicyStatuses, policy.Status):
+		eligibility.EligibleFrom = &coverage.EligibleFrom
+		eligibility.Reason = fmt.Sprintf("policy is %s", policy.Status)
+	case date.Before(coverage.EligibleFrom):
+		eligibility.EligibleFrom = &coverage.EligibleFrom
+		eligibility.Reason = fmt.Sprintf("waiting period of %d days ends on %s", coverage.WaitingDays, coverage.EligibleFrom.Format("2006-01-02"))
+	default:
+		eligibility.EligibleFrom = &coverage.EligibleFrom
+		eligibility.Eligible = true
+	}
+
+	return eligibility, nil
+}
+
+// SetWaitingPeriod creates or updates the waiting period of a plan for a coverage type.
+// It applies to policies whose eligibility is computed afterwards.
+func (s *coverageService) SetWaitingPeriod(
+	ctx context.Context,
+	planID string,
+	req models.WaitingPeriodRequest,
+) (*dbModels.PlanWaitingPeriod, error) {
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&dbModels.Plan{}).Where("id = ?", planID).Count(&count).Error; err != nil {
+		s.logger.Error("checking plan", zap.Error(err), zap.String("plan_id", planID))
+		return nil, err
+	}
+	if count == 0 {
+		return nil, domains.ErrPlanNotFound
+	}
+
+	waitingPeriod := dbModels.PlanWaitingPeriod{
+		PlanID:       planID,
+		CoverageType: req.CoverageType,
+		Days:         *req.Days,
+	}
+	err := s.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "plan_id"}, {Name: "coverage_type"}},
+			DoUpdates: clause.AssignmentColumns([]string{"days"}),
+		}).
+		Create(&waitingPeriod).Error
+	if err != nil {
+		s.logger.Error("saving plan waiting period", zap.Error(err), zap.String("plan_id", planID))
+		return nil, err
+	}
+
+	return &waitingPeriod, nil
+}
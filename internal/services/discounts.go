@@ -0,0 +1,238 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"appa_subscriptions/internal/domains"
+	"appa_subscriptions/internal/models"
+	dbModels "appa_subscriptions/pkg/db/models"
+	"appa_subscriptions/pkg/money"
+)
+
+type discountService struct {
+	db     *gorm.DB
+	logger *zap.Logger
+}
+
+// NewDiscountService creates a new instance of DiscountService
+func NewDiscountService(db *gorm.DB, logger *zap.Logger) domains.DiscountService {
+	return &discountService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// RecurringOrderDiscount returns the discount for the next recurring order of a user, or nil
+// when none applies. Discounts do not stack: the best of the multi-pet rule matching the
+// household size and the user's coupons with cycles left wins.
+func (s *discountService) RecurringOrderDiscount(ctx context.Context, userID string) (*models.OrderDiscount, error) {
+	var petCount int64
+	err := s.db.WithContext(ctx).
+		Model(&dbModels.Policy{}).
+		Where("user_id = ? AND status <> ?", userID, statusCancelled).
+		Count(&petCount).Error
+	if err != nil {
+		s.logger.Error("counting user policies", zap.Error(err), zap.String("user_id", userID))
+		return nil, err
+	}
+
+	var discount *models.OrderDiscount
+
+	var rule dbModels.DiscountRule
+	err = s.db.WithContext(ctx).
+		Where("rule_type = ? AND active AND min_pets <= ?", dbModels.DiscountRuleMultiPet, petCount).
+		Order("percentage DESC").
+		Limit(1).
+		Find(&rule).Error
+	if err != nil {
+		s.logger.Error("getting multi-pet discount rule", zap.Error(err))
+		return nil, err
+	}
+	if rule.ID != "" {
+		discount = &models.OrderDiscount{
+			Code:       multiPetDiscountCode,
+			Percentage: rule.Percentage,
+		}
+	}
+
+	var coupon dbModels.CustomerCoupon
+	err = s.db.WithContext(ctx).
+		Where("user_id = ? AND remaining_cycles > 0", userID).
+		Order("percentage DESC").
+		Limit(1).
+		Find(&coupon).Error
+	if err != nil {
+		s.logger.Error("getting customer coupon", zap.Error(err), zap.String("user_id", userID))
+		return nil, err
+	}
+	if coupon.ID != "" && (discount == nil || coupon.Percentage > discount.Percentage) {
+		discount = &models.OrderDiscount{
+			Code:       coupon.Code,
+			Percentage: coupon.Percentage,
+			CouponID:   coupon.ID,
+		}
+	}
+
+	return discount, nil
+}
+
+// RegisterCoupons gives the user a coupon for each code of the first order that matches an
+// active coupon rule. A user gets each coupon rule once.
+func (s *discountService) RegisterCoupons(
+	tx *gorm.DB,
+	ctx context.Context,
+	userID, orderID string,
+	codes []models.Discount,
+) error {
+	for _, code := range codes {
+		if code.Code == "" {
+			continue
+		}
+
+		var rule dbModels.DiscountRule
+		err := tx.WithContext(ctx).
+			Where("rule_type = ? AND active AND upper(code) = ?", dbModels.DiscountRuleCoupon, strings.ToUpper(code.Code)).
+			Limit(1).
+			Find(&rule).Error
+		if err != nil {
+			s.logger.Error("getting coupon rule", zap.Error(err), zap.String("code", code.Code))
+			return err
+		}
+		if rule.ID == "" || rule.Cycles == nil {
+			continue
+		}
+
+		coupon := dbModels.CustomerCoupon{
+			UserID:          userID,
+			DiscountRuleID:  rule.ID,
+			Code:            *rule.Code,
+			Percentage:      rule.Percentage,
+			RemainingCycles: *rule.Cycles,
+			SourceOrderID:   orderID,
+		}
+		err = tx.WithContext(ctx).
+			Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&coupon).Error
+		if err != nil {
+			s.logger.Error("creating customer coupon", zap.Error(err), zap.String("code", code.Code))
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RecordInstallmentDiscount stores the discount applied to an installment and uses a cycle of
+// the coupon it comes from
+func (s *discountService) RecordInstallmentDiscount(
+	tx *gorm.DB,
+	ctx context.Context,
+	installmentID string,
+	discount *models.OrderDiscount,
+	amount money.Amount,
+) error {
+	if discount == nil {
+		return nil
+	}
+
+	err := tx.WithContext(ctx).
+		Model(&dbModels.PaymentInstallment{}).
+		Where("id = ?", installmentID).
+		Updates(map[string]any{
+			"discount_amount": amount,
+			"discount_code":   discount.Code,
+		}).Error
+	if err != nil {
+		s.logger.Error("recording installment discount", zap.Error(err), zap.String("installment_id", installmentID))
+		return err
+	}
+
+	if discount.CouponID == "" {
+		return nil
+	}
+
+	err = tx.WithContext(ctx).
+		Model(&dbModels.CustomerCoupon{}).
+		Where("id = ? AND remaining_cycles > 0", discount.CouponID).
+		Update("remaining_cycles", gorm.Expr("remaining_cycles - 1")).Error
+	if err != nil {
+		s.logger.Error("using customer coupon cycle", zap.Error(err), zap.String("coupon_id", discount.CouponID))
+		return err
+	}
+
+	return nil
+}
+
+// ListRules returns the discount rules
+func (s *discountService) ListRules(ctx context.Context) ([]dbModels.DiscountRule, error) {
+	var rules []dbModels.DiscountRule
+	if err := s.db.WithContext(ctx).Order("created_at").Find(&rules).Error; err != nil {
+		s.logger.Error("listing discount rules", zap.Error(err))
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// CreateRule creates a discount rule
+func (s *discountService) CreateRule(ctx context.Context, req models.DiscountRuleRequest) (*dbModels.DiscountRule, error) {
+	rule := dbModels.DiscountRule{Active: true}
+	setDiscountRule(&rule, req)
+
+	if err := s.db.WithContext(ctx).Create(&rule).Error; err != nil {
+		s.logger.Error("creating discount rule", zap.Error(err), zap.Any("req", req))
+		return nil, err
+	}
+
+	return &rule, nil
+}
+
+// UpdateRule replaces a discount rule. Coupons already given keep their percentage and cycles.
+func (s *discountService) UpdateRule(
+	ctx context.Context,
+	ruleID string,
+	req models.DiscountRuleRequest,
+) (*dbModels.DiscountRule, error) {
+	var rule dbModels.DiscountRule
+	err := s.db.WithContext(ctx).Where("id = ?", ruleID).First(&rule).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domains.ErrDiscountRuleNotFound
+	}
+	if err != nil {
+		s.logger.Error("getting discount rule", zap.Error(err), zap.String("rule_id", ruleID))
+		return nil, err
+	}
+
+	setDiscountRule(&rule, req)
+	if err := s.db.WithContext(ctx).Save(&rule).Error; err != nil {
+		s.logger.Error("updating discount rule", zap.Error(err), zap.String("rule_id", ruleID))
+		return nil, err
+	}
+
+	return &rule, nil
+}
+
+func setDiscountRule(rule *dbModels.DiscountRule, req models.DiscountRuleRequest) {
+	rule.Name = req.Name
+	rule.RuleType = req.RuleType
+	rule.Percentage = req.Percentage
+	rule.Code, rule.MinPets, rule.Cycles = nil, nil, nil
+
+	switch req.RuleType {
+	case dbModels.DiscountRuleMultiPet:
+		rule.MinPets = &req.MinPets
+	case dbModels.DiscountRuleCoupon:
+		code := strings.ToUpper(strings.TrimSpace(req.Code))
+		rule.Code = &code
+		rule.Cycles = &req.Cycles
+	}
+	if req.Active != nil {
+		rule.Active = *req.Active
+	}
+}
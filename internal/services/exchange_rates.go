@@ -0,0 +1,231 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"appa_subscriptions/internal/domains"
+	"appa_subscriptions/internal/models"
+	dbModels "appa_subscriptions/pkg/db/models"
+	"appa_subscriptions/pkg/exchange"
+)
+
+// Rates stored for the same day are ranked with the ones entered by staff first
+var (
+	manualRatesFirst = clause.OrderBy{Expression: clause.Expr{
+		SQL:                "source = ? DESC",
+		Vars:               []interface{}{dbModels.ExchangeRateManualSource},
+		WithoutParentheses: true,
+	}}
+	latestManualRatesFirst = clause.OrderBy{Expression: clause.Expr{
+		SQL:                "effective_date DESC, source = ? DESC",
+		Vars:               []interface{}{dbModels.ExchangeRateManualSource},
+		WithoutParentheses: true,
+	}}
+)
+
+type exchangeRateService struct {
+	db     *gorm.DB
+	source exchange.Source
+	loc    *time.Location
+	logger *zap.Logger
+}
+
+// NewExchangeRateService creates a new instance of ExchangeRateService
+func NewExchangeRateService(
+	db *gorm.DB,
+	source exchange.Source,
+	loc *time.Location,
+	logger *zap.Logger,
+) domains.ExchangeRateService {
+	return &exchangeRateService{
+		db:     db,
+		source: source,
+		loc:    loc,
+		logger: logger,
+	}
+}
+
+// RateFor returns the official USD/VES rate in force on the date. A rate stored for the day
+// wins, manual entries over the source's. Otherwise the source is asked and its answer stored,
+// and when it fails the latest stored rate before the date is used.
+func (s *exchangeRateService) RateFor(ctx context.Context, date time.Time) (*dbModels.ExchangeRate, error) {
+	day := s.startOfDay(date)
+
+	var rate dbModels.ExchangeRate
+	err := s.db.WithContext(ctx).
+		Where("base_currency = ? AND quote_currency = ? AND effective_date = ?", exchange.USD, exchange.VES, day).
+		Order(manualRatesFirst).
+		Limit(1).
+		Find(&rate).Error
+	if err != nil {
+		s.logger.Error("getting exchange rate", zap.Error(err), zap.Time("date", day))
+		return nil, err
+	}
+	if rate.ID != "" {
+		return &rate, nil
+	}
+
+	stored, err := s.fetchRate(ctx, day)
+	if err == nil {
+		return stored, nil
+	}
+	s.logger.Warn("exchange rate source failed, using the latest stored rate",
+		zap.Error(err), zap.String("source", s.source.Name()), zap.Time("date", day))
+
+	err = s.db.WithContext(ctx).
+		Where("base_currency = ? AND quote_currency = ? AND effective_date <= ?", exchange.USD, exchange.VES, day).
+		Order(latestManualRatesFirst).
+		Limit(1).
+		Find(&rate).Error
+	if err != nil {
+		s.logger.Error("getting latest exchange rate", zap.Error(err), zap.Time("date", day))
+		return nil, err
+	}
+	if rate.ID == "" {
+		return nil, domains.ErrExchangeRateNotFound
+	}
+
+	return &rate, nil
+}
+
+// SyncRate stores the rate the source publishes for today
+func (s *exchangeRateService) SyncRate(ctx context.Context) (*dbModels.ExchangeRate, error) {
+	return s.fetchRate(ctx, s.startOfDay(time.Now()))
+}
+
+// PlanRateSync returns the rates stored for the day of the date, which SyncRate would keep if
+// it ran then, without calling the source or writing
+func (s *exchangeRateService) PlanRateSync(ctx context.Context, date time.Time) (*models.ExchangeRateSyncPlan, error) {
+	day := s.startOfDay(date)
+
+	var rates []dbModels.ExchangeRate
+	err := s.db.WithContext(ctx).
+		Where("base_currency = ? AND quote_currency = ? AND effective_date = ?", exchange.USD, exchange.VES, day).
+		Find(&rates).Error
+	if err != nil {
+		s.logger.Error("getting exchange rates of the day", zap.Error(err))
+		return nil, err
+	}
+
+	plan := &models.ExchangeRateSyncPlan{
+		Date:   day.Format("2006-01-02"),
+		Stored: make(map[string]float64, len(rates)),
+	}
+	for _, rate := range rates {
+		plan.Stored[rate.Source] = rate.Rate
+	}
+
+	return plan, nil
+}
+
+// SetManualRate stores or replaces the rate entered by staff for a day
+func (s *exchangeRateService) SetManualRate(ctx context.Context, req models.ManualExchangeRateRequest) (*dbModels.ExchangeRate, error) {
+	day, err := time.ParseInLocation("2006-01-02", req.EffectiveDate, s.loc)
+	if err != nil {
+		return nil, err
+	}
+
+	rate := dbModels.ExchangeRate{
+		BaseCurrency:  exchange.USD,
+		QuoteCurrency: exchange.VES,
+		Rate:          req.Rate,
+		EffectiveDate: day,
+		Source:        dbModels.ExchangeRateManualSource,
+		CreatedBy:     &req.CreatedBy,
+	}
+	err = s.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "base_currency"}, {Name: "quote_currency"}, {Name: "effective_date"}, {Name: "source"}},
+			DoUpdates: clause.AssignmentColumns([]string{"rate", "created_by", "created_at"}),
+		}).
+		Create(&rate).Error
+	if err != nil {
+		s.logger.Error("storing manual exchange rate", zap.Error(err), zap.String("date", req.EffectiveDate))
+		return nil, err
+	}
+
+	s.logger.Info("manual exchange rate stored",
+		zap.String("date", req.EffectiveDate), zap.Float64("rate", req.Rate), zap.String("created_by", req.CreatedBy))
+
+	return &rate, nil
+}
+
+// ListRates returns the stored rates between two days, newest first
+func (s *exchangeRateService) ListRates(ctx context.Context, from, to time.Time) ([]dbModels.ExchangeRate, error) {
+	var rates []dbModels.ExchangeRate
+	err := s.db.WithContext(ctx).
+		Where("effective_date BETWEEN ? AND ?", s.startOfDay(from), s.startOfDay(to)).
+		Order("effective_date DESC, source").
+		Find(&rates).Error
+	if err != nil {
+		s.logger.Error("listing exchange rates", zap.Error(err))
+		return nil, err
+	}
+
+	return rates, nil
+}
+
+// fetchRate asks the source for the rate of the day and stores it. The source may answer with
+// the rate of an earlier value date, which is stored under that date.
+func (s *exchangeRateService) fetchRate(ctx context.Context, day time.Time) (*dbModels.ExchangeRate, error) {
+	fetched, err := s.source.Rate(ctx, exchange.USD, exchange.VES, day)
+	if err != nil {
+		return nil, err
+	}
+
+	rate := dbModels.ExchangeRate{
+		BaseCurrency:  fetched.Base,
+		QuoteCurrency: fetched.Quote,
+		Rate:          fetched.Value,
+		EffectiveDate: s.startOfDay(fetched.Date),
+		Source:        fetched.Source,
+	}
+	err = s.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&rate).Error
+	if err != nil {
+		s.logger.Error("storing exchange rate", zap.Error(err), zap.String("source", fetched.Source))
+		return nil, err
+	}
+
+	// Already stored by an earlier sync
+	if rate.ID == "" {
+		err = s.db.WithContext(ctx).
+			Where("base_currency = ? AND quote_currency = ? AND effective_date = ? AND source = ?",
+				rate.BaseCurrency, rate.QuoteCurrency, rate.EffectiveDate, rate.Source).
+			First(&rate).Error
+		if err != nil {
+			s.logger.Error("getting exchange rate", zap.Error(err), zap.String("source", fetched.Source))
+			return nil, err
+		}
+	}
+
+	return &rate, nil
+}
+
+// startOfDay returns the midnight starting the day of t in the service location
+func (s *exchangeRateService) startOfDay(t time.Time) time.Time {
+	t = t.In(s.loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, s.loc)
+}
+
+// snapshotRate returns the rate to store on an installment due on the date. Installments are
+// still created without one when no rate is available, the report leaves them unconverted.
+func snapshotRate(ctx context.Context, service domains.ExchangeRateService, date time.Time, logger *zap.Logger) *dbModels.ExchangeRate {
+	rate, err := service.RateFor(ctx, date)
+	if err != nil {
+		if !errors.Is(err, domains.ErrExchangeRateNotFound) {
+			logger.Error("getting exchange rate for installment", zap.Error(err))
+		}
+		logger.Warn("installment created without exchange rate", zap.Time("date", date))
+		return nil
+	}
+
+	return rate
+}
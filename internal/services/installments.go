@@ -0,0 +1,152 @@
+package services
+
+import (
+	"strconv"
+
+	"appa_subscriptions/internal/models"
+	dbModels "appa_subscriptions/pkg/db/models"
+	"appa_subscriptions/pkg/money"
+	"appa_subscriptions/pkg/shopify"
+)
+
+// policyMatcher assigns the line items of an order to the policies billed in it by variant.
+// Policies sharing a variant are assigned in order, one line item each.
+type policyMatcher map[string][]string
+
+// newPolicyMatcher indexes the policies by the legacy id of their variant
+func newPolicyMatcher(policies []dbModels.Policy) policyMatcher {
+	matcher := make(policyMatcher, len(policies))
+	for _, policy := range policies {
+		matcher.add(policy.ShopifyID, policy.ID)
+	}
+	return matcher
+}
+
+func (m policyMatcher) add(variantID, policyID string) {
+	variantID = shopify.LegacyID(variantID)
+	m[variantID] = append(m[variantID], policyID)
+}
+
+// match returns the next policy billed with the variant, or nil for line items of no policy
+func (m policyMatcher) match(variantID string) *string {
+	variantID = shopify.LegacyID(variantID)
+	policyIDs := m[variantID]
+	if len(policyIDs) == 0 {
+		return nil
+	}
+
+	m[variantID] = policyIDs[1:]
+	return &policyIDs[0]
+}
+
+// installmentItemsFromOrder builds the installment breakdown of an order created in Shopify
+func installmentItemsFromOrder(order *shopify.OrderCreateResponse, matcher policyMatcher) []dbModels.PaymentInstallmentItem {
+	items := make([]dbModels.PaymentInstallmentItem, 0, len(order.BilledLineItems.Nodes))
+	for _, lineItem := range order.BilledLineItems.Nodes {
+		var taxAmount money.Amount
+		for _, taxLine := range lineItem.TaxLines {
+			taxAmount = taxAmount.Add(parseAmount(taxLine.PriceSet.ShopMoney.Amount))
+		}
+
+		item := newInstallmentItem(
+			shopify.LegacyID(lineItem.ID),
+			lineItem.Title,
+			lineItem.Quantity,
+			parseAmount(lineItem.OriginalUnitPriceSet.ShopMoney.Amount),
+			parseAmount(lineItem.TotalDiscountSet.ShopMoney.Amount),
+			taxAmount,
+			order.TaxesIncluded,
+		)
+		if lineItem.Variant != nil {
+			variantID := shopify.LegacyID(lineItem.Variant.ID)
+			item.ShopifyVariantID = &variantID
+			item.PolicyID = matcher.match(variantID)
+		}
+		items = append(items, item)
+	}
+
+	return items
+}
+
+// installmentItemsFromWebhook builds the installment breakdown of an order received by webhook
+func installmentItemsFromWebhook(webhook models.Webhook, matcher policyMatcher) []dbModels.PaymentInstallmentItem {
+	items := make([]dbModels.PaymentInstallmentItem, 0, len(webhook.LineItems))
+	for _, lineItem := range webhook.LineItems {
+		var discountAmount, taxAmount money.Amount
+		for _, allocation := range lineItem.DiscountAllocation {
+			discountAmount = discountAmount.Add(parseAmount(allocation.AmountSet.ShopMoney.Amount))
+		}
+		for _, taxLine := range lineItem.TaxLines {
+			taxAmount = taxAmount.Add(parseAmount(taxLine.PriceSet.ShopMoney.Amount))
+		}
+
+		item := newInstallmentItem(
+			strconv.Itoa(lineItem.ID),
+			lineItem.Name,
+			lineItem.CurrentQuantity,
+			parseAmount(lineItem.PriceSet.ShopMoney.Amount),
+			discountAmount,
+			taxAmount,
+			webhook.TaxesIncluded,
+		)
+		if lineItem.VariantID != 0 {
+			variantID := strconv.Itoa(lineItem.VariantID)
+			item.ShopifyVariantID = &variantID
+			item.PolicyID = matcher.match(variantID)
+		}
+		items = append(items, item)
+	}
+
+	return items
+}
+
+// newInstallmentItem computes the total paid for a line item. Taxes are only added when the
+// prices do not include them.
+func newInstallmentItem(
+	lineItemID, description string,
+	quantity int,
+	unitPrice, discountAmount, taxAmount money.Amount,
+	taxesIncluded bool,
+) dbModels.PaymentInstallmentItem {
+	total := unitPrice.Mul(int64(quantity)).Sub(discountAmount)
+	if !taxesIncluded {
+		total = total.Add(taxAmount)
+	}
+
+	return dbModels.PaymentInstallmentItem{
+		ShopifyLineItemID: lineItemID,
+		Description:       description,
+		Quantity:          quantity,
+		UnitPrice:         unitPrice,
+		DiscountAmount:    discountAmount,
+		TaxAmount:         taxAmount,
+		Total:             total,
+	}
+}
+
+// presentmentMoney returns the amount and currency the customer is charged in, empty when
+// Shopify did not return them
+func presentmentMoney(set shopify.ShopMoney) (string, string) {
+	if set.PresentmentMoney == nil {
+		return "", ""
+	}
+	return set.PresentmentMoney.Amount, set.PresentmentMoney.CurrencyCode
+}
+
+// parseAmount parses a Shopify money amount, zero when it is empty or malformed
+func parseAmount(amount string) money.Amount {
+	value, err := money.Parse(amount)
+	if err != nil {
+		return money.Zero
+	}
+	return value
+}
+
+// installmentIDs returns the ids of the installments
+func installmentIDs(installments []dbModels.PaymentInstallment) []string {
+	ids := make([]string, 0, len(installments))
+	for _, installment := range installments {
+		ids = append(ids, installment.ID)
+	}
+	return ids
+}
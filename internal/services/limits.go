@@ -0,0 +1,224 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"appa_subscriptions/internal/domains"
+	"appa_subscriptions/internal/models"
+	"appa_subscriptions/pkg/db"
+	dbModels "appa_subscriptions/pkg/db/models"
+	"appa_subscriptions/pkg/money"
+)
+
+// settledClaimStatuses are the claim states that consumed the policy balance
+var settledClaimStatuses = []string{dbModels.ClaimApproved, dbModels.ClaimPartiallyApproved, dbModels.ClaimPaid}
+
+type limitPeriodService struct {
+	db            *gorm.DB
+	pendingAction string
+	loc           *time.Location
+	logger        *zap.Logger
+}
+
+// NewLimitPeriodService creates a new instance of LimitPeriodService. pendingAction is
+// applied to policies that are not in good standing on their renewal date.
+func NewLimitPeriodService(
+	db *gorm.DB,
+	pendingAction string,
+	loc *time.Location,
+	logger *zap.Logger,
+) domains.LimitPeriodService {
+	return &limitPeriodService{
+		db:            db,
+		pendingAction: pendingAction,
+		loc:           loc,
+		logger:        logger,
+	}
+}
+
+// RenewLimitPeriods rolls every policy whose limit period ended over to a new one year
+// period, restoring the balance to the current plan annual limit. The closed period is
+// kept as a snapshot with the claims paid out of it.
+func (s *limitPeriodService) RenewLimitPeriods(ctx context.Context) (*models.LimitRenewalReport, error) {
+	today := time.Now().In(s.loc).Format("2006-01-02")
+
+	var policyIDs []string
+	err := s.db.WithContext(ctx).
+		Model(&dbModels.Policy{}).
+		Where("limit_period_end <= ? AND status <> ?", today, statusCancelled).
+		Order("limit_period_end").
+		Pluck("id", &policyIDs).Error
+	if err != nil {
+		s.logger.Error("getting policies with an ended limit period", zap.Error(err))
+		return nil, err
+	}
+
+	report := &models.LimitRenewalReport{Due: len(policyIDs)}
+	for _, policyID := range policyIDs {
+		outcome, err := s.renewLimitPeriod(ctx, policyID, today)
+		if err != nil {
+			s.logger.Error("renewing limit period", zap.Error(err), zap.String("policy_id", policyID))
+			report.Failed = append(report.Failed, policyID)
+			continue
+		}
+
+		switch outcome {
+		case dbModels.LimitPeriodRenewed:
+			report.Renewed++
+		case dbModels.LimitPeriodExpired:
+			report.Expired++
+		default:
+			report.Deferred++
+		}
+	}
+
+	s.logger.Info("limit periods renewed",
+		zap.Int("due", report.Due),
+		zap.Int("renewed", report.Renewed),
+		zap.Int("deferred", report.Deferred),
+		zap.Int("expired", report.Expired),
+		zap.Int("failed", len(report.Failed)),
+	)
+
+	return report, nil
+}
+
+// PlanLimitPeriodRenewals returns the policies RenewLimitPeriods would renew, defer or expire
+// if it ran at the date, without writing
+func (s *limitPeriodService) PlanLimitPeriodRenewals(ctx context.Context, date time.Time) (*models.LimitRenewalPlan, error) {
+	day := date.In(s.loc).Format("2006-01-02")
+
+	var policies []dbModels.Policy
+	err := s.db.WithContext(ctx).
+		Where("limit_period_end <= ? AND status <> ?", day, statusCancelled).
+		Order("limit_period_end").
+		Find(&policies).Error
+	if err != nil {
+		s.logger.Error("getting policies with an ended limit period", zap.Error(err))
+		return nil, err
+	}
+
+	plan := &models.LimitRenewalPlan{Date: day, Policies: make([]models.PlannedLimitRenewal, 0, len(policies))}
+	for _, policy := range policies {
+		action := models.LimitRenewalRenew
+		if !slices.Contains(coveredPolicyStatuses, policy.Status) {
+			action = s.pendingAction
+		}
+
+		plan.Policies = append(plan.Policies, models.PlannedLimitRenewal{
+			PolicyID:     policy.ID,
+			PolicyStatus: policy.Status,
+			PeriodEnd:    policy.LimitPeriodEnd.Format("2006-01-02"),
+			Action:       action,
+		})
+	}
+
+	return plan, nil
+}
+
+// renewLimitPeriod closes the limit period of a policy and returns the outcome, or an
+// empty string when the policy is left as it is.
+func (s *limitPeriodService) renewLimitPeriod(
+	ctx context.Context,
+	policyID, today string,
+) (outcome string, err error) {
+	tx := s.db.Begin().WithContext(ctx)
+	defer db.DBRollback(tx, &err)
+
+	var policy dbModels.Policy
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND limit_period_end <= ? AND status <> ?", policyID, today, statusCancelled).
+		First(&policy).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// renewed or cancelled since it was listed
+		err = nil
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	action := models.LimitRenewalRenew
+	if !slices.Contains(coveredPolicyStatuses, policy.Status) {
+		action = s.pendingAction
+	}
+	if action == models.LimitRenewalDefer {
+		return "", nil
+	}
+
+	var plan dbModels.Plan
+	if err = tx.Where("id = ?", policy.PlanID).First(&plan).Error; err != nil {
+		return "", err
+	}
+
+	snapshot := dbModels.PolicyLimitPeriod{
+		PolicyID:       policy.ID,
+		PlanID:         policy.PlanID,
+		PeriodStart:    policy.LimitPeriodStart,
+		PeriodEnd:      policy.LimitPeriodEnd,
+		AnnualLimit:    plan.AnnualLimit,
+		ClosingBalance: policy.RemainingBalance,
+		PolicyStatus:   policy.Status,
+		Outcome:        dbModels.LimitPeriodRenewed,
+	}
+	if action == models.LimitRenewalExpire {
+		snapshot.Outcome = dbModels.LimitPeriodExpired
+	}
+
+	var usage struct {
+		UsedAmount  money.Amount
+		ClaimsCount int
+	}
+	err = tx.Model(&dbModels.Claim{}).
+		Select("COALESCE(SUM(approved_amount), 0) AS used_amount, COUNT(*) AS claims_count").
+		Where("policy_id = ? AND status IN ?", policy.ID, settledClaimStatuses).
+		Where("incident_date >= ? AND incident_date < ?", policy.LimitPeriodStart, policy.LimitPeriodEnd).
+		Scan(&usage).Error
+	if err != nil {
+		return "", err
+	}
+	snapshot.UsedAmount = usage.UsedAmount
+	snapshot.ClaimsCount = usage.ClaimsCount
+
+	// an expired period keeps its snapshot when the policy is renewed later on
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&snapshot)
+	if err = result.Error; err != nil {
+		return "", err
+	}
+
+	if action == models.LimitRenewalExpire {
+		if result.RowsAffected == 0 {
+			// already expired on a previous run
+			return "", nil
+		}
+
+		err = tx.Model(&dbModels.Policy{}).
+			Where("id = ?", policy.ID).
+			Update("remaining_balance", 0).Error
+		if err != nil {
+			return "", err
+		}
+
+		return dbModels.LimitPeriodExpired, nil
+	}
+
+	err = tx.Model(&dbModels.Policy{}).
+		Where("id = ?", policy.ID).
+		Updates(map[string]any{
+			"limit_period_start": policy.LimitPeriodEnd,
+			"limit_period_end":   policy.LimitPeriodEnd.AddDate(1, 0, 0),
+			"remaining_balance":  plan.AnnualLimit,
+		}).Error
+	if err != nil {
+		return "", err
+	}
+
+	return dbModels.LimitPeriodRenewed, nil
+}
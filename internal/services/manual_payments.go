@@ -0,0 +1,383 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"appa_subscriptions/internal/domains"
+	"appa_subscriptions/internal/models"
+	"appa_subscriptions/pkg/blobstore"
+	"appa_subscriptions/pkg/db"
+	dbModels "appa_subscriptions/pkg/db/models"
+	"appa_subscriptions/pkg/db/repositories"
+	"appa_subscriptions/pkg/shopify"
+)
+
+// maxProofSize is the largest payment proof accepted, screenshots are well below it
+const maxProofSize = 10 << 20
+
+// proofExtensions are the accepted payment proof types, detected from their content
+var proofExtensions = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/webp":      ".webp",
+	"application/pdf": ".pdf",
+}
+
+type manualPaymentService struct {
+	db           *gorm.DB
+	shopify      shopify.Repository
+	installments repositories.PaymentInstallmentRepository
+	blobs        blobstore.Store
+	loc          *time.Location
+	logger       *zap.Logger
+}
+
+// NewManualPaymentService creates a new instance of ManualPaymentService
+func NewManualPaymentService(
+	db *gorm.DB,
+	shopifyRepo shopify.Repository,
+	installmentRepo repositories.PaymentInstallmentRepository,
+	blobs blobstore.Store,
+	loc *time.Location,
+	logger *zap.Logger,
+) domains.ManualPaymentService {
+	return &manualPaymentService{
+		db:           db,
+		shopify:      shopifyRepo,
+		installments: installmentRepo,
+		blobs:        blobs,
+		loc:          loc,
+		logger:       logger,
+	}
+}
+
+// SubmitManualPayment records a Pago Móvil, Zelle or bank transfer payment of an installment with
+// its proof, pending staff review. A bank reference can only be reported once.
+func (s *manualPaymentService) SubmitManualPayment(
+	ctx context.Context,
+	installmentID string,
+	req models.SubmitManualPaymentRequest,
+	proof models.ManualPaymentProof,
+) (*dbModels.ManualPayment, error) {
+	paidOn, err := time.ParseInLocation("2006-01-02", req.PaidOn, s.loc)
+	if err != nil {
+		return nil, err
+	}
+
+	contentType, content, err := checkProof(proof)
+	if err != nil {
+		return nil, err
+	}
+
+	installment, err := s.installments.GetByID(s.db, ctx, installmentID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domains.ErrInstallmentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(dbModels.OpenInstallmentStatuses, installment.Status) {
+		return nil, domains.ErrInstallmentNotPayable
+	}
+
+	reported, err := s.installments.ReferenceReported(s.db, ctx, req.Method, req.Reference)
+	if err != nil {
+		return nil, err
+	}
+	if reported {
+		return nil, domains.ErrDuplicatePaymentReference
+	}
+
+	if expected, ok := convertAmount(*installment, req.Currency); ok && !expected.Equal(req.Amount) {
+		s.logger.Warn("manual payment amount differs from the installment",
+			zap.String("installment_id", installmentID),
+			zap.Stringer("expected", expected), zap.Stringer("reported", req.Amount), zap.String("currency", req.Currency))
+	}
+
+	key, err := proofKey(installmentID, proofExtensions[contentType])
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.blobs.Put(ctx, key, contentType, content); err != nil {
+		s.logger.Error("storing payment proof", zap.Error(err), zap.String("installment_id", installmentID))
+		return nil, err
+	}
+
+	payment := dbModels.ManualPayment{
+		PaymentInstallmentID: installmentID,
+		Method:               req.Method,
+		Reference:            req.Reference,
+		Amount:               req.Amount,
+		Currency:             req.Currency,
+		PaidOn:               paidOn,
+		ProofKey:             key,
+		ProofContentType:     contentType,
+		SubmittedBy:          req.SubmittedBy,
+		Status:               dbModels.ManualPaymentPending,
+	}
+	if err := s.db.WithContext(ctx).Create(&payment).Error; err != nil {
+		if delErr := s.blobs.Delete(ctx, key); delErr != nil {
+			s.logger.Warn("removing orphan payment proof", zap.Error(delErr), zap.String("key", key))
+		}
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return nil, domains.ErrDuplicatePaymentReference
+		}
+		s.logger.Error("creating manual payment", zap.Error(err), zap.String("installment_id", installmentID))
+		return nil, err
+	}
+
+	s.logger.Info("manual payment submitted",
+		zap.String("manual_payment_id", payment.ID), zap.String("installment_id", installmentID), zap.String("method", req.Method))
+
+	return &payment, nil
+}
+
+// ListManualPayments returns the manual payments in a review state, all of them when empty,
+// oldest first so the review queue is worked in order
+func (s *manualPaymentService) ListManualPayments(ctx context.Context, status string) ([]dbModels.ManualPayment, error) {
+	query := s.db.WithContext(ctx).Preload("PaymentInstallment").Order("created_at")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var payments []dbModels.ManualPayment
+	if err := query.Find(&payments).Error; err != nil {
+		s.logger.Error("listing manual payments", zap.Error(err))
+		return nil, err
+	}
+
+	return payments, nil
+}
+
+// GetProof opens the proof of a manual payment, the caller closes it
+func (s *manualPaymentService) GetProof(ctx context.Context, paymentID string) (io.ReadCloser, *blobstore.Object, error) {
+	payment, err := s.getManualPayment(s.db.WithContext(ctx), paymentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	content, object, err := s.blobs.Get(ctx, payment.ProofKey)
+	if err != nil {
+		s.logger.Error("opening payment proof", zap.Error(err), zap.String("manual_payment_id", paymentID))
+		return nil, nil, err
+	}
+	object.ContentType = payment.ProofContentType
+
+	return content, object, nil
+}
+
+// ApproveManualPayment marks the installment paid and the Shopify order paid. The order is
+// marked once the approval is committed. When Shopify fails the payment stays approved without
+// OrderMarkedPaidAt, and the payment reconciliation marks the order later.
+func (s *manualPaymentService) ApproveManualPayment(
+	ctx context.Context,
+	paymentID string,
+	req models.ApproveManualPaymentRequest,
+) (*dbModels.ManualPayment, error) {
+	payment, installment, err := s.approve(ctx, paymentID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("manual payment approved",
+		zap.String("manual_payment_id", paymentID), zap.String("installment_id", installment.ID), zap.String("reviewed_by", req.ReviewedBy))
+
+	if err := s.markOrderPaid(ctx, payment, installment.ShopifyOrderID); err != nil {
+		s.logger.Warn("shopify order of approved manual payment left for reconciliation",
+			zap.Error(err), zap.String("manual_payment_id", paymentID), zap.String("order_id", installment.ShopifyOrderID))
+	}
+
+	return payment, nil
+}
+
+// MarkApprovedOrdersPaid marks paid the Shopify orders of the approved manual payments whose
+// order could not be marked when they were approved. It returns the number of orders marked,
+// a payment whose order fails again is left for the next run.
+func (s *manualPaymentService) MarkApprovedOrdersPaid(ctx context.Context) (int, error) {
+	var payments []dbModels.ManualPayment
+	err := s.db.WithContext(ctx).
+		Preload("PaymentInstallment").
+		Where("status = ? AND order_marked_paid_at IS NULL", dbModels.ManualPaymentApproved).
+		Order("reviewed_at").
+		Find(&payments).Error
+	if err != nil {
+		s.logger.Error("getting approved manual payments with unmarked orders", zap.Error(err))
+		return 0, err
+	}
+
+	marked := 0
+	for i := range payments {
+		payment := &payments[i]
+		if err := s.markOrderPaid(ctx, payment, payment.PaymentInstallment.ShopifyOrderID); err != nil {
+			s.logger.Warn("marking shopify order of approved manual payment",
+				zap.Error(err), zap.String("manual_payment_id", payment.ID))
+			continue
+		}
+		marked++
+	}
+
+	return marked, nil
+}
+
+// approve marks the installment paid and the payment approved in one transaction
+func (s *manualPaymentService) approve(
+	ctx context.Context,
+	paymentID string,
+	req models.ApproveManualPaymentRequest,
+) (payment *dbModels.ManualPayment, installment *dbModels.PaymentInstallment, err error) {
+	tx := s.db.Begin().WithContext(ctx)
+	defer db.DBRollback(tx, &err)
+
+	payment, err = s.getManualPayment(tx.Clauses(clause.Locking{Strength: "UPDATE"}), paymentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if payment.Status != dbModels.ManualPaymentPending {
+		err = domains.ErrManualPaymentNotPending
+		return nil, nil, err
+	}
+
+	installment, err = s.installments.GetByID(tx, ctx, payment.PaymentInstallmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now().In(s.loc)
+	marked, err := s.installments.MarkPaid(tx, ctx, []string{installment.ID}, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	if marked == 0 {
+		err = domains.ErrInstallmentNotPayable
+		return nil, nil, err
+	}
+
+	payment.Status = dbModels.ManualPaymentApproved
+	payment.ReviewedBy = &req.ReviewedBy
+	payment.ReviewedAt = &now
+	if err = tx.Omit(clause.Associations).Save(payment).Error; err != nil {
+		s.logger.Error("approving manual payment", zap.Error(err), zap.String("manual_payment_id", paymentID))
+		return nil, nil, err
+	}
+
+	return payment, installment, nil
+}
+
+// markOrderPaid marks the Shopify order of an approved payment paid and records it. An
+// installment without an order has nothing to mark.
+func (s *manualPaymentService) markOrderPaid(ctx context.Context, payment *dbModels.ManualPayment, orderID string) error {
+	if orderID != "" {
+		if _, err := s.shopify.MarkOrderAsPaid(ctx, orderID); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now().In(s.loc)
+	err := s.db.WithContext(ctx).
+		Model(&dbModels.ManualPayment{}).
+		Where("id = ?", payment.ID).
+		Update("order_marked_paid_at", now).Error
+	if err != nil {
+		s.logger.Error("recording marked shopify order", zap.Error(err), zap.String("manual_payment_id", payment.ID))
+		return err
+	}
+	payment.OrderMarkedPaidAt = &now
+
+	return nil
+}
+
+// RejectManualPayment rejects a manual payment with a reason, its reference can be reported again
+func (s *manualPaymentService) RejectManualPayment(
+	ctx context.Context,
+	paymentID string,
+	req models.RejectManualPaymentRequest,
+) (*dbModels.ManualPayment, error) {
+	now := time.Now().In(s.loc)
+	result := s.db.WithContext(ctx).
+		Model(&dbModels.ManualPayment{}).
+		Where("id = ? AND status = ?", paymentID, dbModels.ManualPaymentPending).
+		Updates(map[string]any{
+			"status":           dbModels.ManualPaymentRejected,
+			"rejection_reason": req.Reason,
+			"reviewed_by":      req.ReviewedBy,
+			"reviewed_at":      now,
+		})
+	if result.Error != nil {
+		s.logger.Error("rejecting manual payment", zap.Error(result.Error), zap.String("manual_payment_id", paymentID))
+		return nil, result.Error
+	}
+
+	payment, err := s.getManualPayment(s.db.WithContext(ctx), paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if result.RowsAffected == 0 {
+		return nil, domains.ErrManualPaymentNotPending
+	}
+
+	return payment, nil
+}
+
+func (s *manualPaymentService) getManualPayment(query *gorm.DB, paymentID string) (*dbModels.ManualPayment, error) {
+	var payment dbModels.ManualPayment
+	err := query.Where("id = ?", paymentID).First(&payment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domains.ErrManualPaymentNotFound
+	}
+	if err != nil {
+		s.logger.Error("getting manual payment", zap.Error(err), zap.String("manual_payment_id", paymentID))
+		return nil, err
+	}
+
+	return &payment, nil
+}
+
+// checkProof detects the type of the proof from its first bytes, the declared type is not
+// trusted. It returns a reader of the whole proof.
+func checkProof(proof models.ManualPaymentProof) (string, io.Reader, error) {
+	if proof.Content == nil || proof.Size == 0 {
+		return "", nil, fmt.Errorf("%w: a screenshot or receipt is required", domains.ErrInvalidPaymentProof)
+	}
+	if proof.Size > maxProofSize {
+		return "", nil, fmt.Errorf("%w: larger than %d MB", domains.ErrInvalidPaymentProof, maxProofSize>>20)
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(proof.Content, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", nil, err
+	}
+	head = head[:n]
+
+	contentType := http.DetectContentType(head)
+	if _, ok := proofExtensions[contentType]; !ok {
+		return "", nil, fmt.Errorf("%w: %s is not an image or PDF", domains.ErrInvalidPaymentProof, contentType)
+	}
+
+	return contentType, io.MultiReader(bytes.NewReader(head), proof.Content), nil
+}
+
+// proofKey returns a unique blob key for a proof of the installment
+func proofKey(installmentID, extension string) (string, error) {
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("manual-payments/%s/%s%s", installmentID, hex.EncodeToString(suffix), extension), nil
+}
@@ -1,17 +1,20 @@
 package services
 
 import (
+	"appa_subscriptions/internal/domains"
 	"appa_subscriptions/internal/models"
 	helpers "appa_subscriptions/pkg"
 	"appa_subscriptions/pkg/mailgun"
 	"context"
+	"sync"
 
 	"go.uber.org/zap"
 )
 
 type notificationService struct {
-	muRepo mailgun.Repository
-	logger *zap.Logger
+	muRepo  mailgun.Repository
+	workers sync.WaitGroup
+	logger  *zap.Logger
 }
 
 type notificationJob struct {
@@ -46,7 +49,7 @@ var (
 func NewNotificationService(
 	mailgunRepo mailgun.Repository,
 	logger *zap.Logger,
-) *notificationService {
+) domains.NotificationService {
 
 	service := &notificationService{
 		muRepo: mailgunRepo,
@@ -62,7 +65,11 @@ func (h *notificationService) startWorkerPool(numWorkers int) {
 	notificationJobsQueue = make(chan notificationJob, 100) // Cola con buffer de 100 trabajos
 
 	for i := 1; i <= numWorkers; i++ {
-		go h.worker(i, notificationJobsQueue)
+		h.workers.Add(1)
+		go func(id int) {
+			defer h.workers.Done()
+			h.worker(id, notificationJobsQueue)
+		}(i)
 	}
 	h.logger.Info("Started workers", zap.Int("num_workers", numWorkers))
 }
@@ -80,6 +87,13 @@ func (h *notificationService) worker(id int, jobs <-chan notificationJob) {
 	}
 }
 
+// Close stops taking notifications and waits for the queued ones to be sent. It is called
+// before a one-off command exits, nothing may be queued afterwards.
+func (h *notificationService) Close() {
+	close(notificationJobsQueue)
+	h.workers.Wait()
+}
+
 func (s *notificationService) sendEmail(
 	ctx context.Context,
 	vars models.ConfirmationOrderEmailVars,
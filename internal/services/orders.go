@@ -2,7 +2,9 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
@@ -14,28 +16,38 @@ import (
 	helpers "appa_subscriptions/pkg"
 	"appa_subscriptions/pkg/db"
 	dbModels "appa_subscriptions/pkg/db/models"
-	PaymentInstallment "appa_subscriptions/pkg/db/repositories"
+	"appa_subscriptions/pkg/db/repositories"
 	"appa_subscriptions/pkg/mailgun"
+	"appa_subscriptions/pkg/money"
 	"appa_subscriptions/pkg/shopify"
 )
 
 type orderService struct {
 	db                     *gorm.DB
 	shopify                shopify.Repository
-	PaymentInstallmentRepo PaymentInstallment.Repository
+	PaymentInstallmentRepo repositories.PaymentInstallmentRepository
+	policies               repositories.PolicyRepository
+	discountService        domains.DiscountService
+	exchangeRateService    domains.ExchangeRateService
 	muRepo                 mailgun.Repository
 	loc                    *time.Location
 	logger                 *zap.Logger
 }
 
 const (
-	statusCanceled = "canceled"
+	tagBillingPeriodPrefix = "billing_period"
+	prorationLineItemTitle = "Ajuste por cambio de plan"
+	prorationDiscountCode  = "CAMBIO-DE-PLAN"
+	multiPetDiscountCode   = "MULTIMASCOTA"
 )
 
 func NewOrderService(
 	db *gorm.DB,
 	shopifyRepo shopify.Repository,
-	PaymentInstallmentRepo PaymentInstallment.Repository,
+	PaymentInstallmentRepo repositories.PaymentInstallmentRepository,
+	policyRepo repositories.PolicyRepository,
+	discountService domains.DiscountService,
+	exchangeRateService domains.ExchangeRateService,
 	mailgunRepo mailgun.Repository,
 	loc *time.Location,
 	logger *zap.Logger,
@@ -44,6 +56,9 @@ func NewOrderService(
 		db:                     db,
 		shopify:                shopifyRepo,
 		PaymentInstallmentRepo: PaymentInstallmentRepo,
+		policies:               policyRepo,
+		discountService:        discountService,
+		exchangeRateService:    exchangeRateService,
 		muRepo:                 mailgunRepo,
 		loc:                    loc,
 		logger:                 logger,
@@ -54,53 +69,72 @@ func NewOrderService(
 func (s *orderService) NextPaymentInstallmentCreate(ctx context.Context) error {
 	currentDate := time.Now().In(s.loc)
 
-	var policies []dbModels.Policy
-	err := s.db.WithContext(ctx).
-		Select("policies.*").
-		InnerJoins("Pet", s.db.Select("Name").Model(&dbModels.Pet{})).
-		InnerJoins("User", s.db.Select("ID", "ShopifyID", "Email").Model(&dbModels.User{})).
-		Where(
-			"next_payment <= ? AND status = ? AND is_manual = ?",
-			currentDate,
-			"active",
-			true,
-		).
-		Find(&policies).Error
+	policies, err := s.policies.FindDueForBilling(ctx, currentDate)
 	if err != nil {
-		s.logger.Error(err.Error())
 		return err
 	}
 
-	userPoliciesMap := make(map[string][]dbModels.Policy)
-	for _, policy := range policies {
-		userPoliciesMap[policy.UserID] = append(userPoliciesMap[policy.UserID], policy)
-	}
-
-	for _, policies := range userPoliciesMap {
+	for _, policies := range policiesByUser(policies) {
 		var (
 			email         = policies[0].User.Email
 			shopifyUserID = policies[0].User.ShopifyID
 		)
 
-		order, userPolicyIDs, err := s.createOrderInShopify(
+		billingTag := getBillingPeriodTag(policies)
+		adjustments, err := s.getOrderAdjustments(ctx, policies)
+		if err != nil {
+			s.logger.Error(err.Error(), zap.Any("policies", policies))
+			continue
+		}
+
+		order, userPolicyIDs, err := s.findOrCreateOrderInShopify(
 			ctx,
 			policies,
+			adjustments,
 			email,
 			shopifyUserID,
+			billingTag,
 		)
 		if err != nil {
+			var userErrs *shopify.MutationError
+			if errors.As(err, &userErrs) {
+				s.logger.Error("shopify rejected recurring order", zap.Any("user_errors", userErrs.Errors), zap.String("billing_tag", billingTag))
+				continue
+			}
 			s.logger.Error(err.Error(), zap.Any("policies", policies))
 			continue
 		}
 
+		registered, err := s.PaymentInstallmentRepo.FindByShopifyOrderID(s.db, ctx, order.ID)
+		if err != nil {
+			continue
+		}
+		if len(registered) > 0 {
+			s.logger.Info("payment installment already registered for billing period, skipping", zap.String("order_id", order.ID), zap.String("billing_tag", billingTag))
+			continue
+		}
+
+		dueDate := getBillingDate(policies)
+		presentmentAmount, presentmentCurrency := presentmentMoney(order.TotalPriceSet)
+		rate := snapshotRate(ctx, s.exchangeRateService, dueDate, s.logger)
+
 		var (
 			errDB error
 			tx    = s.db.Begin().WithContext(ctx)
 		)
 
-		paymentInstallment, err := s.PaymentInstallmentRepo.Create(
-			tx, ctx, order.ID, statusPendingPayment, order.Name, order.TotalPriceSet.ShopMoney.Amount,
-		)
+		paymentInstallment, err := s.PaymentInstallmentRepo.Create(tx, ctx, repositories.CreateInstallmentInput{
+			OrderID:             order.ID,
+			OrderName:           order.Name,
+			Status:              statusPendingPayment,
+			Amount:              order.TotalPriceSet.ShopMoney.Amount,
+			Currency:            order.TotalPriceSet.ShopMoney.CurrencyCode,
+			PresentmentAmount:   presentmentAmount,
+			PresentmentCurrency: presentmentCurrency,
+			ExchangeRate:        rate,
+			DueDate:             dueDate,
+			PolicyIDs:           userPolicyIDs,
+		})
 		if err != nil {
 			errDB = err
 			db.DBRollback(tx, &errDB)
@@ -108,27 +142,52 @@ func (s *orderService) NextPaymentInstallmentCreate(ctx context.Context) error {
 			continue
 		}
 
+		items := installmentItemsFromOrder(order, newPolicyMatcher(policies))
+		if err := s.PaymentInstallmentRepo.CreateItems(tx, ctx, paymentInstallment.ID, items); err != nil {
+			errDB = err
+			db.DBRollback(tx, &errDB)
+			continue
+		}
+
 		// create policy payment installment records
-		policyPayments := s.getPolicyPaymentsByPolicies(policies, paymentInstallment.ID)
-		err = tx.WithContext(ctx).Create(&policyPayments).Error
+		err = s.policies.WithTx(tx).LinkInstallment(ctx, getPolicyIDs(policies), paymentInstallment.ID)
 		if err != nil {
 			errDB = err
 			db.DBRollback(tx, &errDB)
-			s.logger.Error(err.Error())
 			continue
 		}
 
+		err = s.discountService.RecordInstallmentDiscount(
+			tx, ctx, paymentInstallment.ID, adjustments.discount, adjustments.discountAmount,
+		)
+		if err != nil {
+			errDB = err
+			db.DBRollback(tx, &errDB)
+			continue
+		}
+
+		// bill the plan change prorations in this installment
+		if len(adjustments.prorations) > 0 {
+			prorationIDs := make([]string, 0, len(adjustments.prorations))
+			for _, proration := range adjustments.prorations {
+				prorationIDs = append(prorationIDs, proration.ID)
+			}
+			err = tx.WithContext(ctx).Model(&dbModels.PolicyPlanChange{}).
+				Where("id IN ?", prorationIDs).
+				Update("proration_installment_id", paymentInstallment.ID).Error
+			if err != nil {
+				errDB = err
+				db.DBRollback(tx, &errDB)
+				s.logger.Error(err.Error(), zap.Strings("plan_change_ids", prorationIDs))
+				continue
+			}
+		}
+
 		// update next payment date for policies
-		err = tx.WithContext(ctx).Model(&dbModels.Policy{}).
-			Where("id IN ?", userPolicyIDs).
-			Updates(map[string]any{
-				"next_payment": currentDate.AddDate(0, 1, 0).In(s.loc).Format("2006-01-02"),
-				"status":       statusPendingPolicy,
-			}).Error
+		err = s.policies.WithTx(tx).AdvanceNextPayment(ctx, userPolicyIDs, statusPendingPolicy)
 		if err != nil {
 			errDB = err
 			db.DBRollback(tx, &errDB)
-			s.logger.Error(err.Error(), zap.Any("policy_ids", userPolicyIDs))
 			return err
 		}
 
@@ -156,27 +215,151 @@ func (s *orderService) NextPaymentInstallmentCreate(ctx context.Context) error {
 	return nil
 }
 
-// getPolicyPaymentsByPolicies creates PolicyPayment records for the given policies and payment installment ID
-func (s *orderService) getPolicyPaymentsByPolicies(
-	policies []dbModels.Policy,
-	paymentInstallmentID string,
-) []dbModels.PolicyPayment {
-	var policyPayments []dbModels.PolicyPayment
+// PlanNextPaymentInstallments returns the recurring orders NextPaymentInstallmentCreate would
+// create if it ran at the date and the emails it would send, without calling Shopify, writing
+// or sending mail.
+// A user whose order cannot be planned is listed with the error, the run would skip them.
+func (s *orderService) PlanNextPaymentInstallments(ctx context.Context, date time.Time) (*models.BillingPlan, error) {
+	currentDate := date.In(s.loc)
+
+	policies, err := s.policies.FindDueForBilling(ctx, currentDate)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &models.BillingPlan{
+		Date:   currentDate.Format("2006-01-02"),
+		Orders: []models.PlannedOrder{},
+	}
+	for _, policies := range policiesByUser(policies) {
+		order := models.PlannedOrder{
+			UserID:     policies[0].UserID,
+			Name:       policies[0].User.Name,
+			Email:      policies[0].User.Email,
+			BillingTag: getBillingPeriodTag(policies),
+			DueDate:    getBillingDate(policies).Format("2006-01-02"),
+			Policies:   make([]models.PlannedPolicy, 0, len(policies)),
+			Notification: models.PlannedNotification{
+				Template: "create_order",
+				To:       policies[0].User.Email,
+			},
+		}
+		for _, policy := range policies {
+			order.Policies = append(order.Policies, models.PlannedPolicy{
+				PolicyID:    policy.ID,
+				PetName:     policy.Pet.Name,
+				VariantID:   shopify.LegacyID(policy.ShopifyID),
+				NextPayment: policy.NextPayment.Format("2006-01-02"),
+			})
+		}
+
+		if err := s.planOrderAmounts(ctx, policies, &order); err != nil {
+			order.Error = err.Error()
+		} else {
+			plan.EstimatedTotal = plan.EstimatedTotal.Add(order.EstimatedTotal)
+		}
+		plan.Orders = append(plan.Orders, order)
+	}
+	slices.SortFunc(plan.Orders, func(a, b models.PlannedOrder) int { return strings.Compare(a.Email, b.Email) })
+
+	return plan, nil
+}
+
+// planOrderAmounts sets the amounts of a planned order from the current plan prices and the
+// adjustments the run would apply
+func (s *orderService) planOrderAmounts(ctx context.Context, policies []dbModels.Policy, order *models.PlannedOrder) error {
+	adjustments, err := s.getOrderAdjustments(ctx, policies)
+	if err != nil {
+		return err
+	}
+
+	subtotal, err := s.policies.PlanSubtotal(ctx, getPolicyIDs(policies))
+	if err != nil {
+		return err
+	}
+
+	order.Subtotal = subtotal
+	order.Proration, order.Discount, order.Codes = adjustmentAmounts(adjustments)
+	order.EstimatedTotal = subtotal.Add(money.Max(order.Proration, money.Zero)).Sub(order.Discount)
+
+	return nil
+}
+
+// policiesByUser groups the policies by their user
+func policiesByUser(policies []dbModels.Policy) map[string][]dbModels.Policy {
+	byUser := make(map[string][]dbModels.Policy)
 	for _, policy := range policies {
-		policyPayments = append(policyPayments, dbModels.PolicyPayment{
-			PolicyID:             policy.ID,
-			PaymentInstallmentID: paymentInstallmentID,
-		})
+		byUser[policy.UserID] = append(byUser[policy.UserID], policy)
+	}
+	return byUser
+}
+
+// getPolicyIDs returns the ids of the policies
+func getPolicyIDs(policies []dbModels.Policy) []string {
+	ids := make([]string, 0, len(policies))
+	for _, policy := range policies {
+		ids = append(ids, policy.ID)
+	}
+	return ids
+}
+
+// getBillingPeriodTag builds the deterministic Shopify tag that identifies the
+// recurring order of a user for the billing period being charged.
+func getBillingPeriodTag(policies []dbModels.Policy) string {
+	return fmt.Sprintf("%s_%s_%s", tagBillingPeriodPrefix, policies[0].UserID, getBillingDate(policies).Format("2006-01"))
+}
+
+// getBillingDate returns the billing anchor of a group of policies, their earliest next payment
+func getBillingDate(policies []dbModels.Policy) time.Time {
+	billingDate := policies[0].NextPayment
+	for _, policy := range policies {
+		if policy.NextPayment.Before(billingDate) {
+			billingDate = policy.NextPayment
+		}
+	}
+
+	return billingDate
+}
+
+// findOrCreateOrderInShopify returns the order already created in Shopify for the billing period,
+// or creates it when none exists. This keeps the recurring order creation idempotent when a
+// previous run created the order but failed to register the payment installment.
+func (s *orderService) findOrCreateOrderInShopify(
+	ctx context.Context,
+	policies []dbModels.Policy,
+	adjustments *orderAdjustments,
+	email,
+	shopifyUserID,
+	billingTag string,
+) (*shopify.OrderCreateResponse, []string, error) {
+	orders, err := s.shopify.GetOrdersByQuery(ctx, fmt.Sprintf("tag:%q", billingTag), 1)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(orders) > 0 {
+		s.logger.Info("reusing existing shopify order for billing period", zap.String("order_id", orders[0].ID), zap.String("billing_tag", billingTag))
+		_, userPolicyIDs := getOrderLineItemsByPolicies(policies)
+		return &shopify.OrderCreateResponse{
+			ID:              orders[0].ID,
+			Name:            orders[0].Name,
+			TotalPriceSet:   orders[0].TotalPriceSet,
+			TaxesIncluded:   orders[0].TaxesIncluded,
+			BilledLineItems: orders[0].BilledLineItems,
+		}, userPolicyIDs, nil
 	}
-	return policyPayments
+
+	return s.createOrderInShopify(ctx, policies, adjustments, email, shopifyUserID, billingTag)
 }
 
 // createOrderInShopify creates an order in Shopify for the given user and line items
 func (s *orderService) createOrderInShopify(
 	ctx context.Context,
 	policies []dbModels.Policy,
+	adjustments *orderAdjustments,
 	email,
-	shopifyUserID string,
+	shopifyUserID,
+	billingTag string,
 ) (*shopify.OrderCreateResponse, []string, error) {
 	lineItems, userPolicyIDs := getOrderLineItemsByPolicies(policies)
 
@@ -188,12 +371,13 @@ func (s *orderService) createOrderInShopify(
 		Order: shopify.CreateOrderInput{
 			CustomerID:      shopifyUserID,
 			Email:           email,
-			Tags:            []string{tagManualSubscriptionRecurringOrder},
+			Tags:            []string{tagManualSubscriptionRecurringOrder, billingTag},
 			LineItems:       lineItems,
 			Note:            "Order created for manual subscription recurring payment",
 			FinancialStatus: "PENDING",
 		},
 	}
+	addAdjustmentsToOrder(&shopifyOrderRequest.Order, adjustments)
 
 	order, err := s.shopify.CreateOrder(ctx, shopifyOrderRequest)
 	if err != nil {
@@ -204,6 +388,101 @@ func (s *orderService) createOrderInShopify(
 	return order, userPolicyIDs, nil
 }
 
+// orderAdjustments are the amounts added to or discounted from a recurring order on top of its plans
+type orderAdjustments struct {
+	// prorations are the plan changes whose proration is billed in the order
+	prorations []dbModels.PolicyPlanChange
+	// discount is the household or coupon discount of the order, if any
+	discount       *models.OrderDiscount
+	discountAmount money.Amount
+}
+
+// getOrderAdjustments returns the plan change prorations not billed yet and the discount of the
+// next recurring order of the policies
+func (s *orderService) getOrderAdjustments(
+	ctx context.Context,
+	policies []dbModels.Policy,
+) (*orderAdjustments, error) {
+	policyIDs := getPolicyIDs(policies)
+
+	adjustments := &orderAdjustments{}
+	err := s.db.WithContext(ctx).
+		Where("policy_id IN ? AND status = ?", policyIDs, dbModels.PlanChangeApplied).
+		Where("proration_amount <> 0 AND proration_installment_id IS NULL").
+		Find(&adjustments.prorations).Error
+	if err != nil {
+		return nil, err
+	}
+
+	adjustments.discount, err = s.discountService.RecurringOrderDiscount(ctx, policies[0].UserID)
+	if err != nil {
+		return nil, err
+	}
+	if adjustments.discount == nil {
+		return adjustments, nil
+	}
+
+	// the discount applies to the plans only, at their current matrix price or the plan price
+	subtotal, err := s.policies.PlanSubtotal(ctx, policyIDs)
+	if err != nil {
+		return nil, err
+	}
+	adjustments.discountAmount = subtotal.Percent(adjustments.discount.Percentage)
+
+	return adjustments, nil
+}
+
+// addAdjustmentsToOrder adds the net plan change proration to the order, as a custom line item
+// when it is a charge or as part of the order discount when it is a credit. Shopify takes a
+// single discount code per order, so the credit and the discount are sent as one fixed amount.
+func addAdjustmentsToOrder(order *shopify.CreateOrderInput, adjustments *orderAdjustments) {
+	proration, discountAmount, codes := adjustmentAmounts(adjustments)
+
+	if proration.IsPositive() {
+		requiresShipping := false
+		priceSet := shopify.NewShopMoney(proration)
+		order.LineItems = append(order.LineItems, shopify.LineItemsNodeRequest{
+			Title:            prorationLineItemTitle,
+			Quantity:         1,
+			PriceSet:         &priceSet,
+			RequiresShipping: &requiresShipping,
+		})
+	}
+
+	if !discountAmount.IsPositive() {
+		return
+	}
+
+	order.DiscountCode = &shopify.DiscountCodeInput{
+		ItemFixedDiscountCode: &shopify.FixedDiscountCodeInput{
+			Code:      strings.Join(codes, "+"),
+			AmountSet: shopify.NewShopMoney(discountAmount),
+		},
+	}
+}
+
+// adjustmentAmounts returns the net plan change proration of an order, the discount sent with
+// it, which takes a proration credit, and the codes of that discount
+func adjustmentAmounts(adjustments *orderAdjustments) (proration, discount money.Amount, codes []string) {
+	for _, planChange := range adjustments.prorations {
+		proration = proration.Add(planChange.ProrationAmount)
+	}
+
+	if adjustments.discount != nil && adjustments.discountAmount.IsPositive() {
+		codes = append(codes, adjustments.discount.Code)
+		discount = discount.Add(adjustments.discountAmount)
+	}
+	if proration.IsNegative() {
+		codes = append(codes, prorationDiscountCode)
+		discount = discount.Sub(proration)
+	}
+	if !discount.IsPositive() {
+		return proration, money.Zero, nil
+	}
+
+	return proration, discount, codes
+}
+
 func getOrderLineItemsByPolicies(policies []dbModels.Policy) ([]shopify.LineItemsNodeRequest, []string) {
 	var lineItems []shopify.LineItemsNodeRequest
 	var policyIDs []string
@@ -225,19 +504,8 @@ func (s *orderService) ReminderPendingPolicies(ctx context.Context) error {
 	s.logger.Info("starting ReminderPendingPolicies process")
 	now := time.Now().In(s.loc)
 
-	var policies []dbModels.PolicyPayment
-	err := s.db.WithContext(ctx).Debug().
-		Select("policies_payments.*").
-		InnerJoins("PaymentInstallment", s.db.Select("ShopifyOrderID").Model(&dbModels.PaymentInstallment{})).
-		InnerJoins("Policy", s.db.Select("ID", "NextPayment").Where(&dbModels.Policy{
-			Status:   statusPendingPolicy,
-			IsManual: true,
-		})).
-		Joins("Policy.User", s.db.Select("Email", "Name").Model(&dbModels.User{})).
-		Joins("Policy.Pet", s.db.Select("Name").Model(&dbModels.Pet{})).
-		Find(&policies).Error
+	policies, err := s.policies.FindAwaitingPayment(ctx)
 	if err != nil {
-		s.logger.Error("failed to fetch policies by next_delivery_day", zap.Error(err))
 		return err
 	}
 
@@ -256,22 +524,11 @@ func (s *orderService) ReminderPendingPolicies(ctx context.Context) error {
 			continue
 		}
 
-		var (
-			nextPaymentDay = policyPayment.Policy.NextPayment.In(s.loc)
-			availableDays  = int(nextPaymentDay.Sub(now).Hours() / 24)
-			template       string
-		)
+		template, availableDays, cancel := s.reminderFor(policyPayment.Policy.NextPayment, now)
 
 		s.logger.Info("", zap.Any("days", availableDays), zap.Any("template", template), zap.Any("nextPaymentDay", policyPayment.Policy.NextPayment))
-		if availableDays >= 0 && availableDays <= 27 {
-			template = "reminder"
-		} else if availableDays == 0 {
-			template = "cancellation"
-		} else {
-			availableDays = 30
-			template = "reactivation"
-
-			err := s.UpdatePoliceStatus(ctx, policyPayment.Policy.ID, statusCanceled)
+		if cancel {
+			err := s.UpdatePoliceStatus(ctx, policyPayment.Policy.ID, statusCancelled)
 			if err != nil {
 				s.logger.Error("failed to update policy status to canceled", zap.String("policy_id", policyPayment.Policy.ID))
 			}
@@ -297,21 +554,71 @@ func (s *orderService) ReminderPendingPolicies(ctx context.Context) error {
 	return nil
 }
 
+// PlanReminderPendingPolicies returns the reminders ReminderPendingPolicies would send if it ran
+// at the date and the policies it would cancel, without sending mail or writing. The installments
+// are the ones awaiting payment now.
+func (s *orderService) PlanReminderPendingPolicies(ctx context.Context, date time.Time) (*models.ReminderPlan, error) {
+	now := date.In(s.loc)
+
+	policies, err := s.policies.FindAwaitingPayment(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &models.ReminderPlan{
+		Date:      now.Format("2006-01-02"),
+		Reminders: []models.PlannedReminder{},
+	}
+	byInstallment := make(map[string]int)
+	for _, policyPayment := range policies {
+		if i, exist := byInstallment[policyPayment.PaymentInstallmentID]; exist {
+			plan.Reminders[i].Pets = append(plan.Reminders[i].Pets, policyPayment.Policy.Pet.Name)
+			continue
+		}
+
+		template, availableDays, cancel := s.reminderFor(policyPayment.Policy.NextPayment, now)
+		reminder := models.PlannedReminder{
+			PaymentInstallmentID: policyPayment.PaymentInstallmentID,
+			ShopifyOrderID:       policyPayment.PaymentInstallment.ShopifyOrderID,
+			Pets:                 []string{policyPayment.Policy.Pet.Name},
+			DaysLeft:             30 - availableDays,
+			Notification: models.PlannedNotification{
+				Template: template,
+				To:       policyPayment.Policy.User.Email,
+			},
+		}
+		if cancel {
+			reminder.CancelPolicyID = policyPayment.Policy.ID
+		}
+
+		byInstallment[policyPayment.PaymentInstallmentID] = len(plan.Reminders)
+		plan.Reminders = append(plan.Reminders, reminder)
+	}
+
+	return plan, nil
+}
+
+// reminderFor returns the reminder template for an installment due on the next payment date and
+// the days available to pay it. Past the grace period the policy is cancelled.
+func (s *orderService) reminderFor(nextPayment, now time.Time) (template string, availableDays int, cancel bool) {
+	availableDays = int(nextPayment.In(s.loc).Sub(now).Hours() / 24)
+
+	if availableDays >= 0 && availableDays <= 27 {
+		return "reminder", availableDays, false
+	} else if availableDays == 0 {
+		return "cancellation", availableDays, false
+	}
+
+	return "reactivation", 30, true
+}
+
 // UpdatePoliceStatus updates the status of a policy
 func (o *orderService) UpdatePoliceStatus(
 	ctx context.Context,
 	policyID string,
 	status string,
 ) error {
-	err := o.db.WithContext(ctx).Model(&dbModels.Policy{}).
-		Where("id = ?", policyID).
-		Update("status", status).Error
-	if err != nil {
-		o.logger.Error(err.Error(), zap.String("policy_id", policyID))
-		return err
-	}
-
-	return nil
+	return o.policies.UpdateStatus(ctx, []string{policyID}, status)
 }
 
 // SendEmail sends an email to the customer
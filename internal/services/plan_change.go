@@ -0,0 +1,375 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"appa_subscriptions/internal/domains"
+	"appa_subscriptions/internal/models"
+	"appa_subscriptions/pkg/db"
+	dbModels "appa_subscriptions/pkg/db/models"
+	"appa_subscriptions/pkg/money"
+	"appa_subscriptions/pkg/shopify"
+)
+
+type planChangeService struct {
+	db             *gorm.DB
+	pricingService domains.PricingService
+	loc            *time.Location
+	logger         *zap.Logger
+}
+
+// NewPlanChangeService creates a new instance of PlanChangeService
+func NewPlanChangeService(
+	db *gorm.DB,
+	pricingService domains.PricingService,
+	loc *time.Location,
+	logger *zap.Logger,
+) domains.PlanChangeService {
+	return &planChangeService{
+		db:             db,
+		pricingService: pricingService,
+		loc:            loc,
+		logger:         logger,
+	}
+}
+
+// ChangePlan moves an active policy to another plan of the same pet type. Immediate changes
+// apply today and prorate the price difference over the rest of the billing period, charged
+// or credited on the next installment. Next billing changes are scheduled for the next
+// payment date and are not prorated. A new request replaces a scheduled change.
+func (s *planChangeService) ChangePlan(
+	ctx context.Context,
+	policyID string,
+	req models.ChangePlanRequest,
+) (change *dbModels.PolicyPlanChange, err error) {
+	var (
+		now   = time.Now().In(s.loc)
+		today = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, s.loc)
+	)
+
+	tx := s.db.Begin().WithContext(ctx)
+	defer db.DBRollback(tx, &err)
+
+	var policy dbModels.Policy
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", policyID).First(&policy).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = domains.ErrPolicyNotFound
+		return nil, err
+	}
+	if err != nil {
+		s.logger.Error("getting policy", zap.Error(err), zap.String("policy_id", policyID))
+		return nil, err
+	}
+	if policy.Status != statusActive {
+		err = domains.ErrPolicyNotActive
+		return nil, err
+	}
+
+	change, err = s.newPlanChange(tx, ctx, &policy, req, today)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Model(&dbModels.PolicyPlanChange{}).
+		Where("policy_id = ? AND status = ?", policy.ID, dbModels.PlanChangeScheduled).
+		Update("status", dbModels.PlanChangeCancelled).Error
+	if err != nil {
+		s.logger.Error("cancelling scheduled plan changes", zap.Error(err), zap.String("policy_id", policy.ID))
+		return nil, err
+	}
+
+	if req.Effective == dbModels.PlanChangeImmediate {
+		change.ProrationAmount = prorate(change.FromMonthlyPrice, change.ToMonthlyPrice, policy.NextPayment, today)
+		if err = s.applyPlanChange(tx, &policy, change, now); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = tx.Create(change).Error; err != nil {
+		s.logger.Error("creating plan change", zap.Error(err), zap.String("policy_id", policy.ID))
+		return nil, err
+	}
+
+	return change, nil
+}
+
+// ApplyScheduledChanges applies the next billing plan changes that are due, so the next
+// installment bills the new plan
+func (s *planChangeService) ApplyScheduledChanges(ctx context.Context) error {
+	now := time.Now().In(s.loc)
+
+	var changes []dbModels.PolicyPlanChange
+	err := s.db.WithContext(ctx).
+		Where("status = ? AND effective_date <= ?", dbModels.PlanChangeScheduled, now.Format("2006-01-02")).
+		Find(&changes).Error
+	if err != nil {
+		s.logger.Error("getting scheduled plan changes", zap.Error(err))
+		return err
+	}
+
+	for i := range changes {
+		if err := s.applyScheduledChange(ctx, &changes[i], now); err != nil {
+			s.logger.Error("applying scheduled plan change", zap.Error(err), zap.String("plan_change_id", changes[i].ID))
+		}
+	}
+
+	return nil
+}
+
+// PlanScheduledChanges returns the scheduled plan changes ApplyScheduledChanges would apply or
+// cancel if it ran at the date, without writing
+func (s *planChangeService) PlanScheduledChanges(ctx context.Context, date time.Time) (*models.PlanChangePlan, error) {
+	day := date.In(s.loc).Format("2006-01-02")
+
+	var changes []dbModels.PolicyPlanChange
+	err := s.db.WithContext(ctx).
+		Where("status = ? AND effective_date <= ?", dbModels.PlanChangeScheduled, day).
+		Order("effective_date").
+		Find(&changes).Error
+	if err != nil {
+		s.logger.Error("getting scheduled plan changes", zap.Error(err))
+		return nil, err
+	}
+
+	policyIDs := make([]string, 0, len(changes))
+	for _, change := range changes {
+		policyIDs = append(policyIDs, change.PolicyID)
+	}
+	var policies []dbModels.Policy
+	if err := s.db.WithContext(ctx).Where("id IN ?", policyIDs).Find(&policies).Error; err != nil {
+		s.logger.Error("getting policies of scheduled plan changes", zap.Error(err))
+		return nil, err
+	}
+	byID := make(map[string]dbModels.Policy, len(policies))
+	for _, policy := range policies {
+		byID[policy.ID] = policy
+	}
+
+	plan := &models.PlanChangePlan{Date: day, Changes: make([]models.PlannedPlanChange, 0, len(changes))}
+	for _, change := range changes {
+		policy, ok := byID[change.PolicyID]
+		plan.Changes = append(plan.Changes, models.PlannedPlanChange{
+			PlanChangeID:   change.ID,
+			PolicyID:       change.PolicyID,
+			FromPlanID:     change.FromPlanID,
+			ToPlanID:       change.ToPlanID,
+			ToMonthlyPrice: change.ToMonthlyPrice,
+			EffectiveDate:  change.EffectiveDate.Format("2006-01-02"),
+			Apply:          ok && policy.Status == statusActive && policy.PlanID == change.FromPlanID,
+		})
+	}
+
+	return plan, nil
+}
+
+// GetPlanHistory returns the plan changes of a policy, newest first
+func (s *planChangeService) GetPlanHistory(ctx context.Context, policyID string) ([]dbModels.PolicyPlanChange, error) {
+	var changes []dbModels.PolicyPlanChange
+	err := s.db.WithContext(ctx).
+		Where("policy_id = ?", policyID).
+		Order("created_at DESC").
+		Find(&changes).Error
+	if err != nil {
+		s.logger.Error("getting plan history", zap.Error(err), zap.String("policy_id", policyID))
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// applyScheduledChange applies a scheduled change, or cancels it when the policy is no longer active
+func (s *planChangeService) applyScheduledChange(
+	ctx context.Context,
+	change *dbModels.PolicyPlanChange,
+	now time.Time,
+) (err error) {
+	tx := s.db.Begin().WithContext(ctx)
+	defer db.DBRollback(tx, &err)
+
+	var policy dbModels.Policy
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", change.PolicyID).First(&policy).Error
+	if err != nil {
+		return err
+	}
+
+	if policy.Status != statusActive || policy.PlanID != change.FromPlanID {
+		s.logger.Warn("cancelling scheduled plan change",
+			zap.String("plan_change_id", change.ID),
+			zap.String("policy_status", policy.Status),
+		)
+		return tx.Model(change).Update("status", dbModels.PlanChangeCancelled).Error
+	}
+
+	if err = s.applyPlanChange(tx, &policy, change, now); err != nil {
+		return err
+	}
+
+	return tx.Model(change).Updates(map[string]any{
+		"status":         change.Status,
+		"balance_before": change.BalanceBefore,
+		"balance_after":  change.BalanceAfter,
+		"applied_at":     change.AppliedAt,
+	}).Error
+}
+
+// newPlanChange validates the target plan and prices it for the pet of the policy
+func (s *planChangeService) newPlanChange(
+	tx *gorm.DB,
+	ctx context.Context,
+	policy *dbModels.Policy,
+	req models.ChangePlanRequest,
+	today time.Time,
+) (*dbModels.PolicyPlanChange, error) {
+	if req.PlanID == policy.PlanID {
+		return nil, fmt.Errorf("%w: the policy is already on this plan", domains.ErrInvalidPlanChange)
+	}
+
+	var plans []dbModels.Plan
+	if err := tx.Where("id IN ?", []string{policy.PlanID, req.PlanID}).Find(&plans).Error; err != nil {
+		s.logger.Error("getting plans", zap.Error(err))
+		return nil, err
+	}
+	var fromPlan, toPlan *dbModels.Plan
+	for i := range plans {
+		switch plans[i].ID {
+		case policy.PlanID:
+			fromPlan = &plans[i]
+		case req.PlanID:
+			toPlan = &plans[i]
+		}
+	}
+	if fromPlan == nil || toPlan == nil {
+		return nil, domains.ErrPlanNotFound
+	}
+	if fromPlan.PetTypeID != toPlan.PetTypeID {
+		return nil, fmt.Errorf("%w: the plan is for another pet type", domains.ErrInvalidPlanChange)
+	}
+
+	// the pet profile the policy is priced for comes from its current variant
+	var current dbModels.PlanPrice
+	err := tx.Where("shopify_variant_id = ?", shopify.LegacyID(policy.ShopifyID)).
+		Order("effective_from DESC").
+		Limit(1).
+		Find(&current).Error
+	if err != nil {
+		s.logger.Error("getting plan price of policy variant", zap.Error(err), zap.String("variant_id", policy.ShopifyID))
+		return nil, err
+	}
+	if current.ID == "" {
+		return nil, domains.ErrPlanPriceNotFound
+	}
+
+	quoteReq := models.PlanPriceQuoteRequest{
+		PlanID:      fromPlan.ID,
+		AgeRangeID:  current.AgeRangeID,
+		ConditionID: current.ConditionID,
+		Date:        &today,
+	}
+	if current.SizeID != nil {
+		quoteReq.SizeID = *current.SizeID
+	}
+	fromQuote, err := s.pricingService.QuotePlanPrice(ctx, quoteReq)
+	if err != nil {
+		return nil, err
+	}
+	quoteReq.PlanID = toPlan.ID
+	toQuote, err := s.pricingService.QuotePlanPrice(ctx, quoteReq)
+	if err != nil {
+		return nil, err
+	}
+
+	effectiveDate := today
+	if req.Effective == dbModels.PlanChangeNextBilling {
+		effectiveDate = policy.NextPayment
+	}
+
+	return &dbModels.PolicyPlanChange{
+		PolicyID:         policy.ID,
+		FromPlanID:       fromPlan.ID,
+		ToPlanID:         toPlan.ID,
+		FromVariantID:    policy.ShopifyID,
+		ToVariantID:      toQuote.ShopifyVariantID,
+		FromMonthlyPrice: fromQuote.MonthlyPrice,
+		ToMonthlyPrice:   toQuote.MonthlyPrice,
+		Effective:        req.Effective,
+		EffectiveDate:    effectiveDate,
+		Status:           dbModels.PlanChangeScheduled,
+		RequestedBy:      req.RequestedBy,
+	}, nil
+}
+
+// applyPlanChange moves the policy to the new plan and variant and rescales its balance
+func (s *planChangeService) applyPlanChange(
+	tx *gorm.DB,
+	policy *dbModels.Policy,
+	change *dbModels.PolicyPlanChange,
+	now time.Time,
+) error {
+	var limits []dbModels.Plan
+	if err := tx.Select("id", "annual_limit").Where("id IN ?", []string{change.FromPlanID, change.ToPlanID}).Find(&limits).Error; err != nil {
+		return err
+	}
+	var fromLimit, toLimit money.Amount
+	for _, plan := range limits {
+		if plan.ID == change.FromPlanID {
+			fromLimit = plan.AnnualLimit
+		}
+		if plan.ID == change.ToPlanID {
+			toLimit = plan.AnnualLimit
+		}
+	}
+
+	balanceBefore := policy.RemainingBalance
+	balanceAfter := rescaleBalance(balanceBefore, fromLimit, toLimit)
+
+	err := tx.Model(&dbModels.Policy{}).
+		Where("id = ?", policy.ID).
+		Updates(map[string]any{
+			"plan_id":           change.ToPlanID,
+			"shopify_id":        change.ToVariantID,
+			"remaining_balance": balanceAfter,
+		}).Error
+	if err != nil {
+		s.logger.Error("changing policy plan", zap.Error(err), zap.String("policy_id", policy.ID))
+		return err
+	}
+
+	change.Status = dbModels.PlanChangeApplied
+	change.BalanceBefore = &balanceBefore
+	change.BalanceAfter = &balanceAfter
+	change.AppliedAt = &now
+
+	return nil
+}
+
+// rescaleBalance keeps the share of the annual limit that is still available: a policy that
+// used 40% of the old limit keeps 60% of the new one. The result is rounded to cents.
+func rescaleBalance(balance, fromLimit, toLimit money.Amount) money.Amount {
+	if !fromLimit.IsPositive() {
+		return toLimit
+	}
+
+	return balance.Rescale(toLimit, fromLimit)
+}
+
+// prorate returns the price difference for the days left until the next payment, as a share of
+// the monthly billing period ending on it. A negative amount is a credit.
+func prorate(fromPrice, toPrice money.Amount, nextPayment, today time.Time) money.Amount {
+	periodStart := nextPayment.AddDate(0, -1, 0)
+	periodDays := int64(math.Round(nextPayment.Sub(periodStart).Hours() / 24))
+	remainingDays := int64(math.Ceil(nextPayment.Sub(today).Hours() / 24))
+	if periodDays <= 0 || remainingDays <= 0 {
+		return money.Zero
+	}
+	remainingDays = min(remainingDays, periodDays)
+
+	return toPrice.Sub(fromPrice).MulRatio(remainingDays, periodDays)
+}
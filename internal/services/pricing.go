@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"appa_subscriptions/internal/domains"
+	"appa_subscriptions/internal/models"
+	dbModels "appa_subscriptions/pkg/db/models"
+	"appa_subscriptions/pkg/money"
+)
+
+type pricingService struct {
+	db     *gorm.DB
+	loc    *time.Location
+	logger *zap.Logger
+}
+
+// NewPricingService creates a new instance of PricingService
+func NewPricingService(db *gorm.DB, loc *time.Location, logger *zap.Logger) domains.PricingService {
+	return &pricingService{
+		db:     db,
+		loc:    loc,
+		logger: logger,
+	}
+}
+
+// QuotePlanPrice returns the monthly price of a plan for the pet profile on the requested date
+func (s *pricingService) QuotePlanPrice(
+	ctx context.Context,
+	req models.PlanPriceQuoteRequest,
+) (*models.PlanPriceQuote, error) {
+	date := time.Now().In(s.loc)
+	if req.Date != nil {
+		date = *req.Date
+	}
+
+	query := s.db.WithContext(ctx).
+		Where(
+			"plan_id = ? AND age_range_id = ? AND condition_id = ? AND effective_from <= ? AND (effective_to IS NULL OR effective_to > ?)",
+			req.PlanID, req.AgeRangeID, req.ConditionID, date, date,
+		)
+	if req.SizeID == "" {
+		query = query.Where("size_id IS NULL")
+	} else {
+		query = query.Where("size_id = ?", req.SizeID)
+	}
+
+	var planPrice dbModels.PlanPrice
+	if err := query.Order("effective_from DESC").Limit(1).Find(&planPrice).Error; err != nil {
+		s.logger.Error("getting plan price", zap.Error(err), zap.Any("req", req))
+		return nil, err
+	}
+	if planPrice.ID == "" {
+		return nil, domains.ErrPlanPriceNotFound
+	}
+
+	return &models.PlanPriceQuote{
+		PlanID:           planPrice.PlanID,
+		PlanPriceID:      planPrice.ID,
+		MonthlyPrice:     planPrice.MonthlyPrice,
+		ShopifyVariantID: planPrice.ShopifyVariantID,
+		EffectiveFrom:    planPrice.EffectiveFrom,
+	}, nil
+}
+
+// VerifyLineItemPrice compares the price charged for a line item with the price matrix
+// and records a price discrepancy when they differ. The discrepancy is written in the
+// transaction of the order, so a redelivered order does not record it twice.
+func (s *pricingService) VerifyLineItemPrice(
+	tx *gorm.DB,
+	ctx context.Context,
+	orderID string,
+	item models.LineItem,
+	req models.PlanPriceQuoteRequest,
+) error {
+	charged, err := money.Parse(item.PriceSet.ShopMoney.Amount)
+	if err != nil {
+		s.logger.Error(err.Error(), zap.String("amount", item.PriceSet.ShopMoney.Amount))
+		return err
+	}
+
+	discrepancy := dbModels.PriceDiscrepancy{
+		ShopifyOrderID:   orderID,
+		ShopifyVariantID: fmt.Sprintf("%d", item.VariantID),
+		ChargedPrice:     charged,
+	}
+
+	quote, err := s.QuotePlanPrice(ctx, req)
+	switch {
+	case errors.Is(err, domains.ErrPlanPriceNotFound):
+		discrepancy.Reason = "no price configured for the pet profile"
+	case err != nil:
+		return err
+	case quote.MonthlyPrice.Equal(charged):
+		return nil
+	default:
+		discrepancy.Reason = "charged price differs from the price matrix"
+		discrepancy.PlanPriceID = &quote.PlanPriceID
+		discrepancy.ExpectedPrice = &quote.MonthlyPrice
+	}
+
+	s.logger.Warn("mispriced line item", zap.String("order_id", orderID), zap.Any("discrepancy", discrepancy))
+	if err := tx.WithContext(ctx).Create(&discrepancy).Error; err != nil {
+		s.logger.Error("creating price discrepancy", zap.Error(err), zap.String("order_id", orderID))
+		return err
+	}
+
+	return nil
+}
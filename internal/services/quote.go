@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"appa_subscriptions/internal/domains"
+	"appa_subscriptions/internal/models"
+	helpers "appa_subscriptions/pkg"
+	dbModels "appa_subscriptions/pkg/db/models"
+)
+
+type quoteService struct {
+	db                  *gorm.DB
+	pricingService      domains.PricingService
+	underwritingService domains.UnderwritingService
+	loc                 *time.Location
+	logger              *zap.Logger
+}
+
+// NewQuoteService creates a new instance of QuoteService
+func NewQuoteService(
+	db *gorm.DB,
+	pricingService domains.PricingService,
+	underwritingService domains.UnderwritingService,
+	loc *time.Location,
+	logger *zap.Logger,
+) domains.QuoteService {
+	return &quoteService{
+		db:                  db,
+		pricingService:      pricingService,
+		underwritingService: underwritingService,
+		loc:                 loc,
+		logger:              logger,
+	}
+}
+
+// Quote maps the pet profile to the catalog and returns the plans the pet is eligible for,
+// priced with the same price matrix used to verify orders. Each plan carries the underwriting
+// decision the order would get, so plans held for review or with exclusions are flagged.
+func (s *quoteService) Quote(
+	ctx context.Context,
+	req models.QuoteRequest,
+) (*models.QuoteResponse, error) {
+	now := time.Now().In(s.loc)
+
+	birthday, err := helpers.ParseBirthday(req.Birthday, s.loc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", domains.ErrInvalidPetProfile, err.Error())
+	}
+	if birthday.After(now) {
+		return nil, fmt.Errorf("%w: birthday is in the future", domains.ErrInvalidPetProfile)
+	}
+
+	var petType dbModels.PetType
+	err = s.db.WithContext(ctx).
+		Where(dbModels.PetType{Name: strings.ToLower(req.PetType)}).
+		Limit(1).Find(&petType).Error
+	if err != nil {
+		s.logger.Error("getting pet type", zap.Error(err))
+		return nil, err
+	}
+	if petType.ID == "" {
+		return nil, fmt.Errorf("%w: unknown pet type %q", domains.ErrInvalidPetProfile, req.PetType)
+	}
+
+	response := &models.QuoteResponse{
+		PetType:   petType.Name,
+		AgeMonths: helpers.AgeInMonths(birthday, now),
+		Plans:     make([]models.QuotePlan, 0),
+	}
+
+	var ageRange dbModels.PetAgeRange
+	err = s.db.WithContext(ctx).
+		Where("pet_type_id = ? AND min_age_months <= ? AND (max_age_months IS NULL OR max_age_months > ?)", petType.ID, response.AgeMonths, response.AgeMonths).
+		Order("min_age_months DESC").
+		Limit(1).Find(&ageRange).Error
+	if err != nil {
+		s.logger.Error("getting pet age range", zap.Error(err))
+		return nil, err
+	}
+	if ageRange.ID == "" {
+		return response, nil
+	}
+	response.AgeRange = ageRange.Name
+
+	var size dbModels.PetSize
+	if req.Size != "" {
+		err = s.db.WithContext(ctx).
+			Where(dbModels.PetSize{Name: strings.ToLower(req.Size), PetTypeID: petType.ID}).
+			Limit(1).Find(&size).Error
+		if err != nil {
+			s.logger.Error("getting pet size", zap.Error(err))
+			return nil, err
+		}
+		if size.ID == "" {
+			return nil, fmt.Errorf("%w: unknown size %q for %s", domains.ErrInvalidPetProfile, req.Size, petType.Name)
+		}
+	} else {
+		// prices of a pet type with sizes are set by size, none would match without one
+		var sizes int64
+		err = s.db.WithContext(ctx).Model(&dbModels.PetSize{}).Where("pet_type_id = ?", petType.ID).Count(&sizes).Error
+		if err != nil {
+			s.logger.Error("counting pet sizes", zap.Error(err))
+			return nil, err
+		}
+		if sizes > 0 {
+			return nil, fmt.Errorf("%w: size is required for %s", domains.ErrInvalidPetProfile, petType.Name)
+		}
+	}
+
+	var condition dbModels.PetCondition
+	conditionQuery := s.db.WithContext(ctx).
+		Where("pet_type_id = ? AND neutered = ? AND gender IS NOT NULL", petType.ID, *req.Neutered)
+	if req.Gender != "" {
+		conditionQuery = conditionQuery.Where("gender = ?", strings.ToLower(req.Gender))
+	}
+	if err := conditionQuery.Order("name").Limit(1).Find(&condition).Error; err != nil {
+		s.logger.Error("getting pet condition", zap.Error(err))
+		return nil, err
+	}
+	if condition.ID == "" {
+		return nil, fmt.Errorf("%w: no condition for the gender and neutered status", domains.ErrInvalidPetProfile)
+	}
+
+	var plans []dbModels.Plan
+	err = s.db.WithContext(ctx).
+		Where(dbModels.Plan{PetTypeID: petType.ID}).
+		Order("annual_limit, name").
+		Find(&plans).Error
+	if err != nil {
+		s.logger.Error("getting plans", zap.Error(err))
+		return nil, err
+	}
+
+	waitingPeriods, err := s.getWaitingPeriodsByPlan(ctx, plans)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, plan := range plans {
+		quote, err := s.pricingService.QuotePlanPrice(ctx, models.PlanPriceQuoteRequest{
+			PlanID:      plan.ID,
+			AgeRangeID:  ageRange.ID,
+			SizeID:      size.ID,
+			ConditionID: condition.ID,
+			Date:        &now,
+		})
+		if errors.Is(err, domains.ErrPlanPriceNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		decision, err := s.underwritingService.Evaluate(s.db, ctx, models.UnderwritingInput{
+			PetTypeID: petType.ID,
+			PlanID:    plan.ID,
+			Breed:     req.Breed,
+			Birthday:  req.Birthday,
+			Neutered:  strconv.FormatBool(*req.Neutered),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		response.Plans = append(response.Plans, models.QuotePlan{
+			PlanID:           plan.ID,
+			Name:             plan.Name,
+			MonthlyPrice:     quote.MonthlyPrice,
+			AnnualLimit:      plan.AnnualLimit,
+			WaitingPeriods:   waitingPeriods[plan.ID],
+			ShopifyVariantID: quote.ShopifyVariantID,
+			Underwriting:     *decision,
+		})
+	}
+
+	return response, nil
+}
+
+// getWaitingPeriodsByPlan returns the waiting periods of the plans grouped by plan ID
+func (s *quoteService) getWaitingPeriodsByPlan(
+	ctx context.Context,
+	plans []dbModels.Plan,
+) (map[string][]models.QuoteWaitingPeriod, error) {
+	planIDs := make([]string, 0, len(plans))
+	for _, plan := range plans {
+		planIDs = append(planIDs, plan.ID)
+	}
+
+	var waitingPeriods []dbModels.PlanWaitingPeriod
+	err := s.db.WithContext(ctx).
+		Where("plan_id IN ?", planIDs).
+		Order("days").
+		Find(&waitingPeriods).Error
+	if err != nil {
+		s.logger.Error("getting plan waiting periods", zap.Error(err))
+		return nil, err
+	}
+
+	waitingPeriodsMap := make(map[string][]models.QuoteWaitingPeriod)
+	for _, waitingPeriod := range waitingPeriods {
+		waitingPeriodsMap[waitingPeriod.PlanID] = append(waitingPeriodsMap[waitingPeriod.PlanID], models.QuoteWaitingPeriod{
+			CoverageType: waitingPeriod.CoverageType,
+			Days:         waitingPeriod.Days,
+		})
+	}
+
+	return waitingPeriodsMap, nil
+}
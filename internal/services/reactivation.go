@@ -0,0 +1,273 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"appa_subscriptions/internal/domains"
+	"appa_subscriptions/internal/models"
+	"appa_subscriptions/pkg/db"
+	dbModels "appa_subscriptions/pkg/db/models"
+	"appa_subscriptions/pkg/db/repositories"
+	"appa_subscriptions/pkg/shopify"
+)
+
+const tagPolicyReactivation = "policy_reactivation"
+
+type reactivationService struct {
+	db                     *gorm.DB
+	shopify                shopify.Repository
+	PaymentInstallmentRepo repositories.PaymentInstallmentRepository
+	policies               repositories.PolicyRepository
+	coverageService        domains.CoverageService
+	exchangeRateService    domains.ExchangeRateService
+	loc                    *time.Location
+	logger                 *zap.Logger
+}
+
+// NewReactivationService creates a new instance of ReactivationService
+func NewReactivationService(
+	db *gorm.DB,
+	shopifyRepo shopify.Repository,
+	PaymentInstallmentRepo repositories.PaymentInstallmentRepository,
+	policyRepo repositories.PolicyRepository,
+	coverageService domains.CoverageService,
+	exchangeRateService domains.ExchangeRateService,
+	loc *time.Location,
+	logger *zap.Logger,
+) domains.ReactivationService {
+	return &reactivationService{
+		db:                     db,
+		shopify:                shopifyRepo,
+		PaymentInstallmentRepo: PaymentInstallmentRepo,
+		policies:               policyRepo,
+		coverageService:        coverageService,
+		exchangeRateService:    exchangeRateService,
+		loc:                    loc,
+		logger:                 logger,
+	}
+}
+
+// ReactivatePolicy brings a cancelled policy back. The waiting periods start again from today
+// and a Shopify order is created for the first payment; the policy stays payment_pending and
+// becomes active when its installment is paid.
+func (s *reactivationService) ReactivatePolicy(
+	ctx context.Context,
+	policyID string,
+	req models.ReactivatePolicyRequest,
+) (*models.PolicyReactivationResponse, error) {
+	if req.HealthDeclared == nil || !*req.HealthDeclared {
+		return nil, domains.ErrHealthDeclarationRequired
+	}
+
+	var policy dbModels.Policy
+	err := s.db.WithContext(ctx).
+		Preload("User").
+		Preload("Pet").
+		Where("id = ?", policyID).
+		First(&policy).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domains.ErrPolicyNotFound
+	}
+	if err != nil {
+		s.logger.Error("getting policy", zap.Error(err), zap.String("policy_id", policyID))
+		return nil, err
+	}
+	if policy.Status != statusCancelled {
+		return nil, domains.ErrPolicyNotCancelled
+	}
+
+	order, err := s.createReactivationOrder(ctx, &policy)
+	if err != nil {
+		return nil, err
+	}
+
+	installment, err := s.reactivate(ctx, &policy, order, req)
+	if err != nil {
+		// the order would never be paid, void it so the customer cannot pay it
+		_, cancelErr := s.shopify.CancelOrder(ctx, shopify.CancelOrderRequest{
+			OrderID:   order.ID,
+			Reason:    shopify.CancelReasonOther,
+			StaffNote: "policy reactivation failed",
+		})
+		if cancelErr != nil {
+			s.logger.Error("cancelling reactivation order", zap.Error(cancelErr), zap.String("order_id", order.ID))
+		}
+		return nil, err
+	}
+
+	orderID := shopify.LegacyID(order.ID)
+	payUrl := fmt.Sprintf("https://pay.appasalud.com/?orderId=%s", orderID)
+
+	petName := ""
+	if policy.Pet != nil {
+		petName = policy.Pet.Name
+	}
+	notificationJobsQueue <- notificationJob{
+		vars: models.ConfirmationOrderEmailVars{
+			FirtsName: policy.User.Name,
+			PetsList:  []string{petName},
+			PayUrl:    payUrl,
+		},
+		email:    policy.User.Email,
+		template: "create_order",
+	}
+
+	return &models.PolicyReactivationResponse{
+		PolicyID:             policy.ID,
+		Status:               statusPendingPolicy,
+		PaymentInstallmentID: installment.ID,
+		ShopifyOrderID:       orderID,
+		PayUrl:               payUrl,
+	}, nil
+}
+
+// reactivate moves the policy back to payment_pending with a fresh health declaration and
+// registers the installment of the reactivation order
+func (s *reactivationService) reactivate(
+	ctx context.Context,
+	policy *dbModels.Policy,
+	order *shopify.OrderCreateResponse,
+	req models.ReactivatePolicyRequest,
+) (installment *dbModels.PaymentInstallment, err error) {
+	var (
+		now   = time.Now().In(s.loc)
+		today = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, s.loc)
+	)
+
+	presentmentAmount, presentmentCurrency := presentmentMoney(order.TotalPriceSet)
+	rate := snapshotRate(ctx, s.exchangeRateService, today, s.logger)
+
+	tx := s.db.Begin().WithContext(ctx)
+	defer db.DBRollback(tx, &err)
+
+	updates := map[string]any{
+		"status":                      statusPendingPolicy,
+		"health_declared":             true,
+		"next_payment":                today.Format("2006-01-02"),
+		"cancellation_reason":         nil,
+		"cancellation_notes":          nil,
+		"cancellation_requested_at":   nil,
+		"cancellation_effective_date": nil,
+		"cancelled_at":                nil,
+	}
+	if !req.KeepStartDate {
+		updates["start_date"] = today.Format("2006-01-02")
+	}
+
+	// a limit period that ended while cancelled starts over with the full plan limit
+	if !policy.LimitPeriodEnd.After(today) {
+		var plan dbModels.Plan
+		if err = tx.Where("id = ?", policy.PlanID).First(&plan).Error; err != nil {
+			s.logger.Error("getting plan", zap.Error(err), zap.String("plan_id", policy.PlanID))
+			return nil, err
+		}
+		updates["limit_period_start"] = today.Format("2006-01-02")
+		updates["limit_period_end"] = today.AddDate(1, 0, 0).Format("2006-01-02")
+		updates["remaining_balance"] = plan.AnnualLimit
+	}
+
+	result := tx.Model(&dbModels.Policy{}).
+		Where("id = ? AND status = ?", policy.ID, statusCancelled).
+		Updates(updates)
+	if err = result.Error; err != nil {
+		s.logger.Error("reactivating policy", zap.Error(err), zap.String("policy_id", policy.ID))
+		return nil, err
+	}
+	// reactivated by a concurrent request, its order is the one to pay
+	if result.RowsAffected == 0 {
+		err = domains.ErrPolicyNotCancelled
+		return nil, err
+	}
+
+	if err = s.coverageService.ComputeEligibility(tx, ctx, policy, now); err != nil {
+		return nil, err
+	}
+
+	installment, err = s.PaymentInstallmentRepo.Create(tx, ctx, repositories.CreateInstallmentInput{
+		OrderID:             order.ID,
+		OrderName:           order.Name,
+		Status:              statusPendingPayment,
+		Amount:              order.TotalPriceSet.ShopMoney.Amount,
+		Currency:            order.TotalPriceSet.ShopMoney.CurrencyCode,
+		PresentmentAmount:   presentmentAmount,
+		PresentmentCurrency: presentmentCurrency,
+		ExchangeRate:        rate,
+		DueDate:             today,
+		PolicyIDs:           []string{policy.ID},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	items := installmentItemsFromOrder(order, newPolicyMatcher([]dbModels.Policy{*policy}))
+	if err = s.PaymentInstallmentRepo.CreateItems(tx, ctx, installment.ID, items); err != nil {
+		return nil, err
+	}
+
+	if err = s.policies.WithTx(tx).LinkInstallment(ctx, []string{policy.ID}, installment.ID); err != nil {
+		return nil, err
+	}
+
+	preexistingConditions := req.PreexistingConditions
+	if preexistingConditions == nil {
+		preexistingConditions = []string{}
+	}
+	err = tx.Create(&dbModels.PolicyReactivation{
+		PolicyID:              policy.ID,
+		PaymentInstallmentID:  installment.ID,
+		CancelledAt:           policy.CancelledAt,
+		CancellationReason:    policy.CancellationReason,
+		PreexistingConditions: preexistingConditions,
+		KeptStartDate:         req.KeepStartDate,
+		RequestedBy:           req.RequestedBy,
+	}).Error
+	if err != nil {
+		s.logger.Error("recording policy reactivation", zap.Error(err), zap.String("policy_id", policy.ID))
+		return nil, err
+	}
+
+	return installment, nil
+}
+
+// createReactivationOrder creates the Shopify order that pays the first installment of the
+// reactivated policy
+func (s *reactivationService) createReactivationOrder(
+	ctx context.Context,
+	policy *dbModels.Policy,
+) (*shopify.OrderCreateResponse, error) {
+	if policy.User == nil {
+		return nil, fmt.Errorf("policy %s has no user", policy.ID)
+	}
+
+	shopifyUserID := policy.User.ShopifyID
+	if !strings.Contains(shopifyUserID, shopify.CustomerKind) {
+		shopifyUserID = shopify.GID(shopify.CustomerKind, shopifyUserID)
+	}
+	lineItems, _ := getOrderLineItemsByPolicies([]dbModels.Policy{*policy})
+
+	shopifyOrderRequest := shopify.CreateOrderInShopifyRequest{
+		Order: shopify.CreateOrderInput{
+			CustomerID:      shopifyUserID,
+			Email:           policy.User.Email,
+			Tags:            []string{tagManualSubscriptionRecurringOrder, tagPolicyReactivation},
+			LineItems:       lineItems,
+			Note:            "Order created for policy reactivation",
+			FinancialStatus: "PENDING",
+		},
+	}
+
+	order, err := s.shopify.CreateOrder(ctx, shopifyOrderRequest)
+	if err != nil {
+		s.logger.Error("creating reactivation order", zap.Error(err), zap.Any("request", shopifyOrderRequest))
+		return nil, err
+	}
+
+	return order, nil
+}
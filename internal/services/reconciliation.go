@@ -0,0 +1,402 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"appa_subscriptions/internal/domains"
+	"appa_subscriptions/internal/models"
+	"appa_subscriptions/pkg/db"
+	dbModels "appa_subscriptions/pkg/db/models"
+	"appa_subscriptions/pkg/db/repositories"
+	"appa_subscriptions/pkg/money"
+	"appa_subscriptions/pkg/shopify"
+)
+
+const (
+	// reconciliationBatchSize is the number of orders looked up in Shopify per search
+	reconciliationBatchSize = 50
+	// reconciliationOrdersLimit is the most recent Shopify orders checked for a local installment
+	reconciliationOrdersLimit = 250
+	// reconciliationWindow is how far back Shopify orders are checked for a local installment
+	reconciliationWindow = 7 * 24 * time.Hour
+	// reconciliationGrace leaves out the newest orders, whose webhooks may still be on their way
+	reconciliationGrace = time.Hour
+)
+
+// Shopify financial statuses of orders still waiting for their payment
+var unpaidFinancialStatuses = []string{"PENDING", "AUTHORIZED", "EXPIRED"}
+
+type reconciliationService struct {
+	db             *gorm.DB
+	shopify        shopify.Repository
+	installments   repositories.PaymentInstallmentRepository
+	manualPayments domains.ManualPaymentService
+	loc            *time.Location
+	logger         *zap.Logger
+}
+
+// NewReconciliationService creates a new instance of ReconciliationService
+func NewReconciliationService(
+	db *gorm.DB,
+	shopifyRepo shopify.Repository,
+	installmentRepo repositories.PaymentInstallmentRepository,
+	manualPaymentService domains.ManualPaymentService,
+	loc *time.Location,
+	logger *zap.Logger,
+) domains.ReconciliationService {
+	return &reconciliationService{
+		db:             db,
+		shopify:        shopifyRepo,
+		installments:   installmentRepo,
+		manualPayments: manualPaymentService,
+		loc:            loc,
+		logger:         logger,
+	}
+}
+
+// ReconcilePayments compares the pending and overdue installments with their Shopify orders.
+// Installments whose order is paid for the same amount are marked paid, as the lost orders/paid
+// webhook would have done. Anything else is written to the discrepancy report of the day, which
+// replaces an earlier report of the same day. The Shopify orders of approved manual payments
+// that could not be marked paid on approval are marked first.
+func (s *reconciliationService) ReconcilePayments(ctx context.Context) (*models.ReconciliationReport, error) {
+	now := time.Now().In(s.loc)
+	runDate := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, s.loc)
+
+	ordersMarked, err := s.manualPayments.MarkApprovedOrdersPaid(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	installments, err := s.installments.FindByStatus(s.db, ctx, dbModels.OpenInstallmentStatuses...)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.ReconciliationReport{
+		RunDate:          runDate.Format("2006-01-02"),
+		Checked:          len(installments),
+		OrdersMarkedPaid: ordersMarked,
+		Discrepancies:    map[string]int{},
+	}
+
+	var (
+		discrepancies []dbModels.PaymentDiscrepancy
+		orderIDs      []string
+		byOrder       = map[string][]dbModels.PaymentInstallment{}
+	)
+	for _, installment := range installments {
+		if installment.ShopifyOrderID == "" {
+			discrepancies = append(discrepancies, installmentDiscrepancy(runDate, dbModels.DiscrepancyInstallmentWithoutOrder, installment, nil))
+			continue
+		}
+		if _, ok := byOrder[installment.ShopifyOrderID]; !ok {
+			orderIDs = append(orderIDs, installment.ShopifyOrderID)
+		}
+		byOrder[installment.ShopifyOrderID] = append(byOrder[installment.ShopifyOrderID], installment)
+	}
+
+	orders, err := s.getOrders(ctx, orderIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, orderID := range orderIDs {
+		orderInstallments := byOrder[orderID]
+		order, ok := orders[orderID]
+		if !ok {
+			for _, installment := range orderInstallments {
+				discrepancies = append(discrepancies, installmentDiscrepancy(runDate, dbModels.DiscrepancyInstallmentWithoutOrder, installment, nil))
+			}
+			continue
+		}
+
+		kind, fix := reconcileOrder(order, orderInstallments)
+		if fix {
+			fixed, err := s.markPaid(ctx, orderInstallments, now)
+			if err != nil {
+				return nil, err
+			}
+			report.Fixed += fixed
+			continue
+		}
+		if kind != "" {
+			for _, installment := range orderInstallments {
+				discrepancies = append(discrepancies, installmentDiscrepancy(runDate, kind, installment, &order))
+			}
+		}
+	}
+
+	missing, checked, err := s.ordersMissingLocally(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	report.OrdersChecked = checked
+	for _, order := range missing {
+		discrepancies = append(discrepancies, orderDiscrepancy(runDate, order))
+	}
+
+	if err := s.saveDiscrepancies(ctx, runDate, discrepancies); err != nil {
+		return nil, err
+	}
+
+	for _, discrepancy := range discrepancies {
+		report.Discrepancies[discrepancy.Kind]++
+	}
+
+	s.logger.Info("payments reconciled",
+		zap.Int("checked", report.Checked),
+		zap.Int("fixed", report.Fixed),
+		zap.Int("orders_marked_paid", report.OrdersMarkedPaid),
+		zap.Int("orders_checked", report.OrdersChecked),
+		zap.Int("discrepancies", len(discrepancies)))
+
+	return report, nil
+}
+
+// PlanReconciliation returns the installments ReconcilePayments would check if it ran at the
+// date and the window of recent orders it would look for, without calling Shopify or writing.
+// The installments are the ones payable now.
+func (s *reconciliationService) PlanReconciliation(ctx context.Context, date time.Time) (*models.ReconciliationPlan, error) {
+	now := date.In(s.loc)
+
+	installments, err := s.installments.FindByStatus(s.db, ctx, dbModels.OpenInstallmentStatuses...)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &models.ReconciliationPlan{
+		Date:            now.Format("2006-01-02"),
+		Installments:    len(installments),
+		ShopifyOrderIDs: []string{},
+		WithoutOrder:    []string{},
+		OrdersFrom:      now.Add(-reconciliationWindow).Format(time.RFC3339),
+		OrdersTo:        now.Add(-reconciliationGrace).Format(time.RFC3339),
+	}
+	for _, installment := range installments {
+		switch {
+		case installment.ShopifyOrderID == "":
+			plan.WithoutOrder = append(plan.WithoutOrder, installment.ID)
+		case !slices.Contains(plan.ShopifyOrderIDs, installment.ShopifyOrderID):
+			plan.ShopifyOrderIDs = append(plan.ShopifyOrderIDs, installment.ShopifyOrderID)
+		}
+	}
+
+	return plan, nil
+}
+
+// ListDiscrepancies returns the discrepancies reported by the reconciliation of a day
+func (s *reconciliationService) ListDiscrepancies(
+	ctx context.Context,
+	req models.PaymentDiscrepancyRequest,
+) ([]dbModels.PaymentDiscrepancy, error) {
+	runDate, err := time.ParseInLocation("2006-01-02", req.Date, s.loc)
+	if err != nil {
+		return nil, err
+	}
+
+	query := s.db.WithContext(ctx).Where("run_date = ?", runDate).Order("kind, shopify_order_name")
+	if req.Kind != "" {
+		query = query.Where("kind = ?", req.Kind)
+	}
+
+	var discrepancies []dbModels.PaymentDiscrepancy
+	if err := query.Find(&discrepancies).Error; err != nil {
+		s.logger.Error("listing payment discrepancies", zap.Error(err))
+		return nil, err
+	}
+
+	return discrepancies, nil
+}
+
+// getOrders looks the orders up in Shopify in batches, keyed by their numeric id. Orders
+// Shopify does not return are left out.
+func (s *reconciliationService) getOrders(ctx context.Context, orderIDs []string) (map[string]shopify.Order, error) {
+	orders := make(map[string]shopify.Order, len(orderIDs))
+	for start := 0; start < len(orderIDs); start += reconciliationBatchSize {
+		batch := orderIDs[start:min(start+reconciliationBatchSize, len(orderIDs))]
+
+		terms := make([]string, 0, len(batch))
+		for _, id := range batch {
+			terms = append(terms, "id:"+id)
+		}
+
+		found, err := s.shopify.GetOrdersByQuery(ctx, strings.Join(terms, " OR "), len(batch))
+		if err != nil {
+			s.logger.Error("getting shopify orders to reconcile", zap.Error(err), zap.Int("batch", len(batch)))
+			return nil, err
+		}
+		for _, order := range found {
+			orders[shopify.LegacyID(order.ID)] = order
+		}
+	}
+
+	return orders, nil
+}
+
+// ordersMissingLocally returns the Shopify orders of the reconciliation window that no
+// installment was created for. It also returns how many orders were checked.
+func (s *reconciliationService) ordersMissingLocally(ctx context.Context, now time.Time) ([]shopify.Order, int, error) {
+	query := fmt.Sprintf("created_at:>='%s' created_at:<'%s'",
+		now.Add(-reconciliationWindow).Format(time.RFC3339), now.Add(-reconciliationGrace).Format(time.RFC3339))
+	orders, err := s.shopify.GetOrdersByQuery(ctx, query, reconciliationOrdersLimit)
+	if err != nil {
+		s.logger.Error("getting recent shopify orders", zap.Error(err))
+		return nil, 0, err
+	}
+	if len(orders) == reconciliationOrdersLimit {
+		s.logger.Warn("recent shopify orders over the reconciliation limit, the oldest are not checked",
+			zap.Int("limit", reconciliationOrdersLimit))
+	}
+	if len(orders) == 0 {
+		return nil, 0, nil
+	}
+
+	ids := make([]string, 0, len(orders))
+	for _, order := range orders {
+		ids = append(ids, shopify.LegacyID(order.ID))
+	}
+
+	var known []string
+	err = s.db.WithContext(ctx).
+		Model(&dbModels.PaymentInstallment{}).
+		Where("shopify_order_id IN ?", ids).
+		Distinct().
+		Pluck("shopify_order_id", &known).Error
+	if err != nil {
+		s.logger.Error("getting installments of recent shopify orders", zap.Error(err))
+		return nil, 0, err
+	}
+
+	recorded := make(map[string]bool, len(known))
+	for _, id := range known {
+		recorded[id] = true
+	}
+
+	var missing []shopify.Order
+	for _, order := range orders {
+		if !recorded[shopify.LegacyID(order.ID)] {
+			missing = append(missing, order)
+		}
+	}
+
+	return missing, len(orders), nil
+}
+
+// markPaid marks the installments of a paid order as paid. Installments paid meanwhile, by
+// the webhook or a manual payment, are left as they are.
+func (s *reconciliationService) markPaid(ctx context.Context, installments []dbModels.PaymentInstallment, paidAt time.Time) (int, error) {
+	paid, err := s.installments.MarkPaid(s.db, ctx, installmentIDs(installments), paidAt)
+	if err != nil {
+		return 0, err
+	}
+
+	s.logger.Info("installments marked paid from shopify",
+		zap.String("order_id", installments[0].ShopifyOrderID), zap.Int64("installments", paid))
+
+	return int(paid), nil
+}
+
+// saveDiscrepancies replaces the discrepancy report of the day
+func (s *reconciliationService) saveDiscrepancies(
+	ctx context.Context,
+	runDate time.Time,
+	discrepancies []dbModels.PaymentDiscrepancy,
+) (err error) {
+	tx := s.db.Begin().WithContext(ctx)
+	defer db.DBRollback(tx, &err)
+
+	if err = tx.Where("run_date = ?", runDate).Delete(&dbModels.PaymentDiscrepancy{}).Error; err != nil {
+		s.logger.Error("clearing payment discrepancies", zap.Error(err))
+		return err
+	}
+	if len(discrepancies) == 0 {
+		return nil
+	}
+	if err = tx.CreateInBatches(&discrepancies, 100).Error; err != nil {
+		s.logger.Error("saving payment discrepancies", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// reconcileOrder compares an order with its pending installments. It reports whether the
+// installments can be marked paid, or else the kind of discrepancy found, empty when they agree.
+func reconcileOrder(order shopify.Order, installments []dbModels.PaymentInstallment) (string, bool) {
+	var expected money.Amount
+	for _, installment := range installments {
+		expected = expected.Add(installment.Amount)
+	}
+
+	total, err := money.Parse(order.TotalPriceSet.ShopMoney.Amount)
+	sameAmount := err == nil && total.Equal(expected) &&
+		order.TotalPriceSet.ShopMoney.CurrencyCode == installments[0].Currency
+
+	status := strings.ToUpper(order.DisplayFinancialStatus)
+	switch {
+	case status == "PAID" && sameAmount:
+		return "", true
+	case !sameAmount:
+		return dbModels.DiscrepancyAmountMismatch, false
+	case !slices.Contains(unpaidFinancialStatuses, status):
+		return dbModels.DiscrepancyStatusMismatch, false
+	}
+
+	return "", false
+}
+
+// installmentDiscrepancy describes an installment that does not match its order, nil when the
+// order was not found
+func installmentDiscrepancy(
+	runDate time.Time,
+	kind string,
+	installment dbModels.PaymentInstallment,
+	order *shopify.Order,
+) dbModels.PaymentDiscrepancy {
+	discrepancy := dbModels.PaymentDiscrepancy{
+		RunDate:              runDate,
+		Kind:                 kind,
+		PaymentInstallmentID: &installment.ID,
+		LocalStatus:          &installment.Status,
+		LocalAmount:          money.Ptr(installment.Amount),
+		Currency:             &installment.Currency,
+	}
+	if installment.ShopifyOrderID != "" {
+		discrepancy.ShopifyOrderID = &installment.ShopifyOrderID
+		discrepancy.ShopifyOrderName = &installment.ShopifyOrderName
+	}
+	if order != nil {
+		discrepancy.ShopifyStatus = &order.DisplayFinancialStatus
+		if amount, err := money.Parse(order.TotalPriceSet.ShopMoney.Amount); err == nil {
+			discrepancy.ShopifyAmount = &amount
+		}
+	}
+
+	return discrepancy
+}
+
+// orderDiscrepancy describes a Shopify order no installment was created for
+func orderDiscrepancy(runDate time.Time, order shopify.Order) dbModels.PaymentDiscrepancy {
+	orderID := shopify.LegacyID(order.ID)
+	discrepancy := dbModels.PaymentDiscrepancy{
+		RunDate:          runDate,
+		Kind:             dbModels.DiscrepancyOrderMissingLocally,
+		ShopifyOrderID:   &orderID,
+		ShopifyOrderName: &order.Name,
+		ShopifyStatus:    &order.DisplayFinancialStatus,
+		Currency:         &order.TotalPriceSet.ShopMoney.CurrencyCode,
+	}
+	if amount, err := money.Parse(order.TotalPriceSet.ShopMoney.Amount); err == nil {
+		discrepancy.ShopifyAmount = &amount
+	}
+
+	return discrepancy
+}
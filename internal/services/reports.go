@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"appa_subscriptions/internal/domains"
+	"appa_subscriptions/internal/models"
+	dbModels "appa_subscriptions/pkg/db/models"
+	"appa_subscriptions/pkg/exchange"
+	"appa_subscriptions/pkg/money"
+)
+
+type reportService struct {
+	db     *gorm.DB
+	loc    *time.Location
+	logger *zap.Logger
+}
+
+// NewReportService creates a new instance of ReportService
+func NewReportService(db *gorm.DB, loc *time.Location, logger *zap.Logger) domains.ReportService {
+	return &reportService{
+		db:     db,
+		loc:    loc,
+		logger: logger,
+	}
+}
+
+// InstallmentReport lists the installments due in the range with their amounts in the requested
+// currency, USD by default. Amounts are converted with the rate snapshotted on each installment,
+// never with today's rate, so a report does not change once the installments are billed.
+func (s *reportService) InstallmentReport(ctx context.Context, req models.InstallmentReportRequest) (*models.InstallmentReport, error) {
+	from, err := time.ParseInLocation("2006-01-02", req.From, s.loc)
+	if err != nil {
+		return nil, err
+	}
+	to, err := time.ParseInLocation("2006-01-02", req.To, s.loc)
+	if err != nil {
+		return nil, err
+	}
+
+	currency := req.Currency
+	if currency == "" {
+		currency = exchange.USD
+	}
+
+	query := s.db.WithContext(ctx).
+		Where("due_date >= ? AND due_date < ?", from, to.AddDate(0, 0, 1)).
+		Order("due_date, shopify_order_name")
+	if req.Status != "" {
+		query = query.Where("status = ?", req.Status)
+	}
+
+	var installments []dbModels.PaymentInstallment
+	if err := query.Find(&installments).Error; err != nil {
+		s.logger.Error("listing installments for report", zap.Error(err))
+		return nil, err
+	}
+
+	report := &models.InstallmentReport{
+		From:         req.From,
+		To:           req.To,
+		Currency:     currency,
+		Installments: make([]models.InstallmentReportRow, 0, len(installments)),
+	}
+	for _, installment := range installments {
+		row := models.InstallmentReportRow{
+			ID:                  installment.ID,
+			ShopifyOrderName:    installment.ShopifyOrderName,
+			DueDate:             installment.DueDate,
+			Status:              installment.Status,
+			PaidAt:              installment.PaidAt,
+			ShopAmount:          installment.Amount,
+			ShopCurrency:        installment.Currency,
+			ExchangeRate:        installment.ExchangeRate,
+			ExchangeRateDate:    installment.ExchangeRateDate,
+			PresentmentAmount:   installment.PresentmentAmount,
+			PresentmentCurrency: installment.PresentmentCurrency,
+		}
+
+		if amount, ok := convertAmount(installment, currency); ok {
+			row.Amount = &amount
+			report.Total = report.Total.Add(amount)
+		} else {
+			report.Unconverted++
+		}
+
+		report.Installments = append(report.Installments, row)
+	}
+	return report, nil
+}
+
+// convertAmount returns the installment amount in the currency. The amount the customer was
+// shown wins when it is already in that currency, otherwise the snapshotted rate is applied.
+func convertAmount(installment dbModels.PaymentInstallment, currency string) (money.Amount, bool) {
+	if installment.Currency == currency {
+		return installment.Amount, true
+	}
+	if installment.PresentmentCurrency != nil && *installment.PresentmentCurrency == currency &&
+		installment.PresentmentAmount != nil {
+		return *installment.PresentmentAmount, true
+	}
+	if installment.ExchangeRate == nil || *installment.ExchangeRate <= 0 {
+		return money.Zero, false
+	}
+
+	rate := *installment.ExchangeRate
+	switch {
+	case installment.Currency == exchange.USD && currency == exchange.VES:
+		return installment.Amount.Convert(rate), true
+	case installment.Currency == exchange.VES && currency == exchange.USD:
+		return installment.Amount.ConvertInverse(rate), true
+	}
+
+	return money.Zero, false
+}
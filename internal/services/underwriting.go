@@ -0,0 +1,262 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"appa_subscriptions/internal/domains"
+	"appa_subscriptions/internal/models"
+	helpers "appa_subscriptions/pkg"
+	"appa_subscriptions/pkg/db"
+	dbModels "appa_subscriptions/pkg/db/models"
+)
+
+type underwritingService struct {
+	db     *gorm.DB
+	loc    *time.Location
+	logger *zap.Logger
+}
+
+// NewUnderwritingService creates a new instance of UnderwritingService
+func NewUnderwritingService(db *gorm.DB, loc *time.Location, logger *zap.Logger) domains.UnderwritingService {
+	return &underwritingService{
+		db:     db,
+		loc:    loc,
+		logger: logger,
+	}
+}
+
+// Evaluate runs the underwriting rules of the pet type and plan against the pet.
+// The policy is accepted when no rule applies, held for manual review when any applied rule
+// requires it, and accepted with exclusions otherwise.
+func (s *underwritingService) Evaluate(
+	tx *gorm.DB,
+	ctx context.Context,
+	input models.UnderwritingInput,
+) (*models.UnderwritingDecision, error) {
+	var rules []dbModels.UnderwritingRule
+	err := tx.WithContext(ctx).
+		Where("pet_type_id = ? AND (plan_id IS NULL OR plan_id = ?) AND active = ?", input.PetTypeID, input.PlanID, true).
+		Find(&rules).Error
+	if err != nil {
+		s.logger.Error("getting underwriting rules", zap.Error(err))
+		return nil, err
+	}
+
+	decision := &models.UnderwritingDecision{
+		Decision:   dbModels.DecisionAccepted,
+		Reasons:    make([]string, 0),
+		Exclusions: make([]string, 0),
+	}
+
+	for _, rule := range rules {
+		reason, err := s.evaluateRule(tx, ctx, rule, input)
+		if err != nil {
+			return nil, err
+		}
+		if reason == "" {
+			continue
+		}
+
+		decision.Reasons = append(decision.Reasons, reason)
+		if rule.Outcome == dbModels.DecisionManualReview {
+			decision.Decision = dbModels.DecisionManualReview
+			continue
+		}
+
+		if rule.Exclusion != nil && !slices.Contains(decision.Exclusions, *rule.Exclusion) {
+			decision.Exclusions = append(decision.Exclusions, *rule.Exclusion)
+		}
+		if decision.Decision == dbModels.DecisionAccepted {
+			decision.Decision = dbModels.DecisionAcceptedWithExclusions
+		}
+	}
+
+	return decision, nil
+}
+
+// evaluateRule returns the reason the rule applies to the pet, or an empty string when it does not
+func (s *underwritingService) evaluateRule(
+	tx *gorm.DB,
+	ctx context.Context,
+	rule dbModels.UnderwritingRule,
+	input models.UnderwritingInput,
+) (string, error) {
+	switch rule.RuleType {
+	case dbModels.RuleMaxEntryAgeMonths:
+		maxAge, err := strconv.Atoi(rule.Value)
+		if err != nil {
+			s.logger.Error("invalid underwriting rule value", zap.String("rule_id", rule.ID), zap.String("value", rule.Value))
+			return "", err
+		}
+
+		birthday, err := helpers.ParseBirthday(input.Birthday, s.loc)
+		if err != nil {
+			return "birthday is missing or invalid, entry age cannot be verified", nil
+		}
+		if age := helpers.AgeInMonths(birthday, time.Now().In(s.loc)); age > maxAge {
+			return fmt.Sprintf("entry age of %d months exceeds the maximum of %d months", age, maxAge), nil
+		}
+
+	case dbModels.RuleExcludedBreed:
+		if normalizeBreed(input.Breed) == normalizeBreed(rule.Value) {
+			return fmt.Sprintf("breed %q is excluded", input.Breed), nil
+		}
+
+	case dbModels.RuleRequiredNeutered:
+		neutered := helpers.ParseNeutered(input.Neutered)
+		if neutered == nil {
+			return "neutered status is missing", nil
+		}
+		if required, _ := strconv.ParseBool(rule.Value); required && !*neutered {
+			return "pet is required to be neutered", nil
+		}
+
+	case dbModels.RuleMaxPetsPerHousehold:
+		// quotes have no household yet, the rule is evaluated when the order is placed
+		if input.UserID == "" {
+			return "", nil
+		}
+
+		maxPets, err := strconv.Atoi(rule.Value)
+		if err != nil {
+			s.logger.Error("invalid underwriting rule value", zap.String("rule_id", rule.ID), zap.String("value", rule.Value))
+			return "", err
+		}
+
+		var count int64
+		err = tx.WithContext(ctx).Model(&dbModels.Policy{}).
+			Where("user_id = ? AND status <> ?", input.UserID, statusCancelled).
+			Count(&count).Error
+		if err != nil {
+			s.logger.Error("counting household policies", zap.Error(err), zap.String("user_id", input.UserID))
+			return "", err
+		}
+		if int(count)+1 > maxPets {
+			return fmt.Sprintf("household exceeds the maximum of %d insured pets", maxPets), nil
+		}
+
+	default:
+		s.logger.Warn("unknown underwriting rule type", zap.String("rule_id", rule.ID), zap.String("rule_type", rule.RuleType))
+	}
+
+	return "", nil
+}
+
+// RecordDecision stores the underwriting decision of a policy
+func (s *underwritingService) RecordDecision(
+	tx *gorm.DB,
+	ctx context.Context,
+	policyID string,
+	decision *models.UnderwritingDecision,
+) error {
+	record := dbModels.PolicyUnderwritingDecision{
+		PolicyID:   policyID,
+		Decision:   decision.Decision,
+		Reasons:    decision.Reasons,
+		Exclusions: decision.Exclusions,
+	}
+	if err := tx.WithContext(ctx).Create(&record).Error; err != nil {
+		s.logger.Error("creating underwriting decision", zap.Error(err), zap.String("policy_id", policyID))
+		return err
+	}
+
+	return nil
+}
+
+// ResolveReview approves or rejects a policy held for manual review. An approved policy becomes
+// active when its last installment is paid and waits for payment otherwise. The decision is
+// locked, so a policy is resolved once when reviewers act on it together.
+func (s *underwritingService) ResolveReview(
+	ctx context.Context,
+	policyID string,
+	req models.UnderwritingReviewRequest,
+) (record *dbModels.PolicyUnderwritingDecision, err error) {
+	tx := s.db.Begin().WithContext(ctx)
+	defer db.DBRollback(tx, &err)
+
+	record = &dbModels.PolicyUnderwritingDecision{}
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where(dbModels.PolicyUnderwritingDecision{PolicyID: policyID, Decision: dbModels.DecisionManualReview}).
+		First(record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = domains.ErrPolicyNotUnderReview
+		return nil, err
+	}
+	if err != nil {
+		s.logger.Error("getting underwriting decision", zap.Error(err), zap.String("policy_id", policyID))
+		return nil, err
+	}
+
+	var (
+		now          = time.Now().In(s.loc)
+		policyStatus = statusCancelled
+	)
+	record.Decision = dbModels.DecisionRejected
+	if *req.Approve {
+		record.Decision = dbModels.DecisionAccepted
+		if len(record.Exclusions) > 0 {
+			record.Decision = dbModels.DecisionAcceptedWithExclusions
+		}
+
+		policyStatus, err = s.getPolicyStatusByLastInstallment(tx, policyID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	record.ReviewedBy = &req.ReviewedBy
+	record.ReviewedAt = &now
+	if req.Notes != "" {
+		record.ReviewNotes = &req.Notes
+	}
+
+	if err = tx.Save(record).Error; err != nil {
+		s.logger.Error("updating underwriting decision", zap.Error(err), zap.String("policy_id", policyID))
+		return nil, err
+	}
+
+	err = tx.Model(&dbModels.Policy{}).
+		Where("id = ?", policyID).
+		Update("status", policyStatus).Error
+	if err != nil {
+		s.logger.Error("updating reviewed policy status", zap.Error(err), zap.String("policy_id", policyID))
+		return nil, err
+	}
+
+	return record, nil
+}
+
+// getPolicyStatusByLastInstallment returns the status a policy should have given its last installment
+func (s *underwritingService) getPolicyStatusByLastInstallment(query *gorm.DB, policyID string) (string, error) {
+	var installment dbModels.PaymentInstallment
+	err := query.
+		Joins("JOIN policies_payments ON policies_payments.payment_installment_id = payment_installments.id").
+		Where("policies_payments.policy_id = ?", policyID).
+		Order("payment_installments.created_at DESC").
+		Limit(1).Find(&installment).Error
+	if err != nil {
+		s.logger.Error("getting last payment installment", zap.Error(err), zap.String("policy_id", policyID))
+		return "", err
+	}
+
+	if installment.Status == statusPaidPayment {
+		return statusActive, nil
+	}
+
+	return statusPendingPolicy, nil
+}
+
+// normalizeBreed trims and case-folds a breed name and collapses its spaces, breed rules match
+// whole catalog breed names only
+func normalizeBreed(breed string) string {
+	return strings.ToLower(strings.Join(strings.Fields(breed), " "))
+}
@@ -3,7 +3,6 @@ package services
 import (
 	"context"
 	"fmt"
-	"strconv"
 	"strings"
 	"time"
 
@@ -15,15 +14,19 @@ import (
 	helpers "appa_subscriptions/pkg"
 	"appa_subscriptions/pkg/db"
 	dbModels "appa_subscriptions/pkg/db/models"
-	PaymentInstallment "appa_subscriptions/pkg/db/repositories"
+	"appa_subscriptions/pkg/db/repositories"
+	"appa_subscriptions/pkg/money"
 	"appa_subscriptions/pkg/shopify"
 )
 
 const (
 	statusPendingPolicy                  = "payment_pending"
-	statusPendingPayment                 = "pending"
-	statusPaidPayment                    = "paid"
+	statusPendingPayment                 = dbModels.InstallmentPending
+	statusPaidPayment                    = dbModels.InstallmentPaid
 	statusActive                         = "active"
+	statusPendingReview                  = "pending_review"
+	statusCancelled                      = "cancelled"
+	statusPendingCancellation            = "pending_cancellation"
 	tagManualSubscriptionRecurringOrder  = "manual_subscription_recurring_order"
 	tagAppstleSubscriptionRecurringOrder = "appstle_subscription_recurring_order"
 )
@@ -32,7 +35,17 @@ type webhookService struct {
 	db                     *gorm.DB
 	loc                    *time.Location
 	ShopifyRepository      shopify.Repository
-	PaymentInstallmentRepo PaymentInstallment.Repository
+	PaymentInstallmentRepo repositories.PaymentInstallmentRepository
+	users                  repositories.UserRepository
+	pets                   repositories.PetRepository
+	policies               repositories.PolicyRepository
+	plans                  repositories.PlanRepository
+	petAttributes          repositories.PetAttributeRepository
+	pricingService         domains.PricingService
+	underwritingService    domains.UnderwritingService
+	coverageService        domains.CoverageService
+	discountService        domains.DiscountService
+	exchangeRateService    domains.ExchangeRateService
 	logger                 *zap.Logger
 }
 
@@ -40,7 +53,17 @@ func NewWebhookService(
 	db *gorm.DB,
 	loc *time.Location,
 	shopifyRepo shopify.Repository,
-	PaymentInstallmentRepo PaymentInstallment.Repository,
+	PaymentInstallmentRepo repositories.PaymentInstallmentRepository,
+	userRepo repositories.UserRepository,
+	petRepo repositories.PetRepository,
+	policyRepo repositories.PolicyRepository,
+	planRepo repositories.PlanRepository,
+	petAttributeRepo repositories.PetAttributeRepository,
+	pricingService domains.PricingService,
+	underwritingService domains.UnderwritingService,
+	coverageService domains.CoverageService,
+	discountService domains.DiscountService,
+	exchangeRateService domains.ExchangeRateService,
 	logger *zap.Logger,
 ) domains.WebhookService {
 	return &webhookService{
@@ -48,6 +71,16 @@ func NewWebhookService(
 		loc:                    loc,
 		ShopifyRepository:      shopifyRepo,
 		PaymentInstallmentRepo: PaymentInstallmentRepo,
+		users:                  userRepo,
+		pets:                   petRepo,
+		policies:               policyRepo,
+		plans:                  planRepo,
+		petAttributes:          petAttributeRepo,
+		pricingService:         pricingService,
+		underwritingService:    underwritingService,
+		coverageService:        coverageService,
+		discountService:        discountService,
+		exchangeRateService:    exchangeRateService,
 		logger:                 logger,
 	}
 }
@@ -83,46 +116,33 @@ func (s *webhookService) OrderCreated(webhook models.Webhook) {
 	s.logger.Info("completed processing order created webhook", zap.Int("order_id", webhook.ID))
 }
 
-// OrderPaid handles the order paid webhook from Shopify.
+// OrderPaid handles the order paid webhook from Shopify. Orders without an installment are
+// processed as new orders; the open installments of a known order are marked paid.
 func (s *webhookService) OrderPaid(
 	webhook models.Webhook,
 ) {
-	// 1. Get PolicyPayments by Shopify Order ID
-	var policyPayments []dbModels.PolicyPayment
-	err := s.db.WithContext(context.Background()).
-		Select("policies_payments.*").
-		InnerJoins("PaymentInstallment", s.db.Select("ID").
-			Where(&dbModels.PaymentInstallment{
-				ShopifyOrderID: fmt.Sprintf("%d", webhook.ID),
-				Status:         statusPendingPayment,
-			})).
-		Find(&policyPayments).Error
+	ctx := context.Background()
+	orderID := fmt.Sprintf("%d", webhook.ID)
+
+	// 1. Get the PaymentInstallments of the Shopify order
+	installments, err := s.PaymentInstallmentRepo.FindByShopifyOrderID(s.db, ctx, orderID)
 	if err != nil {
-		s.logger.Error("getting policy payments by shopify order ID", zap.Error(err))
 		return
 	}
 
-	if len(policyPayments) == 0 {
+	if len(installments) == 0 {
 		s.OrderCreated(webhook)
 		return
 	}
 
-	policyPaymentsIDs := make([]string, 0)
-	for _, pp := range policyPayments {
-		policyPaymentsIDs = append(policyPaymentsIDs, pp.ID)
-	}
-
 	// 2. Update PaymentInstallment status to 'paid'
-	err = s.db.WithContext(context.Background()).Model(&dbModels.PaymentInstallment{}).
-		Where("id IN ?", policyPaymentsIDs).
-		Updates(map[string]any{
-			"status":  statusPaidPayment,
-			"paid_at": time.Now().In(s.loc),
-		}).Error
+	paid, err := s.PaymentInstallmentRepo.MarkPaid(s.db, ctx, installmentIDs(installments), time.Now().In(s.loc))
 	if err != nil {
-		s.logger.Error("updating payment installment status to paid", zap.Error(err))
 		return
 	}
+	if paid == 0 {
+		s.logger.Info("payment installments of order already closed, skipping", zap.String("order_id", orderID))
+	}
 }
 
 // firstOrderProcess handles the first order process webhook from Shopify.
@@ -131,19 +151,19 @@ func (s *webhookService) firstOrderProcess(
 	webhook models.Webhook,
 	paymentStatus, policyStatus string,
 ) {
-	var count int64
-	err := s.db.WithContext(ctx).Model(&dbModels.PaymentInstallment{}).
-		Where("shopify_order_id = ?", fmt.Sprintf("%d", webhook.ID)).
-		Count(&count).Error
+	existing, err := s.PaymentInstallmentRepo.FindByShopifyOrderID(s.db, ctx, fmt.Sprintf("%d", webhook.ID))
 	if err != nil {
-		s.logger.Error("checking existing payment installment", zap.Error(err))
 		return
 	}
-	if count > 0 {
+	if len(existing) > 0 {
 		s.logger.Info("payment installment already exists for this order, skipping", zap.Int("order_id", webhook.ID))
 		return
 	}
 
+	// the rate may be fetched from the BCV, resolve it before any row is locked
+	dueDate := time.Now().In(s.loc)
+	rate := snapshotRate(ctx, s.exchangeRateService, dueDate, s.logger)
+
 	var (
 		errDB    error
 		isManual bool
@@ -178,15 +198,19 @@ func (s *webhookService) firstOrderProcess(
 		return
 	}
 
-	// 3. Precreate PaymentInstallment
-	paymentInstallment, err := s.PaymentInstallmentRepo.Create(
-		tx, ctx, fmt.Sprintf("%d", webhook.ID), paymentStatus, webhook.Name, webhook.CurrentTotalPriceSet.ShopMoney.Amount,
-	)
+	// 3. Precreate PaymentInstallment, the first one of new policies
+	paymentInstallment, err := s.PaymentInstallmentRepo.Create(tx, ctx, webhookInstallmentInput(webhook, paymentStatus, dueDate, rate, nil))
 	if err != nil {
 		errDB = err
 		return
 	}
 
+	// 3.1 Keep the discount of the first order and the coupons it earns for the recurring orders
+	if err := s.recordFirstOrderDiscount(tx, ctx, webhook, user.ID, paymentInstallment.ID); err != nil {
+		errDB = err
+		return
+	}
+
 	// 4. Get Variants map by Line Items
 	variantsMap, err := s.getVariantsMapByLineItem(
 		ctx, webhook.LineItems,
@@ -197,7 +221,8 @@ func (s *webhookService) firstOrderProcess(
 	}
 
 	// 5. Register Pets policies
-	policyPayments := make([]dbModels.PolicyPayment, 0)
+	policyIDs := make([]string, 0, len(pets.Pets))
+	matcher := make(policyMatcher)
 	for _, pet := range pets.Pets {
 		petAttributesMap, err := s.GetPetAttributesIDsByVariantID(
 			ctx, variantsMap, pet.ProductVariantID, pet.Type,
@@ -206,6 +231,8 @@ func (s *webhookService) firstOrderProcess(
 			continue
 		}
 
+		s.verifyPetLineItemPrice(tx, ctx, webhook, pet.ProductVariantID, petAttributesMap)
+
 		dbPet, err := s.createOrFindPet(
 			tx, ctx, pet, user.ID,
 			petAttributesMap["age"],
@@ -218,28 +245,90 @@ func (s *webhookService) firstOrderProcess(
 			return
 		}
 
+		decision, err := s.underwritingService.Evaluate(tx, ctx, models.UnderwritingInput{
+			UserID:    user.ID,
+			PetTypeID: petAttributesMap["type"],
+			PlanID:    petAttributesMap["plan"],
+			Breed:     pet.Breed,
+			Birthday:  pet.Birthday,
+			Neutered:  pet.Neutered,
+		})
+		if err != nil {
+			errDB = err
+			return
+		}
+
+		status := policyStatus
+		if decision.Decision == dbModels.DecisionManualReview {
+			s.logger.Info("policy held for manual review", zap.String("pet", pet.Name), zap.Strings("reasons", decision.Reasons))
+			status = statusPendingReview
+		}
+
 		policy, err := s.createPolicy(
-			tx, ctx, isManual, policyStatus, user.ID, dbPet.ID,
-			petAttributesMap["plan"], petAttributesMap["plan_limit"], pet.ProductVariantID,
+			tx, ctx, isManual, status, user.ID, dbPet.ID,
+			petAttributesMap["plan"], pet.ProductVariantID,
 		)
 		if err != nil {
 			errDB = err
 			return
 		}
 
-		policyPayments = append(policyPayments, dbModels.PolicyPayment{
-			PolicyID:             policy.ID,
-			PaymentInstallmentID: paymentInstallment.ID,
-			CreatedAt:            time.Now().In(s.loc),
-		})
-	}
-	if len(policyPayments) > 0 {
-		// 6. Create PolicyPayments
-		errDB = tx.WithContext(ctx).Create(&policyPayments).Error
-		if errDB != nil {
-			s.logger.Error("creating policy payments", zap.Error(errDB))
+		if err := s.underwritingService.RecordDecision(tx, ctx, policy.ID, decision); err != nil {
+			errDB = err
+			return
 		}
+
+		if err := s.coverageService.ComputeEligibility(tx, ctx, policy, policy.StartDate); err != nil {
+			errDB = err
+			return
+		}
+
+		policyIDs = append(policyIDs, policy.ID)
+		matcher.add(pet.ProductVariantID, policy.ID)
+	}
+
+	// 6. Create PolicyPayments
+	errDB = s.policies.WithTx(tx).LinkInstallment(ctx, policyIDs, paymentInstallment.ID)
+	if errDB != nil {
+		return
+	}
+
+	// 7. Register what each pet paid
+	errDB = s.PaymentInstallmentRepo.CreateItems(
+		tx, ctx, paymentInstallment.ID, installmentItemsFromWebhook(webhook, matcher),
+	)
+}
+
+// recordFirstOrderDiscount stores the discount codes of the first order on its installment and
+// registers the coupons they grant
+func (s *webhookService) recordFirstOrderDiscount(
+	tx *gorm.DB,
+	ctx context.Context,
+	webhook models.Webhook,
+	userID, installmentID string,
+) error {
+	if len(webhook.DiscountCodes) == 0 {
+		return nil
+	}
+
+	orderID := fmt.Sprintf("%d", webhook.ID)
+	if err := s.discountService.RegisterCoupons(tx, ctx, userID, orderID, webhook.DiscountCodes); err != nil {
+		return err
+	}
+
+	codes := make([]string, 0, len(webhook.DiscountCodes))
+	for _, code := range webhook.DiscountCodes {
+		codes = append(codes, code.Code)
+	}
+	amount, err := money.Parse(webhook.CurrentTotalDiscountsSet.ShopMoney.Amount)
+	if err != nil {
+		s.logger.Warn("parsing order discounts amount", zap.Error(err), zap.String("order_id", orderID))
+		amount = money.Zero
 	}
+
+	return s.discountService.RecordInstallmentDiscount(
+		tx, ctx, installmentID, &models.OrderDiscount{Code: strings.Join(codes, "+")}, amount,
+	)
 }
 
 // orderRecurring handles the order recurring webhook from Shopify.
@@ -254,63 +343,115 @@ func (s *webhookService) orderRecurring(
 		return
 	}
 
-	var policyPayments []dbModels.PolicyPayment
-	err := s.db.WithContext(context.Background()).
-		Select("policy_payments.*").
-		InnerJoins("PaymentInstallment").
-		Where("payment_installments.shopify_order_id = ?", *firstOrderID).
-		Find(&policyPayments).Error
+	existing, err := s.PaymentInstallmentRepo.FindByShopifyOrderID(s.db, ctx, fmt.Sprintf("%d", webhook.ID))
+	if err != nil {
+		return
+	}
+	if len(existing) > 0 {
+		s.logger.Info("payment installment already exists for this order, skipping", zap.Int("order_id", webhook.ID))
+		return
+	}
+
+	firstInstallments, err := s.PaymentInstallmentRepo.FindByShopifyOrderID(s.db, ctx, *firstOrderID)
 	if err != nil {
-		s.logger.Error("getting policy payments by shopify order ID", zap.Error(err))
 		return
 	}
 
-	if len(policyPayments) == 0 {
-		s.logger.Warn("no policy payments found for shopify order ID", zap.String("shopify_order_id", *firstOrderID))
+	if len(firstInstallments) == 0 {
+		s.logger.Warn("no payment installments found for shopify order ID", zap.String("shopify_order_id", *firstOrderID))
 		return
 	}
 
+	dueDate := time.Now().In(s.loc)
+	rate := snapshotRate(ctx, s.exchangeRateService, dueDate, s.logger)
+
 	var (
-		tx    = s.db.Begin().WithContext(context.Background())
+		tx    = s.db.Begin().WithContext(ctx)
 		errDB error
 	)
 	defer db.DBRollback(tx, &errDB)
 
+	policies, errDB := s.policies.WithTx(tx).FindByInstallments(ctx, installmentIDs(firstInstallments))
+	if errDB != nil {
+		return
+	}
+	policyIDs := getPolicyIDs(policies)
+
 	// 3. Precreate PaymentInstallment
-	paymentInstallment, err := s.PaymentInstallmentRepo.Create(
-		tx, ctx, fmt.Sprintf("%d", webhook.ID), paymentStatus, webhook.Name, webhook.CurrentTotalPriceSet.ShopMoney.Amount,
-	)
+	paymentInstallment, err := s.PaymentInstallmentRepo.Create(tx, ctx, webhookInstallmentInput(webhook, paymentStatus, dueDate, rate, policyIDs))
 	if err != nil {
 		errDB = err
 		return
 	}
 
-	// 4. Update Policies status
-	policiesIDs := make([]string, 0)
-	newPolicyPayments := make([]dbModels.PolicyPayment, 0)
-	for _, pp := range policyPayments {
-		policiesIDs = append(policiesIDs, pp.PolicyID)
-		newPolicyPayments = append(newPolicyPayments, dbModels.PolicyPayment{
-			PolicyID:             pp.ID,
-			PaymentInstallmentID: paymentInstallment.ID,
-			CreatedAt:            time.Now().In(s.loc),
-		})
+	errDB = s.PaymentInstallmentRepo.CreateItems(
+		tx, ctx, paymentInstallment.ID, installmentItemsFromWebhook(webhook, newPolicyMatcher(policies)),
+	)
+	if errDB != nil {
+		return
 	}
 
-	// 5. Update Policies status
-	errDB = tx.Model(&dbModels.Policy{}).
-		Where("id IN ?", policiesIDs).
-		Updates(map[string]any{
-			"status": policyStatus,
-		}).Error
+	// 4. Update Policies status
+	errDB = s.policies.WithTx(tx).UpdateStatus(ctx, policyIDs, policyStatus)
 	if errDB != nil {
-		s.logger.Error("updating policies status to active", zap.Error(errDB))
 		return
 	}
 
-	errDB = tx.WithContext(ctx).Create(&newPolicyPayments).Error
-	if errDB != nil {
-		s.logger.Error("creating policy payments", zap.Error(errDB))
+	// 5. Link the policies to the new PaymentInstallment
+	errDB = s.policies.WithTx(tx).LinkInstallment(ctx, policyIDs, paymentInstallment.ID)
+}
+
+// webhookInstallmentInput describes the installment of a webhook order with its shop and
+// presentment amounts and the exchange rate of the due date
+func webhookInstallmentInput(
+	webhook models.Webhook,
+	status string,
+	dueDate time.Time,
+	rate *dbModels.ExchangeRate,
+	policyIDs []string,
+) repositories.CreateInstallmentInput {
+	return repositories.CreateInstallmentInput{
+		OrderID:             fmt.Sprintf("%d", webhook.ID),
+		OrderName:           webhook.Name,
+		Status:              status,
+		Amount:              webhook.CurrentTotalPriceSet.ShopMoney.Amount,
+		Currency:            webhook.CurrentTotalPriceSet.ShopMoney.Currency,
+		PresentmentAmount:   webhook.CurrentTotalPriceSet.PresentmentMoney.Amount,
+		PresentmentCurrency: webhook.CurrentTotalPriceSet.PresentmentMoney.Currency,
+		ExchangeRate:        rate,
+		DueDate:             dueDate,
+		PolicyIDs:           policyIDs,
+	}
+}
+
+// verifyPetLineItemPrice flags the order when the pet's line item was not charged the price matrix price
+func (s *webhookService) verifyPetLineItemPrice(
+	tx *gorm.DB,
+	ctx context.Context,
+	webhook models.Webhook,
+	variantID string,
+	petAttributesMap map[string]string,
+) {
+	req := models.PlanPriceQuoteRequest{
+		PlanID:      petAttributesMap["plan"],
+		AgeRangeID:  petAttributesMap["age"],
+		SizeID:      petAttributesMap["size"],
+		ConditionID: petAttributesMap["condition"],
+	}
+	if createdAt, err := time.Parse(time.RFC3339, webhook.CreatedAt); err == nil {
+		req.Date = &createdAt
+	}
+
+	for _, item := range webhook.LineItems {
+		if fmt.Sprintf("%d", item.VariantID) != variantID {
+			continue
+		}
+
+		err := s.pricingService.VerifyLineItemPrice(tx, ctx, fmt.Sprintf("%d", webhook.ID), item, req)
+		if err != nil {
+			s.logger.Error("verifying line item price", zap.Error(err), zap.Int("order_id", webhook.ID))
+		}
+		return
 	}
 }
 
@@ -330,17 +471,15 @@ func (s *webhookService) createOrFindUser(
 		DocumentType:   userData.DocType,
 		DocumentNumber: userData.DocNumber,
 	}
-	err := tx.WithContext(ctx).Where(dbModels.User{ShopifyID: user.ShopifyID}).FirstOrCreate(&user).Error
-	if err != nil {
-		s.logger.Error("creating user", zap.Error(err))
+	users := s.users.WithTx(tx)
+	if err := users.FirstOrCreateByShopifyID(ctx, &user); err != nil {
 		return nil, err
 	}
 
 	if user.DocumentNumber == "" || user.DocumentType == "" && userData.DocNumber != "" && userData.DocType != "" {
 		user.DocumentNumber = userData.DocNumber
 		user.DocumentType = userData.DocType
-		if err := tx.WithContext(ctx).Save(&user).Error; err != nil {
-			s.logger.Error("updating user document number and type", zap.Error(err))
+		if err := users.Save(ctx, &user); err != nil {
 			return nil, err
 		}
 	}
@@ -383,66 +522,42 @@ func (s *webhookService) GetPetAttributesIDsByVariantID(
 	variantID string,
 	typeStr string,
 ) (map[string]string, error) {
-	var (
-		petAgeRange   dbModels.PetAgeRange
-		petSize       dbModels.PetSize
-		petCondition  dbModels.PetCondition
-		petType       dbModels.PetType
-		plan          dbModels.Plan
-		attributesMap = make(map[string]string)
-	)
-
 	variant, exist := variantsMap[variantID]
 	if !exist {
 		s.logger.Error("variant data not found for pet", zap.String("variant_id", variantID))
 		return nil, fmt.Errorf("variant data not found for variant ID: %s", variantID)
 	}
 
-	err := s.db.WithContext(ctx).
-		Select("ID").
-		Where(dbModels.PetType{Name: strings.ToLower(typeStr)}).First(&petType).Error
+	petType, err := s.petAttributes.GetTypeByName(ctx, typeStr)
 	if err != nil {
-		s.logger.Error("getting pet type", zap.Error(err))
 		return nil, err
 	}
 
-	err = s.db.WithContext(ctx).
-		Select("ID").
-		Where(dbModels.PetAgeRange{Name: strings.ToLower(variant.PetAge)}).First(&petAgeRange).Error
+	petAgeRange, err := s.petAttributes.GetAgeRangeByName(ctx, petType.ID, variant.PetAge)
 	if err != nil {
-		s.logger.Error("getting pet age range", zap.Error(err))
 		return nil, err
 	}
 
-	err = s.db.WithContext(ctx).
-		Select("ID").
-		Where(dbModels.PetSize{Name: strings.ToLower(variant.PetSize)}).First(&petSize).Error
+	petSize, err := s.petAttributes.GetSizeByName(ctx, petType.ID, variant.PetSize)
 	if err != nil {
-		s.logger.Error("getting pet size", zap.Error(err))
 		return nil, err
 	}
 
-	err = s.db.WithContext(ctx).
-		Select("ID").
-		Where(dbModels.PetCondition{Name: strings.ToLower(variant.PetCondition)}).First(&petCondition).Error
+	petCondition, err := s.petAttributes.GetConditionByName(ctx, petType.ID, variant.PetCondition)
 	if err != nil {
-		s.logger.Error("getting pet condition", zap.Error(err))
 		return nil, err
 	}
 
-	err = s.db.WithContext(ctx).
-		Select("ID", "AnnualLimit").
-		Where(dbModels.Plan{ShopifyID: variant.ProductID}).First(&plan).Error
+	plan, err := s.plans.GetByShopifyID(ctx, variant.ProductID)
 	if err != nil {
-		s.logger.Error("getting plan", zap.Error(err))
 		return nil, err
 	}
 
+	attributesMap := make(map[string]string)
 	attributesMap["age"] = petAgeRange.ID
 	attributesMap["size"] = petSize.ID
 	attributesMap["condition"] = petCondition.ID
 	attributesMap["plan"] = plan.ID
-	attributesMap["plan_limit"] = fmt.Sprintf("%f", plan.AnnualLimit)
 	attributesMap["type"] = petType.ID
 
 	return attributesMap, nil
@@ -459,6 +574,7 @@ func (s *webhookService) createOrFindPet(
 		Name:        strings.ToLower(pet.Name),
 		Breed:       pet.Breed,
 		Gender:      pet.Gender,
+		Neutered:    helpers.ParseNeutered(pet.Neutered),
 		CreatedAt:   time.Now().In(s.loc),
 		AgeRangeID:  ageRangeID,
 		SizeID:      sizeID,
@@ -466,27 +582,25 @@ func (s *webhookService) createOrFindPet(
 		TypeID:      typeID,
 		UserID:      userID,
 	}
-	if err := tx.WithContext(ctx).
-		Where(dbModels.Pet{Name: dbPet.Name, UserID: userID}).
-		Omit("MicrochipID").
-		FirstOrCreate(&dbPet).Error; err != nil {
-		s.logger.Error("creating pet", zap.Error(err))
+	if birthday, err := helpers.ParseBirthday(pet.Birthday, s.loc); err == nil {
+		dbPet.Birthday = &birthday
+	}
+	if err := s.pets.WithTx(tx).FirstOrCreateByName(ctx, &dbPet); err != nil {
 		return nil, err
 	}
 
 	return &dbPet, nil
 }
 
-// createPolicy creates a new policy for the given user and pet.
+// createPolicy creates a new policy for the given user and pet with the full annual limit of its plan.
 func (s *webhookService) createPolicy(
 	tx *gorm.DB,
 	ctx context.Context,
 	isManual bool,
-	status, userID, petID, planID, planLimit, variantID string,
+	status, userID, petID, planID, variantID string,
 ) (*dbModels.Policy, error) {
-	remainingBalance, err := strconv.ParseFloat(planLimit, 64)
+	plan, err := s.plans.WithTx(tx).GetByID(ctx, planID)
 	if err != nil {
-		s.logger.Error("parsing plan limit", zap.Error(err))
 		return nil, err
 	}
 
@@ -503,10 +617,9 @@ func (s *webhookService) createPolicy(
 		LimitPeriodEnd:   time.Now().AddDate(1, 0, 0).In(s.loc),
 		CreatedAt:        time.Now().In(s.loc),
 		IsManual:         isManual,
-		RemainingBalance: remainingBalance,
+		RemainingBalance: plan.AnnualLimit,
 	}
-	if err := tx.WithContext(ctx).Create(&policy).Error; err != nil {
-		s.logger.Error("creating policy", zap.Error(err))
+	if err := s.policies.WithTx(tx).Create(ctx, &policy); err != nil {
 		return nil, err
 	}
 
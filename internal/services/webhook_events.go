@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"appa_subscriptions/internal/domains"
+	"appa_subscriptions/internal/models"
+	dbModels "appa_subscriptions/pkg/db/models"
+)
+
+// RecordEvent stores a webhook as it was received, before it is processed
+func (s *webhookService) RecordEvent(ctx context.Context, event *dbModels.WebhookEvent) error {
+	if err := s.db.WithContext(ctx).Create(event).Error; err != nil {
+		s.logger.Error("recording webhook event", zap.Error(err), zap.String("topic", event.Topic))
+		return err
+	}
+
+	return nil
+}
+
+// ReplayEvents processes again the webhook with the id, or every webhook received for the
+// Shopify order with the id in the order they arrived. Processing is idempotent per order, so
+// a replay only completes what the first delivery left undone.
+func (s *webhookService) ReplayEvents(ctx context.Context, id string) ([]dbModels.WebhookEvent, error) {
+	var events []dbModels.WebhookEvent
+	err := s.db.WithContext(ctx).
+		Where("id::text = ? OR shopify_order_id = ?", id, id).
+		Order("received_at").
+		Find(&events).Error
+	if err != nil {
+		s.logger.Error("getting webhook events", zap.Error(err), zap.String("id", id))
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, domains.ErrWebhookEventNotFound
+	}
+
+	for i := range events {
+		var webhook models.Webhook
+		if err := json.Unmarshal([]byte(events[i].Payload), &webhook); err != nil {
+			return nil, fmt.Errorf("decoding webhook event %s: %w", events[i].ID, err)
+		}
+
+		s.logger.Info("replaying webhook event",
+			zap.String("event_id", events[i].ID), zap.String("topic", events[i].Topic), zap.Int("order_id", webhook.ID))
+		switch events[i].Topic {
+		case dbModels.WebhookTopicOrderCreated:
+			s.OrderCreated(webhook)
+		case dbModels.WebhookTopicOrderPaid:
+			s.OrderPaid(webhook)
+		default:
+			return nil, fmt.Errorf("webhook event %s has unknown topic %q", events[i].ID, events[i].Topic)
+		}
+
+		now := time.Now().In(s.loc)
+		err := s.db.WithContext(ctx).Model(&events[i]).Update("replayed_at", now).Error
+		if err != nil {
+			s.logger.Error("marking webhook event replayed", zap.Error(err), zap.String("event_id", events[i].ID))
+			return nil, err
+		}
+	}
+
+	return events, nil
+}
@@ -0,0 +1,27 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrNotFound is returned when no object is stored under a key
+var ErrNotFound = errors.New("blob not found")
+
+// Object describes a stored blob
+type Object struct {
+	Key         string
+	ContentType string
+	Size        int64
+}
+
+// Store keeps uploaded files, like payment proofs, out of the database
+type Store interface {
+	// Put stores the content under the key, replacing any object stored there
+	Put(ctx context.Context, key, contentType string, content io.Reader) (*Object, error)
+	// Get opens the object stored under the key, the caller closes it
+	Get(ctx context.Context, key string) (io.ReadCloser, *Object, error)
+	// Delete removes the object stored under the key, a missing object is not an error
+	Delete(ctx context.Context, key string) error
+}
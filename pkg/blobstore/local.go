@@ -0,0 +1,121 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// LocalStore keeps blobs as files under a root directory. The content type is not stored, it is
+// read back from the extension of the key.
+type LocalStore struct {
+	root   string
+	logger *zap.Logger
+}
+
+// NewLocalStore creates a blob store on the local filesystem, creating its root if needed
+func NewLocalStore(root string, logger *zap.Logger) (*LocalStore, error) {
+	if err := os.MkdirAll(root, 0o750); err != nil {
+		return nil, err
+	}
+
+	return &LocalStore{
+		root:   root,
+		logger: logger,
+	}, nil
+}
+
+// Put writes the content to a temporary file and moves it under the key, so readers never see
+// a partial file
+func (s *LocalStore) Put(ctx context.Context, key, contentType string, content io.Reader) (*Object, error) {
+	name, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o750); err != nil {
+		return nil, err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+
+	size, err := io.Copy(tmp, content)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		s.logger.Error("writing blob", zap.Error(err), zap.String("key", key))
+		return nil, err
+	}
+
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		s.logger.Error("storing blob", zap.Error(err), zap.String("key", key))
+		return nil, err
+	}
+
+	return &Object{Key: key, ContentType: contentType, Size: size}, nil
+}
+
+// Get opens the file stored under the key
+func (s *LocalStore) Get(ctx context.Context, key string) (io.ReadCloser, *Object, error) {
+	name, err := s.path(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	file, err := os.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	return file, &Object{Key: key, ContentType: contentType, Size: info.Size()}, nil
+}
+
+// Delete removes the file stored under the key
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// path maps a slash separated key to a file under the root, rejecting keys that escape it
+func (s *LocalStore) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if clean == "/" || strings.Contains(key, "\\") {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+
+	return filepath.Join(s.root, filepath.FromSlash(strings.TrimPrefix(clean, "/"))), nil
+}
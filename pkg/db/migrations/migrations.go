@@ -0,0 +1,427 @@
+package migrations
+
+import (
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"appa_subscriptions/pkg/db"
+	dbModels "appa_subscriptions/pkg/db/models"
+)
+
+//go:embed versions/*.sql
+var versionFiles embed.FS
+
+//go:embed seeds/*.sql
+var seedFiles embed.FS
+
+// lockKey is the advisory lock held while a migration or seed is applied, so instances
+// starting together never apply the same one twice
+const lockKey = "schema_migrations"
+
+// Migration states reported by Status
+const (
+	StatePending = "pending"
+	StateApplied = "applied"
+	// StateModified is an applied migration whose file changed afterwards. A modified seed is
+	// applied again by the next Up, a modified versioned migration is only reported.
+	StateModified = "modified"
+	// StateMissing is a migration recorded in the database without a file in this build
+	StateMissing = "missing"
+)
+
+var versionFileName = regexp.MustCompile(`^(\d{4})_(\w+)\.(up|down)\.sql$`)
+
+var seedFileName = regexp.MustCompile(`^(\d{2})_(\w+)\.sql$`)
+
+const createTableSQL = `create table if not exists public.schema_migrations (
+  kind text not null,
+  version text not null,
+  name text not null,
+  checksum text not null,
+  applied_at timestamp with time zone not null default now(),
+  constraint schema_migrations_pkey primary key (kind, version)
+)`
+
+// migration is a numbered migration or a repeatable seed read from the embedded files
+type migration struct {
+	kind     string
+	version  string
+	name     string
+	up       string
+	down     string
+	checksum string
+}
+
+// Status is the state of a migration or seed in the database
+type Status struct {
+	Kind      string     `json:"kind"`
+	Version   string     `json:"version"`
+	Name      string     `json:"name"`
+	State     string     `json:"state"`
+	AppliedAt *time.Time `json:"appliedAt,omitempty"`
+}
+
+// Migrator applies the embedded migrations. Versioned migrations run once in version order and
+// are rolled back newest first. Seeds run after them in name order, again whenever their file
+// changes or a migration was applied, so they must be idempotent.
+type Migrator struct {
+	db         *gorm.DB
+	migrations []migration
+	seeds      []migration
+	logger     *zap.Logger
+}
+
+// NewMigrator reads the embedded migrations and seeds
+func NewMigrator(db *gorm.DB, logger *zap.Logger) (*Migrator, error) {
+	migrations, err := readVersions()
+	if err != nil {
+		return nil, err
+	}
+	seeds, err := readSeeds()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+		seeds:      seeds,
+		logger:     logger,
+	}, nil
+}
+
+// Up applies the pending migrations, then the new or changed seeds. It returns how many of
+// each were applied.
+func (m *Migrator) Up(ctx context.Context) (migrated, seeded int, err error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return 0, 0, err
+	}
+
+	for _, mig := range m.migrations {
+		applied, err := m.apply(ctx, mig, false)
+		if err != nil {
+			return migrated, seeded, err
+		}
+		if applied {
+			migrated++
+		}
+	}
+
+	seeded, err = m.applySeeds(ctx, migrated > 0)
+	return migrated, seeded, err
+}
+
+// Seed applies every seed again, like after the catalog was edited by hand
+func (m *Migrator) Seed(ctx context.Context) (int, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return 0, err
+	}
+
+	return m.applySeeds(ctx, true)
+}
+
+// Down rolls back the last steps applied migrations, newest first. The seeds are forgotten so
+// the next Up applies them again on the restored schema.
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return 0, err
+	}
+
+	rolledBack := 0
+	for ; rolledBack < steps; rolledBack++ {
+		done, err := m.rollbackLast(ctx)
+		if err != nil {
+			return rolledBack, err
+		}
+		if !done {
+			break
+		}
+	}
+
+	return rolledBack, nil
+}
+
+// Status lists the migrations and seeds with their state, in the order Up applies them.
+// Migrations recorded in the database without a file are listed last.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+
+	records, err := m.records(m.db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations)+len(m.seeds))
+	for _, mig := range slices.Concat(m.migrations, m.seeds) {
+		status := Status{Kind: mig.kind, Version: mig.version, Name: mig.name, State: StatePending}
+		if record, ok := records[recordKey(mig.kind, mig.version)]; ok {
+			status.State = StateApplied
+			if record.Checksum != mig.checksum {
+				status.State = StateModified
+			}
+			status.AppliedAt = &record.AppliedAt
+			delete(records, recordKey(mig.kind, mig.version))
+		}
+		statuses = append(statuses, status)
+	}
+
+	missing := make([]Status, 0, len(records))
+	for _, record := range records {
+		missing = append(missing, Status{
+			Kind:      record.Kind,
+			Version:   record.Version,
+			Name:      record.Name,
+			State:     StateMissing,
+			AppliedAt: &record.AppliedAt,
+		})
+	}
+	slices.SortFunc(missing, func(a, b Status) int {
+		return strings.Compare(a.Kind+a.Version, b.Kind+b.Version)
+	})
+
+	return append(statuses, missing...), nil
+}
+
+// applySeeds applies the new or changed seeds, or all of them when force is set
+func (m *Migrator) applySeeds(ctx context.Context, force bool) (int, error) {
+	seeded := 0
+	for _, seed := range m.seeds {
+		applied, err := m.apply(ctx, seed, force)
+		if err != nil {
+			return seeded, err
+		}
+		if applied {
+			seeded++
+		}
+	}
+
+	return seeded, nil
+}
+
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	if err := m.db.WithContext(ctx).Exec(createTableSQL).Error; err != nil {
+		m.logger.Error("creating schema_migrations", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// apply runs the up SQL of a migration or seed and records it, unless it is already recorded.
+// A seed recorded with another checksum, or any seed when force is set, is applied again.
+func (m *Migrator) apply(ctx context.Context, mig migration, force bool) (applied bool, err error) {
+	tx := m.db.Begin().WithContext(ctx)
+	defer db.DBRollback(tx, &err)
+
+	if err = lock(tx); err != nil {
+		return false, err
+	}
+
+	var record dbModels.SchemaMigration
+	err = tx.Where("kind = ? AND version = ?", mig.kind, mig.version).Limit(1).Find(&record).Error
+	if err != nil {
+		m.logger.Error("getting schema migration", zap.Error(err), zap.String("version", mig.version))
+		return false, err
+	}
+	recorded := record.Version != ""
+	if recorded && (mig.kind == dbModels.SchemaMigrationVersioned || (record.Checksum == mig.checksum && !force)) {
+		return false, nil
+	}
+
+	if err = tx.Exec(mig.up).Error; err != nil {
+		m.logger.Error("applying migration", zap.Error(err),
+			zap.String("kind", mig.kind), zap.String("version", mig.version), zap.String("name", mig.name))
+		err = fmt.Errorf("%s %s_%s: %w", mig.kind, mig.version, mig.name, err)
+		return false, err
+	}
+
+	record = dbModels.SchemaMigration{
+		Kind:      mig.kind,
+		Version:   mig.version,
+		Name:      mig.name,
+		Checksum:  mig.checksum,
+		AppliedAt: time.Now(),
+	}
+	if err = tx.Save(&record).Error; err != nil {
+		m.logger.Error("recording schema migration", zap.Error(err), zap.String("version", mig.version))
+		return false, err
+	}
+
+	m.logger.Info("migration applied",
+		zap.String("kind", mig.kind), zap.String("version", mig.version), zap.String("name", mig.name))
+
+	return true, nil
+}
+
+// rollbackLast runs the down SQL of the newest applied migration and forgets it with the seeds.
+// It returns false when no migration is applied.
+func (m *Migrator) rollbackLast(ctx context.Context) (done bool, err error) {
+	tx := m.db.Begin().WithContext(ctx)
+	defer db.DBRollback(tx, &err)
+
+	if err = lock(tx); err != nil {
+		return false, err
+	}
+
+	var record dbModels.SchemaMigration
+	err = tx.Where("kind = ?", dbModels.SchemaMigrationVersioned).
+		Order("version DESC").
+		Limit(1).
+		Find(&record).Error
+	if err != nil {
+		m.logger.Error("getting last schema migration", zap.Error(err))
+		return false, err
+	}
+	if record.Version == "" {
+		return false, nil
+	}
+
+	idx := slices.IndexFunc(m.migrations, func(mig migration) bool { return mig.version == record.Version })
+	if idx < 0 {
+		err = fmt.Errorf("migration %s_%s has no file in this build", record.Version, record.Name)
+		return false, err
+	}
+	mig := m.migrations[idx]
+
+	if err = tx.Exec(mig.down).Error; err != nil {
+		m.logger.Error("rolling back migration", zap.Error(err), zap.String("version", mig.version), zap.String("name", mig.name))
+		err = fmt.Errorf("rolling back %s_%s: %w", mig.version, mig.name, err)
+		return false, err
+	}
+
+	err = tx.Where("(kind = ? AND version = ?) OR kind = ?",
+		dbModels.SchemaMigrationVersioned, mig.version, dbModels.SchemaMigrationSeed).
+		Delete(&dbModels.SchemaMigration{}).Error
+	if err != nil {
+		m.logger.Error("forgetting schema migration", zap.Error(err), zap.String("version", mig.version))
+		return false, err
+	}
+
+	m.logger.Info("migration rolled back", zap.String("version", mig.version), zap.String("name", mig.name))
+
+	return true, nil
+}
+
+func (m *Migrator) records(query *gorm.DB) (map[string]dbModels.SchemaMigration, error) {
+	var records []dbModels.SchemaMigration
+	if err := query.Find(&records).Error; err != nil {
+		m.logger.Error("listing schema migrations", zap.Error(err))
+		return nil, err
+	}
+
+	byKey := make(map[string]dbModels.SchemaMigration, len(records))
+	for _, record := range records {
+		byKey[recordKey(record.Kind, record.Version)] = record
+	}
+	return byKey, nil
+}
+
+// lock waits for the migration advisory lock, released when the transaction ends
+func lock(tx *gorm.DB) error {
+	return tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", lockKey).Error
+}
+
+func recordKey(kind, version string) string {
+	return kind + "/" + version
+}
+
+// readVersions reads the numbered migrations in version order. Each one needs an up and a
+// down file.
+func readVersions() ([]migration, error) {
+	entries, err := fs.ReadDir(versionFiles, "versions")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[string]*migration)
+	for _, entry := range entries {
+		match := versionFileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migration file %s is not named NNNN_name.up.sql or NNNN_name.down.sql", entry.Name())
+		}
+		version, name, direction := match[1], match[2], match[3]
+
+		content, err := versionFiles.ReadFile(path.Join("versions", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &migration{kind: dbModels.SchemaMigrationVersioned, version: version, name: name}
+			byVersion[version] = mig
+		}
+		if mig.name != name {
+			return nil, fmt.Errorf("migration %s is named both %s and %s", version, mig.name, name)
+		}
+		if direction == "up" {
+			mig.up = string(content)
+			mig.checksum = checksum(content)
+		} else {
+			mig.down = string(content)
+		}
+	}
+
+	migrations := make([]migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.up == "" || mig.down == "" {
+			return nil, fmt.Errorf("migration %s_%s needs both an up and a down file", mig.version, mig.name)
+		}
+		migrations = append(migrations, *mig)
+	}
+	slices.SortFunc(migrations, func(a, b migration) int { return strings.Compare(a.version, b.version) })
+
+	return migrations, nil
+}
+
+// readSeeds reads the repeatable seeds in name order
+func readSeeds() ([]migration, error) {
+	entries, err := fs.ReadDir(seedFiles, "seeds")
+	if err != nil {
+		return nil, err
+	}
+
+	seeds := make([]migration, 0, len(entries))
+	for _, entry := range entries {
+		match := seedFileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("seed file %s is not named NN_name.sql", entry.Name())
+		}
+
+		content, err := seedFiles.ReadFile(path.Join("seeds", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if len(strings.TrimSpace(string(content))) == 0 {
+			return nil, fmt.Errorf("seed file %s is empty", entry.Name())
+		}
+
+		seeds = append(seeds, migration{
+			kind:     dbModels.SchemaMigrationSeed,
+			version:  match[1],
+			name:     match[2],
+			up:       string(content),
+			checksum: checksum(content),
+		})
+	}
+
+	return seeds, nil
+}
+
+func checksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
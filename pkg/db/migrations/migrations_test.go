@@ -0,0 +1,129 @@
+package migrations_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	_ "github.com/lib/pq"
+	"go.uber.org/zap"
+
+	"appa_subscriptions/pkg/db"
+	"appa_subscriptions/pkg/db/migrations"
+	dbModels "appa_subscriptions/pkg/db/models"
+)
+
+// legacyDSNEnv names the connection string of a scratch database the legacy dump is restored
+// into. Its public schema is dropped first, the test is skipped when it is not set.
+const legacyDSNEnv = "TEST_LEGACY_DATABASE_DSN"
+
+// legacyColumns are the columns the models use that the old dump did not create
+var legacyColumns = map[string][]string{
+	"users":           {"document_type", "document_number"},
+	"pets":            {"type_id", "birthday", "neutered"},
+	"pets_age_ranges": {"shopify_id", "min_age_months", "max_age_months"},
+	"pets_sizes":      {"shopify_id"},
+	"pets_conditions": {"shopify_id", "gender", "neutered"},
+	"policies": {
+		"shopify_id", "is_manual", "cancellation_reason", "cancellation_notes",
+		"cancellation_requested_at", "cancellation_effective_date", "cancelled_at",
+	},
+	"payment_installments": {
+		"currency", "presentment_amount", "presentment_currency", "exchange_rate",
+		"exchange_rate_source", "exchange_rate_date", "shopify_order_name",
+		"discount_amount", "discount_code",
+	},
+}
+
+func TestUpOnLegacyDump(t *testing.T) {
+	dsn := os.Getenv(legacyDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", legacyDSNEnv)
+	}
+	ctx := context.Background()
+
+	gormDB, err := db.NewDBSQLHandler(dsn)
+	if err != nil {
+		t.Fatalf("connecting: %v", err)
+	}
+
+	dump, err := os.ReadFile("testdata/legacy_schema.sql")
+	if err != nil {
+		t.Fatalf("reading legacy dump: %v", err)
+	}
+	if err := gormDB.Exec("DROP SCHEMA public CASCADE; CREATE SCHEMA public;").Error; err != nil {
+		t.Fatalf("resetting database: %v", err)
+	}
+	if err := gormDB.Exec(string(dump)).Error; err != nil {
+		t.Fatalf("restoring legacy dump: %v", err)
+	}
+
+	migrator, err := migrations.NewMigrator(gormDB, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+	if _, _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("Up on the legacy dump: %v", err)
+	}
+
+	for table, columns := range legacyColumns {
+		for _, column := range columns {
+			var found int64
+			err := gormDB.Raw(
+				"SELECT count(*) FROM information_schema.columns WHERE table_schema = 'public' AND table_name = ? AND column_name = ?",
+				table, column,
+			).Scan(&found).Error
+			if err != nil {
+				t.Fatalf("looking up %s.%s: %v", table, column, err)
+			}
+			if found == 0 {
+				t.Errorf("column %s.%s is missing", table, column)
+			}
+		}
+	}
+
+	t.Run("amounts rounded to cents", func(t *testing.T) {
+		var installments []dbModels.PaymentInstallment
+		if err := gormDB.Order("installment_number").Find(&installments).Error; err != nil {
+			t.Fatalf("reading legacy installments: %v", err)
+		}
+		if len(installments) != 2 {
+			t.Fatalf("got %d installments, want 2", len(installments))
+		}
+		if got := installments[0].Amount.String(); got != "220.01" {
+			t.Errorf("amount 220.005 migrated to %s, want 220.01", got)
+		}
+		if installments[0].Currency != "USD" || !installments[0].DiscountAmount.IsZero() {
+			t.Errorf("legacy installment defaults: currency %q, discount %s", installments[0].Currency, installments[0].DiscountAmount)
+		}
+	})
+
+	t.Run("attributes backfilled", func(t *testing.T) {
+		var missing int64
+		err := gormDB.Raw(`SELECT
+			(SELECT count(*) FROM public.pets_age_ranges WHERE min_age_months IS NULL) +
+			(SELECT count(*) FROM public.pets_conditions WHERE gender IS NULL)`).Scan(&missing).Error
+		if err != nil {
+			t.Fatalf("counting attributes: %v", err)
+		}
+		if missing != 0 {
+			t.Errorf("%d legacy attributes without ages or gender", missing)
+		}
+	})
+
+	t.Run("pending review status allowed", func(t *testing.T) {
+		err := gormDB.Model(&dbModels.Policy{}).
+			Where("id = ?", "6a0c1d2e-0000-4000-8000-000000000003").
+			Update("status", "pending_review").Error
+		if err != nil {
+			t.Errorf("holding a legacy policy for review: %v", err)
+		}
+	})
+
+	t.Run("up again", func(t *testing.T) {
+		migrated, _, err := migrator.Up(ctx)
+		if err != nil || migrated != 0 {
+			t.Errorf("second Up: migrated %d, err %v", migrated, err)
+		}
+	})
+}
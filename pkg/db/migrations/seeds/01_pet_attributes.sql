@@ -0,0 +1,60 @@
+-- Pet types and the age ranges, sizes and conditions of each one. Rows are matched by name
+-- within the pet type and only missing ones are inserted, the catalog sync owns the rest.
+
+INSERT INTO public.pets_types (name)
+SELECT seed.name
+FROM (VALUES ('canine'), ('feline')) AS seed (name)
+WHERE NOT EXISTS (SELECT 1 FROM public.pets_types WHERE name = seed.name);
+
+INSERT INTO public.pets_age_ranges (name, pet_type_id, min_age_months, max_age_months)
+SELECT seed.name, pets_types.id, seed.min_age_months, seed.max_age_months
+FROM (VALUES
+  ('feline', LOWER('Cachorro (5m-1año)'), 5, 12),
+  ('feline', LOWER('Joven (1-6años)'), 12, 72),
+  ('feline', LOWER('Adulto (6-10años)'), 72, 120),
+  ('feline', LOWER('Senior (10-15+años)'), 120, null),
+
+  ('canine', LOWER('Cachorro (5m-1año)'), 5, 12),
+  ('canine', LOWER('Joven (1-5años)'), 12, 60),
+  ('canine', LOWER('Adulto (5-7años)'), 60, 84),
+  ('canine', LOWER('Senior (7-15+años)'), 84, null)
+) AS seed (pet_type, name, min_age_months, max_age_months)
+JOIN public.pets_types ON pets_types.name = seed.pet_type
+WHERE NOT EXISTS (
+  SELECT 1 FROM public.pets_age_ranges
+  WHERE pet_type_id = pets_types.id AND name = seed.name
+);
+
+INSERT INTO public.pets_sizes (name, pet_type_id)
+SELECT seed.name, pets_types.id
+FROM (VALUES
+  ('feline', LOWER('Normal')),
+
+  ('canine', LOWER('Pequeñas')),
+  ('canine', LOWER('Medianas')),
+  ('canine', LOWER('Grandes'))
+) AS seed (pet_type, name)
+JOIN public.pets_types ON pets_types.name = seed.pet_type
+WHERE NOT EXISTS (
+  SELECT 1 FROM public.pets_sizes
+  WHERE pet_type_id = pets_types.id AND name = seed.name
+);
+
+INSERT INTO public.pets_conditions (name, pet_type_id, gender, neutered)
+SELECT seed.name, pets_types.id, seed.gender, seed.neutered
+FROM (VALUES
+  ('canine', LOWER('Macho Castrado'), 'male', true),
+  ('canine', LOWER('Hembra Esterilizada'), 'female', true),
+  ('canine', LOWER('Macho NO Castrado'), 'male', false),
+  ('canine', LOWER('Hembra NO Esterilizada'), 'female', false),
+
+  ('feline', LOWER('Macho Castrado'), 'male', true),
+  ('feline', LOWER('Hembra Esterilizada'), 'female', true),
+  ('feline', LOWER('Macho NO Castrado'), 'male', false),
+  ('feline', LOWER('Hembra NO Esterilizada'), 'female', false)
+) AS seed (pet_type, name, gender, neutered)
+JOIN public.pets_types ON pets_types.name = seed.pet_type
+WHERE NOT EXISTS (
+  SELECT 1 FROM public.pets_conditions
+  WHERE pet_type_id = pets_types.id AND name = seed.name
+);
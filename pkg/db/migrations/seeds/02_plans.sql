@@ -0,0 +1,30 @@
+-- Plans matched by Shopify product id, with the default waiting periods of each plan. Only
+-- missing rows are inserted, prices and limits synced from Shopify are left untouched.
+
+INSERT INTO public.plans (name, monthly_price, annual_limit, shopify_id, pet_type_id)
+SELECT seed.name, seed.monthly_price, seed.annual_limit, seed.shopify_id, pets_types.id
+FROM (VALUES
+  ('feline', LOWER('Plan 5000 feline + Bienestar'), 50.00, 5000.00, '8969215312122'),
+  ('feline', LOWER('Plan 5000 feline'), 90.00, 5000.00, '8969215213818'),
+  ('canine', LOWER('Plan 5000 canine + Bienestar'), 80.00, 5000.00, '8969215115514'),
+  ('canine', LOWER('Plan 5000 canine'), 150.00, 5000.00, '8969215017210'),
+
+  ('feline', LOWER('Plan 500 feline + Bienestar'), 50.00, 500.00, '8969214951674'),
+  ('feline', LOWER('Plan 500 feline'), 90.00, 500.00, '8969214820602'),
+  ('canine', LOWER('Plan 500 canine + Bienestar'), 80.00, 500.00, '8969214722298'),
+  ('canine', LOWER('Plan 500 canine'), 150.00, 500.00, '8969214623994'),
+
+  ('feline', LOWER('Plan 1500 feline + Bienestar'), 50.00, 1500.00, '8969214492922'),
+  ('feline', LOWER('Plan 1500 feline'), 90.00, 1500.00, '8969214394618'),
+  ('canine', LOWER('Plan 1500 canine + Bienestar'), 120.00, 1500.00, '8969214296314'),
+  ('canine', LOWER('Plan 1500 canine'), 220.00, 1500.00, '8969214132474')
+) AS seed (pet_type, name, monthly_price, annual_limit, shopify_id)
+JOIN public.pets_types ON pets_types.name = seed.pet_type
+WHERE NOT EXISTS (SELECT 1 FROM public.plans WHERE shopify_id = seed.shopify_id);
+
+-- Default waiting periods, adjusted per plan afterwards
+INSERT INTO public.plan_waiting_periods (plan_id, coverage_type, days)
+SELECT plans.id, seed.coverage_type, seed.days
+FROM public.plans
+CROSS JOIN (VALUES ('accident', 15), ('illness', 30)) AS seed (coverage_type, days)
+ON CONFLICT (plan_id, coverage_type) DO NOTHING;
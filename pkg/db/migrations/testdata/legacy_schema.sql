@@ -1,3 +1,66 @@
+-- The old pkg/db/schemas.sql dump in an order that applies, with a few rows, standing for the
+-- production databases created from it. The dump left out the functions its triggers run, they
+-- are created first as the production databases have them.
+
+CREATE TYPE public.app_role AS ENUM ('admin', 'user');
+
+create or replace function public.update_updated_at ()
+returns trigger
+language plpgsql
+as $$
+BEGIN
+  NEW.updated_at = now();
+  RETURN NEW;
+END;
+$$;
+
+create or replace function public.auto_update_payment_status ()
+returns trigger
+language plpgsql
+as $$
+BEGIN
+  RETURN NEW;
+END;
+$$;
+
+create or replace function public.update_policy_status_from_installment ()
+returns trigger
+language plpgsql
+as $$
+DECLARE
+  latest_installment RECORD;
+BEGIN
+  -- Iterate over all latest installments for the given policy
+  FOR latest_installment IN
+    SELECT
+      policies_payments.policy_id,
+      payment_installments.id AS installment_id,
+      payment_installments.installment_number,
+      payment_installments.due_date,
+      payment_installments.status
+    FROM policies_payments
+    JOIN payment_installments ON policies_payments.payment_installment_id = payment_installments.id
+    WHERE payment_installments.id = NEW.id
+    ORDER BY payment_installments.installment_number DESC
+  LOOP
+    -- Update policy status based on installment status
+    IF latest_installment.status = 'paid' THEN
+      UPDATE policies
+      SET status = 'active',
+          next_payment = (latest_installment.due_date + INTERVAL '1 month')::DATE
+      WHERE id = latest_installment.policy_id;
+    ELSIF latest_installment.status = 'pending' OR latest_installment.status = 'overdue' THEN
+      UPDATE policies
+      SET status = 'payment_pending',
+          next_payment = latest_installment.due_date
+      WHERE id = latest_installment.policy_id;
+    END IF;
+  END LOOP;
+
+  RETURN NEW;
+END;
+$$;
+
 create table public.pets_types (
   id uuid not null default gen_random_uuid (),
   name text not null,
@@ -68,6 +131,29 @@ INSERT INTO public.pets_conditions (id, name, pet_type_id) VALUES
     (gen_random_uuid(), LOWER('Macho NO Castrado'), (SELECT id FROM public.pets_types WHERE name = LOWER('feline'))),
     (gen_random_uuid(), LOWER('Hembra NO Esterilizada'), (SELECT id FROM public.pets_types WHERE name = LOWER('feline')));
 
+create table public.users (
+  id uuid not null,
+  name text not null,
+  email text not null,
+  phone text null,
+  city text null,
+  role public.app_role not null default 'user'::app_role,
+  created_at timestamp with time zone not null default now(),
+  updated_at timestamp with time zone not null default now(),
+  shopify_id text not null,
+  constraint users_pkey primary key (id),
+  constraint users_email_key unique (email),
+  constraint users_shopify_id_key unique (shopify_id)
+) TABLESPACE pg_default;
+
+create index IF not exists idx_users_email on public.users using btree (email) TABLESPACE pg_default;
+
+create index IF not exists idx_users_role on public.users using btree (role) TABLESPACE pg_default;
+
+create trigger update_users_updated_at BEFORE
+update on users for EACH row
+execute FUNCTION update_updated_at ();
+
 create table public.pets (
   id uuid not null default gen_random_uuid (),
   user_id uuid not null,
@@ -132,29 +218,6 @@ INSERT INTO public.plans (id, name, monthly_price, annual_limit, shopify_id, pet
         (gen_random_uuid(), LOWER('Plan 1500 canine + Bienestar'), 120.00, 1500.00, '8969214296314', (SELECT id FROM public.pets_types WHERE name = 'canine' LIMIT 1)),
         (gen_random_uuid(), LOWER('Plan 1500 canine'), 220.00, 1500.00, '8969214132474', (SELECT id FROM public.pets_types WHERE name = 'canine' LIMIT 1));
 
-create table public.users (
-  id uuid not null,
-  name text not null,
-  email text not null,
-  phone text null,
-  city text null,
-  role public.app_role not null default 'user'::app_role,
-  created_at timestamp with time zone not null default now(),
-  updated_at timestamp with time zone not null default now(),
-  shopify_id text not null,
-  constraint users_pkey primary key (id),
-  constraint users_email_key unique (email),
-  constraint users_shopify_id_key unique (shopify_id)
-) TABLESPACE pg_default;
-
-create index IF not exists idx_users_email on public.users using btree (email) TABLESPACE pg_default;
-
-create index IF not exists idx_users_role on public.users using btree (role) TABLESPACE pg_default;
-
-create trigger update_users_updated_at BEFORE
-update on users for EACH row
-execute FUNCTION update_updated_at ();
-
 create table public.policies (
   id uuid not null default gen_random_uuid (),
   user_id uuid not null,
@@ -202,21 +265,6 @@ create trigger update_policies_updated_at BEFORE
 update on policies for EACH row
 execute FUNCTION update_updated_at ();
 
-create table public.policies_payments (
-  id uuid not null default gen_random_uuid (),
-  policy_id uuid not null,
-  payment_installment_id uuid not null,
-  created_at timestamp with time zone not null default now(),
-  constraint policies_payments_pkey primary key (id),
-  constraint policies_payments_policy_id_payment_installment_id_key unique (policy_id, payment_installment_id),
-  constraint policies_payments_payment_installment_id_fkey foreign KEY (payment_installment_id) references payment_installments (id) on delete CASCADE,
-  constraint policies_payments_policy_id_fkey foreign KEY (policy_id) references policies (id) on delete CASCADE
-) TABLESPACE pg_default;
-
-create index IF not exists idx_policies_payments_policy_id on public.policies_payments using btree (policy_id) TABLESPACE pg_default;
-
-create index IF not exists idx_policies_payments_installment_id on public.policies_payments using btree (payment_installment_id) TABLESPACE pg_default;
-
 create table public.payment_installments (
   id uuid not null default gen_random_uuid (),
   installment_number integer not null,
@@ -257,36 +305,41 @@ or
 update OF status on payment_installments for EACH row
 execute FUNCTION update_policy_status_from_installment ();
 
+create table public.policies_payments (
+  id uuid not null default gen_random_uuid (),
+  policy_id uuid not null,
+  payment_installment_id uuid not null,
+  created_at timestamp with time zone not null default now(),
+  constraint policies_payments_pkey primary key (id),
+  constraint policies_payments_policy_id_payment_installment_id_key unique (policy_id, payment_installment_id),
+  constraint policies_payments_payment_installment_id_fkey foreign KEY (payment_installment_id) references payment_installments (id) on delete CASCADE,
+  constraint policies_payments_policy_id_fkey foreign KEY (policy_id) references policies (id) on delete CASCADE
+) TABLESPACE pg_default;
+
+create index IF not exists idx_policies_payments_policy_id on public.policies_payments using btree (policy_id) TABLESPACE pg_default;
 
-DECLARE
-  latest_installment RECORD;
-BEGIN
-  -- Iterate over all latest installments for the given policy
-  FOR latest_installment IN
-    SELECT
-      policies_payments.policy_id,
-      payment_installments.id AS installment_id,
-      payment_installments.installment_number,
-      payment_installments.due_date,
-      payment_installments.status
-    FROM policies_payments
-    JOIN payment_installments ON policies_payments.payment_installment_id = payment_installments.id
-    WHERE payment_installments.id = NEW.id
-    ORDER BY payment_installments.installment_number DESC
-  LOOP
-    -- Update policy status based on installment status
-    IF latest_installment.status = 'paid' THEN
-      UPDATE policies
-      SET status = 'active',
-          next_payment = (latest_installment.due_date + INTERVAL '1 month')::DATE
-      WHERE id = latest_installment.policy_id;
-    ELSIF latest_installment.status = 'pending' OR latest_installment.status = 'overdue' THEN
-      UPDATE policies
-      SET status = 'payment_pending',
-          next_payment = latest_installment.due_date
-      WHERE id = latest_installment.policy_id;
-    END IF;
-  END LOOP;
+create index IF not exists idx_policies_payments_installment_id on public.policies_payments using btree (payment_installment_id) TABLESPACE pg_default;
 
-  RETURN NEW;
-END;
+INSERT INTO public.users (id, name, email, shopify_id) VALUES
+  ('6a0c1d2e-0000-4000-8000-000000000001', 'Legacy Owner', 'legacy@example.com', '7000000000001');
+
+INSERT INTO public.pets (id, user_id, name, breed, gender, age_range_id, condition_id, size_id, pet_type_id)
+SELECT '6a0c1d2e-0000-4000-8000-000000000002', '6a0c1d2e-0000-4000-8000-000000000001', 'Toby', 'mestizo', 'male',
+  (SELECT id FROM public.pets_age_ranges WHERE name = LOWER('Joven (1-5años)')),
+  (SELECT id FROM public.pets_conditions WHERE name = LOWER('Macho Castrado') AND pet_type_id = pets_types.id),
+  (SELECT id FROM public.pets_sizes WHERE name = LOWER('Medianas')),
+  pets_types.id
+FROM public.pets_types WHERE name = 'canine';
+
+INSERT INTO public.policies (id, user_id, pet_id, plan_id, start_date, next_payment, remaining_balance, status)
+SELECT '6a0c1d2e-0000-4000-8000-000000000003', '6a0c1d2e-0000-4000-8000-000000000001', '6a0c1d2e-0000-4000-8000-000000000002',
+  id, '2025-01-10', '2025-02-10', 1500.00, 'active'
+FROM public.plans WHERE shopify_id = '8969214132474';
+
+INSERT INTO public.payment_installments (id, installment_number, due_date, amount, status, shopify_order_id) VALUES
+  ('6a0c1d2e-0000-4000-8000-000000000004', 1, '2025-01-10', 220.005, 'paid', '5000000000001'),
+  ('6a0c1d2e-0000-4000-8000-000000000005', 2, '2025-02-10', 220, 'pending', '5000000000002');
+
+INSERT INTO public.policies_payments (policy_id, payment_installment_id) VALUES
+  ('6a0c1d2e-0000-4000-8000-000000000003', '6a0c1d2e-0000-4000-8000-000000000004'),
+  ('6a0c1d2e-0000-4000-8000-000000000003', '6a0c1d2e-0000-4000-8000-000000000005');
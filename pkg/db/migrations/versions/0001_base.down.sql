@@ -0,0 +1,15 @@
+drop table if exists public.policies_payments;
+drop table if exists public.payment_installments;
+drop table if exists public.policies;
+drop table if exists public.plans;
+drop table if exists public.pets;
+drop table if exists public.users;
+drop table if exists public.pets_conditions;
+drop table if exists public.pets_sizes;
+drop table if exists public.pets_age_ranges;
+drop table if exists public.pets_types;
+
+drop type if exists public.app_role;
+
+drop function if exists public.update_policy_status_from_installment ();
+drop function if exists public.update_updated_at ();
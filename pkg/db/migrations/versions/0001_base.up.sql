@@ -0,0 +1,327 @@
+-- Base schema: pet attributes, users, pets, plans, policies and their installments.
+-- Every statement is guarded so it can run on a database created from the old schemas.sql
+-- dump. The tables the dump already has are skipped, 0002 and 0010 add the columns they lack.
+
+create or replace function public.update_updated_at ()
+returns trigger
+language plpgsql
+as $$
+BEGIN
+  NEW.updated_at = now();
+  RETURN NEW;
+END;
+$$;
+
+create or replace function public.update_policy_status_from_installment ()
+returns trigger
+language plpgsql
+as $$
+DECLARE
+  latest_installment RECORD;
+BEGIN
+  -- Iterate over all latest installments for the given policy
+  FOR latest_installment IN
+    SELECT
+      policies_payments.policy_id,
+      payment_installments.id AS installment_id,
+      payment_installments.installment_number,
+      payment_installments.due_date,
+      payment_installments.status
+    FROM policies_payments
+    JOIN payment_installments ON policies_payments.payment_installment_id = payment_installments.id
+    WHERE payment_installments.id = NEW.id
+    ORDER BY payment_installments.installment_number DESC
+  LOOP
+    -- Update policy status based on installment status
+    IF latest_installment.status = 'paid' THEN
+      UPDATE policies
+      SET status = 'active',
+          next_payment = (latest_installment.due_date + INTERVAL '1 month')::DATE
+      WHERE id = latest_installment.policy_id
+        AND status NOT IN ('pending_review', 'pending_cancellation', 'cancelled');
+    ELSIF latest_installment.status = 'pending' OR latest_installment.status = 'overdue' THEN
+      UPDATE policies
+      SET status = 'payment_pending',
+          next_payment = latest_installment.due_date
+      WHERE id = latest_installment.policy_id
+        AND status NOT IN ('pending_review', 'pending_cancellation', 'cancelled');
+    END IF;
+  END LOOP;
+
+  RETURN NEW;
+END;
+$$;
+
+DO $$
+BEGIN
+  IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'app_role') THEN
+    CREATE TYPE public.app_role AS ENUM ('admin', 'user');
+  END IF;
+END;
+$$;
+
+create table if not exists public.pets_types (
+  id uuid not null default gen_random_uuid (),
+  name text not null,
+  constraint pets_types_pkey primary key (id)
+) TABLESPACE pg_default;
+CREATE INDEX IF not exists idx_pets_types_name on public.pets_types using btree (name) TABLESPACE pg_default;
+
+create table if not exists public.pets_age_ranges (
+  id uuid not null default gen_random_uuid (),
+  name text not null,
+  pet_type_id uuid not null,
+  shopify_id text null,
+  min_age_months integer null,
+  max_age_months integer null,
+  constraint pets_age_ranges_pet_type_id_fkey foreign KEY (pet_type_id) references pets_types (id) on delete RESTRICT,
+  constraint pets_age_ranges_pkey primary key (id)
+) TABLESPACE pg_default;
+CREATE INDEX IF not exists idx_pets_age_ranges_pet_type_id on public.pets_age_ranges using btree (pet_type_id) TABLESPACE pg_default;
+CREATE INDEX IF not exists idx_pets_age_ranges_name on public.pets_age_ranges using btree (name) TABLESPACE pg_default;
+CREATE INDEX IF not exists idx_pets_age_ranges_shopify_id on public.pets_age_ranges using btree (shopify_id) TABLESPACE pg_default;
+
+create table if not exists public.pets_sizes (
+  id uuid not null default gen_random_uuid (),
+  name text not null,
+  pet_type_id uuid not null,
+  shopify_id text null,
+  constraint pets_sizes_pet_type_id_fkey foreign KEY (pet_type_id) references pets_types (id) on delete RESTRICT,
+  constraint pets_sizes_pkey primary key (id)
+) TABLESPACE pg_default;
+CREATE INDEX IF not exists idx_pets_sizes_pet_type_id on public.pets_sizes using btree (pet_type_id) TABLESPACE pg_default;
+CREATE INDEX IF not exists idx_pets_sizes_name on public.pets_sizes using btree (name) TABLESPACE pg_default;
+CREATE INDEX IF not exists idx_pets_sizes_shopify_id on public.pets_sizes using btree (shopify_id) TABLESPACE pg_default;
+
+create table if not exists public.pets_conditions (
+  id uuid not null default gen_random_uuid (),
+  name text not null,
+  pet_type_id uuid not null,
+  shopify_id text null,
+  gender text null,
+  neutered boolean not null default false,
+  constraint pets_conditions_pet_type_id_fkey foreign KEY (pet_type_id) references pets_types (id) on delete RESTRICT,
+  constraint pets_conditions_pkey primary key (id)
+) TABLESPACE pg_default;
+CREATE INDEX IF not exists idx_pets_conditions_pet_type_id on public.pets_conditions using btree (pet_type_id) TABLESPACE pg_default;
+CREATE INDEX IF not exists idx_pets_conditions_name on public.pets_conditions using btree (name) TABLESPACE pg_default;
+CREATE INDEX IF not exists idx_pets_conditions_shopify_id on public.pets_conditions using btree (shopify_id) TABLESPACE pg_default;
+
+create table if not exists public.users (
+  id uuid not null,
+  name text not null,
+  email text not null,
+  phone text null,
+  city text null,
+  role public.app_role not null default 'user'::app_role,
+  created_at timestamp with time zone not null default now(),
+  updated_at timestamp with time zone not null default now(),
+  shopify_id text not null,
+  constraint users_pkey primary key (id),
+  constraint users_email_key unique (email),
+  constraint users_shopify_id_key unique (shopify_id)
+) TABLESPACE pg_default;
+
+create index IF not exists idx_users_email on public.users using btree (email) TABLESPACE pg_default;
+
+create index IF not exists idx_users_role on public.users using btree (role) TABLESPACE pg_default;
+
+drop trigger if exists update_users_updated_at on public.users;
+
+create trigger update_users_updated_at BEFORE
+update on public.users for EACH row
+execute FUNCTION update_updated_at ();
+
+create table if not exists public.pets (
+  id uuid not null default gen_random_uuid (),
+  user_id uuid not null,
+  name text not null,
+  breed text not null,
+  gender text not null,
+  weight numeric(5, 2) null,
+  created_at timestamp with time zone not null default now(),
+  updated_at timestamp with time zone not null default now(),
+  microchip_id text null,
+  birthday date null,
+  neutered boolean null,
+  age_range_id uuid not null,
+  condition_id uuid not null,
+  size_id uuid not null,
+    pet_type_id uuid not null,
+  constraint pets_pkey primary key (id),
+  constraint pets_user_id_fkey foreign KEY (user_id) references users (id) on delete CASCADE,
+    constraint pets_age_range_id_fkey foreign KEY (age_range_id) references pets_age_ranges (id) on delete RESTRICT,
+    constraint pets_condition_id_fkey foreign KEY (condition_id) references pets_conditions (id) on delete RESTRICT,
+    constraint pets_size_id_fkey foreign KEY (size_id) references pets_sizes (id) on delete RESTRICT,
+    constraint pets_pet_type_id_fkey foreign KEY (pet_type_id) references pets_types (id) on delete RESTRICT,
+  constraint pets_gender_check check (
+    (
+      gender = any (array['male'::text, 'female'::text])
+    )
+  )
+) TABLESPACE pg_default;
+
+create index IF not exists idx_pets_user_id on public.pets using btree (user_id) TABLESPACE pg_default;
+
+drop trigger if exists update_pets_updated_at on public.pets;
+
+create trigger update_pets_updated_at BEFORE
+update on public.pets for EACH row
+execute FUNCTION update_updated_at ();
+
+create table if not exists public.plans (
+  id uuid not null default gen_random_uuid (),
+  name text not null,
+  monthly_price numeric(10, 2) not null,
+  annual_limit numeric(10, 2) not null,
+  description text null,
+  shopify_id text null,
+  pet_type_id uuid not null,
+  created_at timestamp with time zone not null default now(),
+  constraint plans_pkey primary key (id)
+) TABLESPACE pg_default;
+CREATE INDEX IF not exists idx_plans_name on public.plans using btree (name) TABLESPACE pg_default;
+CREATE INDEX IF not exists idx_plans_shopify_id on public.plans using btree (shopify_id) TABLESPACE pg_default;
+CREATE INDEX IF not exists idx_plans_pet_type_id on public.plans using btree (pet_type_id) TABLESPACE pg_default;
+
+create table if not exists public.policies (
+  id uuid not null default gen_random_uuid (),
+  user_id uuid not null,
+  pet_id uuid not null,
+  plan_id uuid not null,
+  start_date date not null,
+  next_payment date not null,
+  remaining_balance numeric(10, 2) not null,
+  status text not null default 'active'::text,
+  created_at timestamp with time zone not null default now(),
+  updated_at timestamp with time zone not null default now(),
+  health_declared boolean not null default false,
+  limit_period_start date not null default CURRENT_DATE,
+  limit_period_end date not null default (CURRENT_DATE + '1 year'::interval),
+  documents_verified boolean not null default false,
+  cancellation_reason text null,
+  cancellation_notes text null,
+  cancellation_requested_at timestamp with time zone null,
+  cancellation_effective_date date null,
+  cancelled_at timestamp with time zone null,
+  constraint policies_pkey primary key (id),
+  constraint policies_pet_id_fkey foreign KEY (pet_id) references pets (id) on delete CASCADE,
+  constraint policies_plan_id_fkey foreign KEY (plan_id) references plans (id) on delete RESTRICT,
+  constraint policies_user_id_fkey foreign KEY (user_id) references users (id) on delete CASCADE,
+  constraint policies_status_check check (
+    (
+      status = any (
+        array[
+          'active'::text,
+          'payment_pending'::text,
+          'cancelled'::text,
+          'pending_cancellation'::text,
+          'pending_review'::text
+        ]
+      )
+    )
+  ),
+  constraint policies_cancellation_reason_check check (
+    (
+      cancellation_reason is null
+      or cancellation_reason = any (
+        array[
+          'customer_request'::text,
+          'non_payment'::text,
+          'pet_deceased'::text,
+          'fraud'::text,
+          'duplicate'::text,
+          'other'::text
+        ]
+      )
+    )
+  )
+) TABLESPACE pg_default;
+
+create index IF not exists idx_policies_cancellation_effective_date on public.policies using btree (cancellation_effective_date) TABLESPACE pg_default
+where
+  status = 'pending_cancellation'::text;
+
+create index IF not exists idx_policies_user_id on public.policies using btree (user_id) TABLESPACE pg_default;
+
+create index IF not exists idx_policies_status on public.policies using btree (status) TABLESPACE pg_default;
+
+create index IF not exists idx_policies_plan_id on public.policies using btree (plan_id) TABLESPACE pg_default;
+
+-- The old dump attached trigger_auto_payment_status to policies without the body of
+-- auto_update_payment_status. Policy status follows the installments through
+-- update_policy_status_on_installment_change, so the trigger is not recreated here.
+
+drop trigger if exists update_policies_updated_at on public.policies;
+
+create trigger update_policies_updated_at BEFORE
+update on public.policies for EACH row
+execute FUNCTION update_updated_at ();
+
+create table if not exists public.payment_installments (
+  id uuid not null default gen_random_uuid (),
+  installment_number integer not null,
+  due_date date not null,
+  amount numeric(14, 2) not null,
+  currency text not null default 'USD'::text,
+  presentment_amount numeric(14, 2) null,
+  presentment_currency text null,
+  exchange_rate numeric(18, 6) null,
+  exchange_rate_source text null,
+  exchange_rate_date date null,
+  status text not null default 'pending'::text,
+  shopify_order_id text null,
+  shopify_order_name text null,
+  shopify_checkout_url text null,
+  paid_at timestamp with time zone null,
+  discount_amount numeric(10, 2) not null default 0,
+  discount_code text null,
+  created_at timestamp with time zone not null default now(),
+  updated_at timestamp with time zone not null default now(),
+  constraint payment_installments_pkey primary key (id),
+  constraint payment_installments_status_check check (
+    (
+      status = any (
+        array[
+          'pending'::text,
+          'paid'::text,
+          'overdue'::text,
+          'cancelled'::text
+        ]
+      )
+    )
+  )
+) TABLESPACE pg_default;
+
+create index IF not exists idx_installments_status on public.payment_installments using btree (status) TABLESPACE pg_default;
+
+create index IF not exists idx_installments_due_date on public.payment_installments using btree (due_date) TABLESPACE pg_default;
+
+drop trigger if exists update_payment_installments_updated_at on public.payment_installments;
+
+create trigger update_payment_installments_updated_at BEFORE
+update on public.payment_installments for EACH row
+execute FUNCTION update_updated_at ();
+
+create table if not exists public.policies_payments (
+  id uuid not null default gen_random_uuid (),
+  policy_id uuid not null,
+  payment_installment_id uuid not null,
+  created_at timestamp with time zone not null default now(),
+  constraint policies_payments_pkey primary key (id),
+  constraint policies_payments_policy_id_payment_installment_id_key unique (policy_id, payment_installment_id),
+  constraint policies_payments_payment_installment_id_fkey foreign KEY (payment_installment_id) references payment_installments (id) on delete CASCADE,
+  constraint policies_payments_policy_id_fkey foreign KEY (policy_id) references policies (id) on delete CASCADE
+) TABLESPACE pg_default;
+
+create index IF not exists idx_policies_payments_policy_id on public.policies_payments using btree (policy_id) TABLESPACE pg_default;
+
+create index IF not exists idx_policies_payments_installment_id on public.policies_payments using btree (payment_installment_id) TABLESPACE pg_default;
+
+drop trigger if exists update_policy_status_on_installment_change on public.payment_installments;
+
+create trigger update_policy_status_on_installment_change
+after INSERT
+or
+update OF status on public.payment_installments for EACH row
+execute FUNCTION update_policy_status_from_installment ();
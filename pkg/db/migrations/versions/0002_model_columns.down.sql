@@ -0,0 +1,9 @@
+-- Rolling back 0002 leaves the schema as it is, on purpose.
+--
+-- Databases built from the old dump already had these columns and pets.type_id before 0002
+-- ran, and nothing records whether 0002 added them or found them. Dropping them here would
+-- destroy user documents, policy Shopify ids and manual flags of those databases.
+--
+-- The columns are harmless to the older code, 0002 up only adds what is missing and renames
+-- pet_type_id when it is still there, so applying it again is safe. Rolling back 0001 drops
+-- the tables altogether.
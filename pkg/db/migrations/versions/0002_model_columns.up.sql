@@ -0,0 +1,23 @@
+-- Columns the models use that the old dump was missing.
+
+alter table public.users
+  add column if not exists document_type text null,
+  add column if not exists document_number text null;
+
+alter table public.policies
+  add column if not exists shopify_id text null,
+  add column if not exists is_manual boolean not null default true;
+
+-- pets reference their type as type_id, like the Pet model
+DO $$
+BEGIN
+  IF EXISTS (
+    SELECT 1 FROM information_schema.columns
+    WHERE table_schema = 'public' AND table_name = 'pets' AND column_name = 'pet_type_id'
+  ) THEN
+    ALTER TABLE public.pets RENAME COLUMN pet_type_id TO type_id;
+  END IF;
+END;
+$$;
+
+create index IF not exists idx_pets_type_id on public.pets using btree (type_id) TABLESPACE pg_default;
@@ -0,0 +1,6 @@
+drop table if exists public.policy_coverage_eligibilities;
+drop table if exists public.policy_underwriting_decisions;
+drop table if exists public.underwriting_rules;
+drop table if exists public.plan_waiting_periods;
+drop table if exists public.price_discrepancies;
+drop table if exists public.plan_prices;
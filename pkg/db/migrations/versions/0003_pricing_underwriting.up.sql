@@ -0,0 +1,141 @@
+-- Plan prices per pet attributes, waiting periods, underwriting and coverage eligibility.
+
+create table if not exists public.plan_prices (
+  id uuid not null default gen_random_uuid (),
+  plan_id uuid not null,
+  age_range_id uuid not null,
+  size_id uuid null,
+  condition_id uuid not null,
+  monthly_price numeric(10, 2) not null,
+  shopify_variant_id text not null,
+  effective_from timestamp with time zone not null default now(),
+  effective_to timestamp with time zone null,
+  created_at timestamp with time zone not null default now(),
+  constraint plan_prices_pkey primary key (id),
+  constraint plan_prices_plan_id_fkey foreign KEY (plan_id) references plans (id) on delete CASCADE,
+  constraint plan_prices_age_range_id_fkey foreign KEY (age_range_id) references pets_age_ranges (id) on delete RESTRICT,
+  constraint plan_prices_size_id_fkey foreign KEY (size_id) references pets_sizes (id) on delete RESTRICT,
+  constraint plan_prices_condition_id_fkey foreign KEY (condition_id) references pets_conditions (id) on delete RESTRICT,
+  constraint plan_prices_effective_check check (effective_to is null or effective_to > effective_from)
+) TABLESPACE pg_default;
+
+create index IF not exists idx_plan_prices_plan_id on public.plan_prices using btree (plan_id, age_range_id, size_id, condition_id) TABLESPACE pg_default;
+
+create index IF not exists idx_plan_prices_shopify_variant_id on public.plan_prices using btree (shopify_variant_id) TABLESPACE pg_default;
+
+create table if not exists public.price_discrepancies (
+  id uuid not null default gen_random_uuid (),
+  shopify_order_id text not null,
+  shopify_variant_id text not null,
+  plan_price_id uuid null,
+  expected_price numeric(10, 2) null,
+  charged_price numeric(10, 2) not null,
+  reason text not null,
+  created_at timestamp with time zone not null default now(),
+  constraint price_discrepancies_pkey primary key (id),
+  constraint price_discrepancies_plan_price_id_fkey foreign KEY (plan_price_id) references plan_prices (id) on delete SET NULL
+) TABLESPACE pg_default;
+
+create index IF not exists idx_price_discrepancies_shopify_order_id on public.price_discrepancies using btree (shopify_order_id) TABLESPACE pg_default;
+
+create table if not exists public.plan_waiting_periods (
+  id uuid not null default gen_random_uuid (),
+  plan_id uuid not null,
+  coverage_type text not null,
+  days integer not null,
+  created_at timestamp with time zone not null default now(),
+  constraint plan_waiting_periods_pkey primary key (id),
+  constraint plan_waiting_periods_plan_id_coverage_type_key unique (plan_id, coverage_type),
+  constraint plan_waiting_periods_plan_id_fkey foreign KEY (plan_id) references plans (id) on delete CASCADE,
+  constraint plan_waiting_periods_days_check check (days >= 0),
+  constraint plan_waiting_periods_coverage_type_check check (
+    (
+      coverage_type = any (array['accident'::text, 'illness'::text])
+    )
+  )
+) TABLESPACE pg_default;
+
+create table if not exists public.underwriting_rules (
+  id uuid not null default gen_random_uuid (),
+  pet_type_id uuid not null,
+  plan_id uuid null,
+  rule_type text not null,
+  value text not null,
+  outcome text not null,
+  exclusion text null,
+  active boolean not null default true,
+  created_at timestamp with time zone not null default now(),
+  constraint underwriting_rules_pkey primary key (id),
+  constraint underwriting_rules_pet_type_id_fkey foreign KEY (pet_type_id) references pets_types (id) on delete CASCADE,
+  constraint underwriting_rules_plan_id_fkey foreign KEY (plan_id) references plans (id) on delete CASCADE,
+  constraint underwriting_rules_rule_type_check check (
+    (
+      rule_type = any (
+        array[
+          'max_entry_age_months'::text,
+          'excluded_breed'::text,
+          'required_neutered'::text,
+          'max_pets_per_household'::text
+        ]
+      )
+    )
+  ),
+  constraint underwriting_rules_outcome_check check (
+    (
+      outcome = any (
+        array[
+          'accepted_with_exclusions'::text,
+          'manual_review'::text
+        ]
+      )
+    )
+  )
+) TABLESPACE pg_default;
+
+create index IF not exists idx_underwriting_rules_pet_type_id on public.underwriting_rules using btree (pet_type_id, plan_id) TABLESPACE pg_default;
+
+create table if not exists public.policy_underwriting_decisions (
+  id uuid not null default gen_random_uuid (),
+  policy_id uuid not null,
+  decision text not null,
+  reasons jsonb not null default '[]'::jsonb,
+  exclusions jsonb not null default '[]'::jsonb,
+  reviewed_by text null,
+  review_notes text null,
+  reviewed_at timestamp with time zone null,
+  created_at timestamp with time zone not null default now(),
+  constraint policy_underwriting_decisions_pkey primary key (id),
+  constraint policy_underwriting_decisions_policy_id_key unique (policy_id),
+  constraint policy_underwriting_decisions_policy_id_fkey foreign KEY (policy_id) references policies (id) on delete CASCADE,
+  constraint policy_underwriting_decisions_decision_check check (
+    (
+      decision = any (
+        array[
+          'accepted'::text,
+          'accepted_with_exclusions'::text,
+          'manual_review'::text,
+          'rejected'::text
+        ]
+      )
+    )
+  )
+) TABLESPACE pg_default;
+
+create table if not exists public.policy_coverage_eligibilities (
+  id uuid not null default gen_random_uuid (),
+  policy_id uuid not null,
+  coverage_type text not null,
+  waiting_days integer not null,
+  eligible_from date not null,
+  created_at timestamp with time zone not null default now(),
+  updated_at timestamp with time zone not null default now(),
+  constraint policy_coverage_eligibilities_pkey primary key (id),
+  constraint policy_coverage_eligibilities_policy_id_coverage_type_key unique (policy_id, coverage_type),
+  constraint policy_coverage_eligibilities_policy_id_fkey foreign KEY (policy_id) references policies (id) on delete CASCADE
+) TABLESPACE pg_default;
+
+drop trigger if exists update_policy_coverage_eligibilities_updated_at on public.policy_coverage_eligibilities;
+
+create trigger update_policy_coverage_eligibilities_updated_at BEFORE
+update on public.policy_coverage_eligibilities for EACH row
+execute FUNCTION update_updated_at ();
@@ -0,0 +1,5 @@
+drop index if exists public.idx_policies_limit_period_end;
+
+drop table if exists public.policy_limit_periods;
+drop table if exists public.claim_attachments;
+drop table if exists public.claims;
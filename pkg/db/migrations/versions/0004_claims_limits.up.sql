@@ -0,0 +1,88 @@
+-- Claims and the yearly limit periods of the policies.
+
+create table if not exists public.claims (
+  id uuid not null default gen_random_uuid (),
+  policy_id uuid not null,
+  coverage_type text not null,
+  vet_clinic text not null,
+  diagnosis text not null,
+  incident_date date not null,
+  invoice_amount numeric(10, 2) not null,
+  approved_amount numeric(10, 2) null,
+  status text not null default 'submitted'::text,
+  rejection_reason text null,
+  reviewer_notes text null,
+  reviewed_by text null,
+  submitted_at timestamp with time zone not null default now(),
+  reviewed_at timestamp with time zone null,
+  paid_at timestamp with time zone null,
+  created_at timestamp with time zone not null default now(),
+  updated_at timestamp with time zone not null default now(),
+  constraint claims_pkey primary key (id),
+  constraint claims_policy_id_fkey foreign KEY (policy_id) references policies (id) on delete RESTRICT,
+  constraint claims_invoice_amount_check check (invoice_amount > 0),
+  constraint claims_approved_amount_check check (approved_amount is null or approved_amount >= 0),
+  constraint claims_status_check check (
+    (
+      status = any (
+        array[
+          'submitted'::text,
+          'in_review'::text,
+          'approved'::text,
+          'partially_approved'::text,
+          'rejected'::text,
+          'paid'::text
+        ]
+      )
+    )
+  )
+) TABLESPACE pg_default;
+
+create index IF not exists idx_claims_policy_id on public.claims using btree (policy_id) TABLESPACE pg_default;
+
+create index IF not exists idx_claims_status on public.claims using btree (status) TABLESPACE pg_default;
+
+drop trigger if exists update_claims_updated_at on public.claims;
+
+create trigger update_claims_updated_at BEFORE
+update on public.claims for EACH row
+execute FUNCTION update_updated_at ();
+
+create table if not exists public.claim_attachments (
+  id uuid not null default gen_random_uuid (),
+  claim_id uuid not null,
+  file_name text not null,
+  url text not null,
+  content_type text null,
+  created_at timestamp with time zone not null default now(),
+  constraint claim_attachments_pkey primary key (id),
+  constraint claim_attachments_claim_id_fkey foreign KEY (claim_id) references claims (id) on delete CASCADE
+) TABLESPACE pg_default;
+
+create index IF not exists idx_claim_attachments_claim_id on public.claim_attachments using btree (claim_id) TABLESPACE pg_default;
+
+create table if not exists public.policy_limit_periods (
+  id uuid not null default gen_random_uuid (),
+  policy_id uuid not null,
+  plan_id uuid not null,
+  period_start date not null,
+  period_end date not null,
+  annual_limit numeric(10, 2) not null,
+  used_amount numeric(10, 2) not null default 0,
+  closing_balance numeric(10, 2) not null,
+  claims_count integer not null default 0,
+  policy_status text not null,
+  outcome text not null,
+  created_at timestamp with time zone not null default now(),
+  constraint policy_limit_periods_pkey primary key (id),
+  constraint policy_limit_periods_policy_id_period_start_key unique (policy_id, period_start),
+  constraint policy_limit_periods_policy_id_fkey foreign KEY (policy_id) references policies (id) on delete CASCADE,
+  constraint policy_limit_periods_plan_id_fkey foreign KEY (plan_id) references plans (id) on delete RESTRICT,
+  constraint policy_limit_periods_outcome_check check (
+    (
+      outcome = any (array['renewed'::text, 'expired'::text])
+    )
+  )
+) TABLESPACE pg_default;
+
+create index IF not exists idx_policies_limit_period_end on public.policies using btree (limit_period_end) TABLESPACE pg_default;
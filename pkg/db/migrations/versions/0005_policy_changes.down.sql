@@ -0,0 +1,2 @@
+drop table if exists public.policy_plan_changes;
+drop table if exists public.policy_reactivations;
@@ -0,0 +1,73 @@
+-- Policy reactivations and plan changes.
+
+create table if not exists public.policy_reactivations (
+  id uuid not null default gen_random_uuid (),
+  policy_id uuid not null,
+  payment_installment_id uuid not null,
+  cancelled_at timestamp with time zone null,
+  cancellation_reason text null,
+  preexisting_conditions jsonb not null default '[]'::jsonb,
+  kept_start_date boolean not null default false,
+  requested_by text not null,
+  created_at timestamp with time zone not null default now(),
+  constraint policy_reactivations_pkey primary key (id),
+  constraint policy_reactivations_policy_id_fkey foreign KEY (policy_id) references policies (id) on delete CASCADE,
+  constraint policy_reactivations_payment_installment_id_fkey foreign KEY (payment_installment_id) references payment_installments (id) on delete RESTRICT
+) TABLESPACE pg_default;
+
+create index IF not exists idx_policy_reactivations_policy_id on public.policy_reactivations using btree (policy_id) TABLESPACE pg_default;
+
+create table if not exists public.policy_plan_changes (
+  id uuid not null default gen_random_uuid (),
+  policy_id uuid not null,
+  from_plan_id uuid not null,
+  to_plan_id uuid not null,
+  from_variant_id text not null,
+  to_variant_id text not null,
+  from_monthly_price numeric(10, 2) not null,
+  to_monthly_price numeric(10, 2) not null,
+  effective text not null,
+  effective_date date not null,
+  status text not null default 'scheduled'::text,
+  proration_amount numeric(10, 2) not null default 0,
+  proration_installment_id uuid null,
+  balance_before numeric(10, 2) null,
+  balance_after numeric(10, 2) null,
+  requested_by text not null,
+  applied_at timestamp with time zone null,
+  created_at timestamp with time zone not null default now(),
+  updated_at timestamp with time zone not null default now(),
+  constraint policy_plan_changes_pkey primary key (id),
+  constraint policy_plan_changes_policy_id_fkey foreign KEY (policy_id) references policies (id) on delete CASCADE,
+  constraint policy_plan_changes_from_plan_id_fkey foreign KEY (from_plan_id) references plans (id) on delete RESTRICT,
+  constraint policy_plan_changes_to_plan_id_fkey foreign KEY (to_plan_id) references plans (id) on delete RESTRICT,
+  constraint policy_plan_changes_proration_installment_id_fkey foreign KEY (proration_installment_id) references payment_installments (id) on delete set null,
+  constraint policy_plan_changes_effective_check check (
+    (
+      effective = any (array['immediate'::text, 'next_billing'::text])
+    )
+  ),
+  constraint policy_plan_changes_status_check check (
+    (
+      status = any (
+        array[
+          'scheduled'::text,
+          'applied'::text,
+          'cancelled'::text
+        ]
+      )
+    )
+  )
+) TABLESPACE pg_default;
+
+create index IF not exists idx_policy_plan_changes_policy_id on public.policy_plan_changes using btree (policy_id) TABLESPACE pg_default;
+
+create index IF not exists idx_policy_plan_changes_scheduled on public.policy_plan_changes using btree (effective_date) TABLESPACE pg_default
+where
+  status = 'scheduled'::text;
+
+drop trigger if exists update_policy_plan_changes_updated_at on public.policy_plan_changes;
+
+create trigger update_policy_plan_changes_updated_at BEFORE
+update on public.policy_plan_changes for EACH row
+execute FUNCTION update_updated_at ();
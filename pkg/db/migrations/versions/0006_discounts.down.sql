@@ -0,0 +1,2 @@
+drop table if exists public.customer_coupons;
+drop table if exists public.discount_rules;
@@ -0,0 +1,64 @@
+-- Multi-pet discounts and coupons.
+
+create table if not exists public.discount_rules (
+  id uuid not null default gen_random_uuid (),
+  name text not null,
+  rule_type text not null,
+  code text null,
+  min_pets integer null,
+  cycles integer null,
+  percentage numeric(5, 2) not null,
+  active boolean not null default true,
+  created_at timestamp with time zone not null default now(),
+  updated_at timestamp with time zone not null default now(),
+  constraint discount_rules_pkey primary key (id),
+  constraint discount_rules_percentage_check check (percentage > 0 and percentage <= 100),
+  constraint discount_rules_rule_type_check check (
+    (
+      (rule_type = 'multi_pet'::text and min_pets is not null and min_pets > 1)
+      or (rule_type = 'coupon'::text and code is not null and cycles is not null and cycles > 0)
+    )
+  )
+) TABLESPACE pg_default;
+
+create unique index IF not exists idx_discount_rules_code on public.discount_rules using btree (upper(code)) TABLESPACE pg_default
+where
+  code is not null;
+
+drop trigger if exists update_discount_rules_updated_at on public.discount_rules;
+
+create trigger update_discount_rules_updated_at BEFORE
+update on public.discount_rules for EACH row
+execute FUNCTION update_updated_at ();
+
+-- Default multi-pet discount
+INSERT INTO public.discount_rules (name, rule_type, min_pets, percentage)
+SELECT 'Descuento multimascota', 'multi_pet', 3, 10
+WHERE NOT EXISTS (SELECT 1 FROM public.discount_rules WHERE rule_type = 'multi_pet');
+
+create table if not exists public.customer_coupons (
+  id uuid not null default gen_random_uuid (),
+  user_id uuid not null,
+  discount_rule_id uuid not null,
+  code text not null,
+  percentage numeric(5, 2) not null,
+  remaining_cycles integer not null,
+  source_order_id text not null,
+  created_at timestamp with time zone not null default now(),
+  updated_at timestamp with time zone not null default now(),
+  constraint customer_coupons_pkey primary key (id),
+  constraint customer_coupons_user_id_discount_rule_id_key unique (user_id, discount_rule_id),
+  constraint customer_coupons_user_id_fkey foreign KEY (user_id) references users (id) on delete CASCADE,
+  constraint customer_coupons_discount_rule_id_fkey foreign KEY (discount_rule_id) references discount_rules (id) on delete RESTRICT,
+  constraint customer_coupons_remaining_cycles_check check (remaining_cycles >= 0)
+) TABLESPACE pg_default;
+
+create index IF not exists idx_customer_coupons_user_id on public.customer_coupons using btree (user_id) TABLESPACE pg_default
+where
+  remaining_cycles > 0;
+
+drop trigger if exists update_customer_coupons_updated_at on public.customer_coupons;
+
+create trigger update_customer_coupons_updated_at BEFORE
+update on public.customer_coupons for EACH row
+execute FUNCTION update_updated_at ();
@@ -0,0 +1,6 @@
+drop table if exists public.payment_discrepancies;
+drop table if exists public.manual_payments;
+drop table if exists public.exchange_rates;
+drop table if exists public.payment_installment_items;
+
+drop index if exists public.idx_policies_payments_policy_installment;
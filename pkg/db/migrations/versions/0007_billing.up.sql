@@ -0,0 +1,133 @@
+-- Installment line items, exchange rates, manual payments and payment reconciliation.
+
+create index IF not exists idx_policies_payments_policy_installment on public.policies_payments using btree (policy_id, payment_installment_id) TABLESPACE pg_default;
+
+create table if not exists public.payment_installment_items (
+  id uuid not null default gen_random_uuid (),
+  payment_installment_id uuid not null,
+  policy_id uuid null,
+  shopify_line_item_id text not null,
+  shopify_variant_id text null,
+  description text not null,
+  quantity integer not null default 1,
+  unit_price numeric(10, 2) not null,
+  discount_amount numeric(10, 2) not null default 0,
+  tax_amount numeric(10, 2) not null default 0,
+  total numeric(10, 2) not null,
+  created_at timestamp with time zone not null default now(),
+  constraint payment_installment_items_pkey primary key (id),
+  constraint payment_installment_items_payment_installment_id_fkey foreign KEY (payment_installment_id) references payment_installments (id) on delete CASCADE,
+  constraint payment_installment_items_policy_id_fkey foreign KEY (policy_id) references policies (id) on delete set null
+) TABLESPACE pg_default;
+
+create index IF not exists idx_payment_installment_items_installment_id on public.payment_installment_items using btree (payment_installment_id) TABLESPACE pg_default;
+
+create index IF not exists idx_payment_installment_items_policy_id on public.payment_installment_items using btree (policy_id) TABLESPACE pg_default;
+
+create table if not exists public.exchange_rates (
+  id uuid not null default gen_random_uuid (),
+  base_currency text not null,
+  quote_currency text not null,
+  rate numeric(18, 6) not null,
+  effective_date date not null,
+  source text not null,
+  created_by text null,
+  created_at timestamp with time zone not null default now(),
+  constraint exchange_rates_pkey primary key (id),
+  constraint exchange_rates_currencies_date_source_key unique (base_currency, quote_currency, effective_date, source),
+  constraint exchange_rates_rate_check check (rate > 0)
+) TABLESPACE pg_default;
+
+create index IF not exists idx_exchange_rates_effective_date on public.exchange_rates using btree (base_currency, quote_currency, effective_date desc) TABLESPACE pg_default;
+
+create table if not exists public.manual_payments (
+  id uuid not null default gen_random_uuid (),
+  payment_installment_id uuid not null,
+  method text not null,
+  reference text not null,
+  amount numeric(14, 2) not null,
+  currency text not null,
+  paid_on date not null,
+  proof_key text not null,
+  proof_content_type text not null,
+  submitted_by text not null,
+  status text not null default 'pending'::text,
+  rejection_reason text null,
+  reviewed_by text null,
+  reviewed_at timestamp with time zone null,
+  created_at timestamp with time zone not null default now(),
+  updated_at timestamp with time zone not null default now(),
+  constraint manual_payments_pkey primary key (id),
+  constraint manual_payments_payment_installment_id_fkey foreign KEY (payment_installment_id) references payment_installments (id) on delete RESTRICT,
+  constraint manual_payments_amount_check check (amount > 0),
+  constraint manual_payments_method_check check (
+    (
+      method = any (
+        array[
+          'pago_movil'::text,
+          'zelle'::text,
+          'transfer'::text
+        ]
+      )
+    )
+  ),
+  constraint manual_payments_status_check check (
+    (
+      status = any (
+        array[
+          'pending'::text,
+          'approved'::text,
+          'rejected'::text
+        ]
+      )
+    )
+  )
+) TABLESPACE pg_default;
+
+create index IF not exists idx_manual_payments_installment_id on public.manual_payments using btree (payment_installment_id) TABLESPACE pg_default;
+
+create index IF not exists idx_manual_payments_status on public.manual_payments using btree (status) TABLESPACE pg_default;
+
+-- a bank reference can only pay one installment, unless the payment was rejected
+create unique index IF not exists idx_manual_payments_method_reference on public.manual_payments using btree (method, reference) TABLESPACE pg_default
+where
+  (status <> 'rejected'::text);
+
+drop trigger if exists update_manual_payments_updated_at on public.manual_payments;
+
+create trigger update_manual_payments_updated_at BEFORE
+update on public.manual_payments for EACH row
+execute FUNCTION update_updated_at ();
+
+create table if not exists public.payment_discrepancies (
+  id uuid not null default gen_random_uuid (),
+  run_date date not null,
+  kind text not null,
+  payment_installment_id uuid null,
+  shopify_order_id text null,
+  shopify_order_name text null,
+  local_status text null,
+  shopify_status text null,
+  local_amount numeric(14, 2) null,
+  shopify_amount numeric(14, 2) null,
+  currency text null,
+  created_at timestamp with time zone not null default now(),
+  constraint payment_discrepancies_pkey primary key (id),
+  constraint payment_discrepancies_payment_installment_id_fkey foreign KEY (payment_installment_id) references payment_installments (id) on delete CASCADE,
+  constraint payment_discrepancies_kind_check check (
+    (
+      kind = any (
+        array[
+          'amount_mismatch'::text,
+          'status_mismatch'::text,
+          'order_missing_locally'::text,
+          'installment_without_order'::text
+        ]
+      )
+    )
+  )
+) TABLESPACE pg_default;
+
+create index IF not exists idx_payment_discrepancies_run_date on public.payment_discrepancies using btree (run_date) TABLESPACE pg_default;
+
+create index IF not exists idx_payment_discrepancies_installment_id on public.payment_discrepancies using btree (payment_installment_id) TABLESPACE pg_default;
@@ -0,0 +1,14 @@
+-- Shopify webhooks as received, so they can be replayed.
+
+create table if not exists public.webhook_events (
+  id uuid not null default gen_random_uuid (),
+  topic text not null,
+  shopify_webhook_id text null,
+  shopify_order_id text null,
+  payload jsonb not null,
+  received_at timestamp with time zone not null default now(),
+  replayed_at timestamp with time zone null,
+  constraint webhook_events_pkey primary key (id)
+) TABLESPACE pg_default;
+
+create index IF not exists idx_webhook_events_shopify_order_id on public.webhook_events using btree (shopify_order_id) TABLESPACE pg_default;
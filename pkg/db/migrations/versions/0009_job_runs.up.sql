@@ -0,0 +1,10 @@
+-- Runs of the scheduled jobs, one per job and scheduled time across every instance.
+
+create table if not exists public.job_runs (
+  job text not null,
+  scheduled_at timestamp with time zone not null,
+  instance text not null,
+  started_at timestamp with time zone not null default now(),
+  finished_at timestamp with time zone null,
+  constraint job_runs_pkey primary key (job, scheduled_at)
+) TABLESPACE pg_default;
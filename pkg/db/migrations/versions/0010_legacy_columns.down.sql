@@ -0,0 +1,5 @@
+-- Rolling back 0010 leaves the schema as it is, on purpose.
+--
+-- On databases created by 0001 these columns and constraints belong to 0001, dropping them
+-- here would break the base schema. 0010 up only adds what is missing, so applying it again
+-- is safe. Amounts rounded to two decimals are not restored.
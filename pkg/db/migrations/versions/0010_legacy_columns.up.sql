@@ -0,0 +1,122 @@
+-- Columns the models gained after the old schemas.sql dump. 0001 skips the tables a database
+-- restored from the dump already has, so their new columns and constraints are added here,
+-- the same as 0001 creates them on new databases.
+
+alter table public.pets_age_ranges
+  add column if not exists shopify_id text null,
+  add column if not exists min_age_months integer null,
+  add column if not exists max_age_months integer null;
+CREATE INDEX IF not exists idx_pets_age_ranges_shopify_id on public.pets_age_ranges using btree (shopify_id) TABLESPACE pg_default;
+
+alter table public.pets_sizes
+  add column if not exists shopify_id text null;
+CREATE INDEX IF not exists idx_pets_sizes_shopify_id on public.pets_sizes using btree (shopify_id) TABLESPACE pg_default;
+
+alter table public.pets_conditions
+  add column if not exists shopify_id text null,
+  add column if not exists gender text null,
+  add column if not exists neutered boolean not null default false;
+CREATE INDEX IF not exists idx_pets_conditions_shopify_id on public.pets_conditions using btree (shopify_id) TABLESPACE pg_default;
+
+alter table public.pets
+  add column if not exists birthday date null,
+  add column if not exists neutered boolean null;
+
+alter table public.policies
+  add column if not exists cancellation_reason text null,
+  add column if not exists cancellation_notes text null,
+  add column if not exists cancellation_requested_at timestamp with time zone null,
+  add column if not exists cancellation_effective_date date null,
+  add column if not exists cancelled_at timestamp with time zone null;
+
+-- the dump's status check predates manual review
+alter table public.policies
+  drop constraint if exists policies_status_check,
+  add constraint policies_status_check check (
+    (
+      status = any (
+        array[
+          'active'::text,
+          'payment_pending'::text,
+          'cancelled'::text,
+          'pending_cancellation'::text,
+          'pending_review'::text
+        ]
+      )
+    )
+  ),
+  drop constraint if exists policies_cancellation_reason_check,
+  add constraint policies_cancellation_reason_check check (
+    (
+      cancellation_reason is null
+      or cancellation_reason = any (
+        array[
+          'customer_request'::text,
+          'non_payment'::text,
+          'pet_deceased'::text,
+          'fraud'::text,
+          'duplicate'::text,
+          'other'::text
+        ]
+      )
+    )
+  );
+
+create index IF not exists idx_policies_cancellation_effective_date on public.policies using btree (cancellation_effective_date) TABLESPACE pg_default
+where
+  status = 'pending_cancellation'::text;
+
+-- the dump stored amounts as unbounded numerics, amounts are read with two decimals
+alter table public.payment_installments
+  alter column amount type numeric(14, 2) using round(amount, 2),
+  add column if not exists currency text not null default 'USD'::text,
+  add column if not exists presentment_amount numeric(14, 2) null,
+  add column if not exists presentment_currency text null,
+  add column if not exists exchange_rate numeric(18, 6) null,
+  add column if not exists exchange_rate_source text null,
+  add column if not exists exchange_rate_date date null,
+  add column if not exists shopify_order_name text null,
+  add column if not exists discount_amount numeric(10, 2) not null default 0,
+  add column if not exists discount_code text null;
+
+-- The seeds only insert missing attributes, the ones the dump inserted get the values the
+-- seeds give new databases. Values already set, by the seeds or the catalog sync, are kept.
+UPDATE public.pets_age_ranges
+SET min_age_months = seed.min_age_months,
+    max_age_months = seed.max_age_months
+FROM (VALUES
+  ('feline', LOWER('Cachorro (5m-1año)'), 5, 12),
+  ('feline', LOWER('Joven (1-6años)'), 12, 72),
+  ('feline', LOWER('Adulto (6-10años)'), 72, 120),
+  ('feline', LOWER('Senior (10-15+años)'), 120, null),
+
+  ('canine', LOWER('Cachorro (5m-1año)'), 5, 12),
+  ('canine', LOWER('Joven (1-5años)'), 12, 60),
+  ('canine', LOWER('Adulto (5-7años)'), 60, 84),
+  ('canine', LOWER('Senior (7-15+años)'), 84, null)
+) AS seed (pet_type, name, min_age_months, max_age_months),
+  public.pets_types
+WHERE pets_types.name = seed.pet_type
+  AND pets_age_ranges.pet_type_id = pets_types.id
+  AND pets_age_ranges.name = seed.name
+  AND pets_age_ranges.min_age_months IS NULL;
+
+UPDATE public.pets_conditions
+SET gender = seed.gender,
+    neutered = seed.neutered
+FROM (VALUES
+  ('canine', LOWER('Macho Castrado'), 'male', true),
+  ('canine', LOWER('Hembra Esterilizada'), 'female', true),
+  ('canine', LOWER('Macho NO Castrado'), 'male', false),
+  ('canine', LOWER('Hembra NO Esterilizada'), 'female', false),
+
+  ('feline', LOWER('Macho Castrado'), 'male', true),
+  ('feline', LOWER('Hembra Esterilizada'), 'female', true),
+  ('feline', LOWER('Macho NO Castrado'), 'male', false),
+  ('feline', LOWER('Hembra NO Esterilizada'), 'female', false)
+) AS seed (pet_type, name, gender, neutered),
+  public.pets_types
+WHERE pets_types.name = seed.pet_type
+  AND pets_conditions.pet_type_id = pets_types.id
+  AND pets_conditions.name = seed.name
+  AND pets_conditions.gender IS NULL;
@@ -0,0 +1,4 @@
+drop index if exists public.idx_manual_payments_order_unmarked;
+
+alter table public.manual_payments
+  drop column if exists order_marked_paid_at;
@@ -0,0 +1,14 @@
+-- Approved manual payments whose Shopify order is not marked paid yet. The order is marked
+-- after the approval commits, a failure is retried by the payment reconciliation.
+
+alter table public.manual_payments
+  add column if not exists order_marked_paid_at timestamp with time zone null;
+
+-- approvals so far marked the order inside their transaction
+update public.manual_payments
+set order_marked_paid_at = reviewed_at
+where status = 'approved' and order_marked_paid_at is null;
+
+create index IF not exists idx_manual_payments_order_unmarked on public.manual_payments using btree (reviewed_at) TABLESPACE pg_default
+where
+  status = 'approved'::text and order_marked_paid_at is null;
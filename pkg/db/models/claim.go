@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"appa_subscriptions/pkg/money"
+)
+
+// Claim review states
+const (
+	ClaimSubmitted         = "submitted"
+	ClaimInReview          = "in_review"
+	ClaimApproved          = "approved"
+	ClaimPartiallyApproved = "partially_approved"
+	ClaimRejected          = "rejected"
+	ClaimPaid              = "paid"
+)
+
+type Claim struct {
+	ID              string            `gorm:"primaryKey;column:id;default:gen_random_uuid()" json:"id"`
+	PolicyID        string            `gorm:"column:policy_id" json:"policyID"`
+	CoverageType    string            `gorm:"column:coverage_type" json:"coverageType"`
+	VetClinic       string            `gorm:"column:vet_clinic" json:"vetClinic"`
+	Diagnosis       string            `gorm:"column:diagnosis" json:"diagnosis"`
+	IncidentDate    time.Time         `gorm:"column:incident_date;type:date" json:"incidentDate"`
+	InvoiceAmount   money.Amount      `gorm:"column:invoice_amount" json:"invoiceAmount"`
+	ApprovedAmount  *money.Amount     `gorm:"column:approved_amount" json:"approvedAmount,omitempty"`
+	Status          string            `gorm:"column:status;default:'submitted'" json:"status"`
+	RejectionReason *string           `gorm:"column:rejection_reason" json:"rejectionReason,omitempty"`
+	ReviewerNotes   *string           `gorm:"column:reviewer_notes" json:"reviewerNotes,omitempty"`
+	ReviewedBy      *string           `gorm:"column:reviewed_by" json:"reviewedBy,omitempty"`
+	SubmittedAt     time.Time         `gorm:"column:submitted_at" json:"submittedAt"`
+	ReviewedAt      *time.Time        `gorm:"column:reviewed_at" json:"reviewedAt,omitempty"`
+	PaidAt          *time.Time        `gorm:"column:paid_at" json:"paidAt,omitempty"`
+	CreatedAt       time.Time         `gorm:"column:created_at;autoCreateTime" json:"createdAt"`
+	UpdatedAt       time.Time         `gorm:"column:updated_at;autoUpdateTime" json:"updatedAt"`
+	Policy          *Policy           `gorm:"foreignKey:PolicyID;references:ID" json:"policy,omitempty"`
+	Attachments     []ClaimAttachment `gorm:"foreignKey:ClaimID;references:ID" json:"attachments,omitempty"`
+}
+
+func (Claim) TableName() string {
+	return "claims"
+}
+
+type ClaimAttachment struct {
+	ID          string    `gorm:"primaryKey;column:id;default:gen_random_uuid()" json:"id"`
+	ClaimID     string    `gorm:"column:claim_id" json:"claimID"`
+	FileName    string    `gorm:"column:file_name" json:"fileName"`
+	URL         string    `gorm:"column:url" json:"url"`
+	ContentType *string   `gorm:"column:content_type" json:"contentType,omitempty"`
+	CreatedAt   time.Time `gorm:"column:created_at;autoCreateTime" json:"createdAt"`
+}
+
+func (ClaimAttachment) TableName() string {
+	return "claim_attachments"
+}
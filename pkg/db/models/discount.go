@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// Discount rule types
+const (
+	// DiscountRuleMultiPet discounts the recurring orders of households with at least MinPets policies
+	DiscountRuleMultiPet = "multi_pet"
+	// DiscountRuleCoupon turns a code used on the first order into a coupon for the next Cycles orders
+	DiscountRuleCoupon = "coupon"
+)
+
+type DiscountRule struct {
+	ID         string    `gorm:"primaryKey;column:id;default:gen_random_uuid()" json:"id"`
+	Name       string    `gorm:"column:name" json:"name"`
+	RuleType   string    `gorm:"column:rule_type" json:"ruleType"`
+	Code       *string   `gorm:"column:code" json:"code,omitempty"`
+	MinPets    *int      `gorm:"column:min_pets" json:"minPets,omitempty"`
+	Cycles     *int      `gorm:"column:cycles" json:"cycles,omitempty"`
+	Percentage float64   `gorm:"column:percentage" json:"percentage"`
+	Active     bool      `gorm:"column:active;default:true" json:"active"`
+	CreatedAt  time.Time `gorm:"column:created_at;autoCreateTime" json:"createdAt"`
+	UpdatedAt  time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updatedAt"`
+}
+
+func (DiscountRule) TableName() string {
+	return "discount_rules"
+}
+
+// CustomerCoupon is a coupon rule won by a user, applied to their next RemainingCycles recurring orders
+type CustomerCoupon struct {
+	ID              string    `gorm:"primaryKey;column:id;default:gen_random_uuid()" json:"id"`
+	UserID          string    `gorm:"column:user_id" json:"userID"`
+	DiscountRuleID  string    `gorm:"column:discount_rule_id" json:"discountRuleID"`
+	Code            string    `gorm:"column:code" json:"code"`
+	Percentage      float64   `gorm:"column:percentage" json:"percentage"`
+	RemainingCycles int       `gorm:"column:remaining_cycles" json:"remainingCycles"`
+	SourceOrderID   string    `gorm:"column:source_order_id" json:"sourceOrderID"`
+	CreatedAt       time.Time `gorm:"column:created_at;autoCreateTime" json:"createdAt"`
+	UpdatedAt       time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updatedAt"`
+}
+
+func (CustomerCoupon) TableName() string {
+	return "customer_coupons"
+}
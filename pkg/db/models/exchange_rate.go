@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// ExchangeRateManualSource identifies the rates entered by staff
+const ExchangeRateManualSource = "manual"
+
+// ExchangeRate is the official price of one unit of BaseCurrency in QuoteCurrency from EffectiveDate on
+type ExchangeRate struct {
+	ID            string    `gorm:"primaryKey;column:id;default:gen_random_uuid()" json:"id"`
+	BaseCurrency  string    `gorm:"column:base_currency" json:"baseCurrency"`
+	QuoteCurrency string    `gorm:"column:quote_currency" json:"quoteCurrency"`
+	Rate          float64   `gorm:"column:rate" json:"rate"`
+	EffectiveDate time.Time `gorm:"column:effective_date;type:date" json:"effectiveDate"`
+	Source        string    `gorm:"column:source" json:"source"`
+	CreatedBy     *string   `gorm:"column:created_by" json:"createdBy,omitempty"`
+	CreatedAt     time.Time `gorm:"column:created_at;autoCreateTime" json:"createdAt"`
+}
+
+func (ExchangeRate) TableName() string {
+	return "exchange_rates"
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// JobRun is a run of a scheduled job, claimed by the instance that executes it
+type JobRun struct {
+	Job         string     `gorm:"primaryKey;column:job" json:"job"`
+	ScheduledAt time.Time  `gorm:"primaryKey;column:scheduled_at" json:"scheduledAt"`
+	Instance    string     `gorm:"column:instance" json:"instance"`
+	StartedAt   time.Time  `gorm:"column:started_at;autoCreateTime" json:"startedAt"`
+	FinishedAt  *time.Time `gorm:"column:finished_at" json:"finishedAt,omitempty"`
+}
+
+func (JobRun) TableName() string {
+	return "job_runs"
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"appa_subscriptions/pkg/money"
+)
+
+// Payment methods settled outside Shopify checkout
+const (
+	ManualPaymentPagoMovil = "pago_movil"
+	ManualPaymentZelle     = "zelle"
+	ManualPaymentTransfer  = "transfer"
+)
+
+// Manual payment review states
+const (
+	ManualPaymentPending  = "pending"
+	ManualPaymentApproved = "approved"
+	ManualPaymentRejected = "rejected"
+)
+
+// ManualPayment is a payment made outside Shopify checkout, reported with a proof for staff review
+type ManualPayment struct {
+	ID                   string              `gorm:"primaryKey;column:id;default:gen_random_uuid()" json:"id"`
+	PaymentInstallmentID string              `gorm:"column:payment_installment_id" json:"paymentInstallmentId"`
+	Method               string              `gorm:"column:method" json:"method"`
+	Reference            string              `gorm:"column:reference" json:"reference"`
+	Amount               money.Amount        `gorm:"column:amount" json:"amount"`
+	Currency             string              `gorm:"column:currency" json:"currency"`
+	PaidOn               time.Time           `gorm:"column:paid_on;type:date" json:"paidOn"`
+	ProofKey             string              `gorm:"column:proof_key" json:"-"`
+	ProofContentType     string              `gorm:"column:proof_content_type" json:"proofContentType"`
+	SubmittedBy          string              `gorm:"column:submitted_by" json:"submittedBy"`
+	Status               string              `gorm:"column:status;default:'pending'" json:"status"`
+	RejectionReason      *string             `gorm:"column:rejection_reason" json:"rejectionReason,omitempty"`
+	ReviewedBy           *string             `gorm:"column:reviewed_by" json:"reviewedBy,omitempty"`
+	ReviewedAt           *time.Time          `gorm:"column:reviewed_at" json:"reviewedAt,omitempty"`
+	OrderMarkedPaidAt    *time.Time          `gorm:"column:order_marked_paid_at" json:"orderMarkedPaidAt,omitempty"`
+	CreatedAt            time.Time           `gorm:"column:created_at;autoCreateTime" json:"createdAt"`
+	UpdatedAt            time.Time           `gorm:"column:updated_at;autoUpdateTime" json:"updatedAt"`
+	PaymentInstallment   *PaymentInstallment `gorm:"foreignKey:PaymentInstallmentID;references:ID" json:"paymentInstallment,omitempty"`
+}
+
+func (ManualPayment) TableName() string {
+	return "manual_payments"
+}
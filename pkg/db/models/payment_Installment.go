@@ -1,18 +1,43 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"appa_subscriptions/pkg/money"
+)
+
+// Installment states, an installment is open until it is paid or cancelled
+const (
+	InstallmentPending   = "pending"
+	InstallmentPaid      = "paid"
+	InstallmentOverdue   = "overdue"
+	InstallmentCancelled = "cancelled"
+)
+
+// OpenInstallmentStatuses are the installment states that still expect a payment
+var OpenInstallmentStatuses = []string{InstallmentPending, InstallmentOverdue}
 
 type PaymentInstallment struct {
-	ID                 string     `json:"id" gorm:"column:id;default:gen_random_uuid()"`
-	InstallmentNumber  int        `json:"installmentNumber" gorm:"column:installment_number"`
-	DueDate            time.Time  `json:"dueDate" gorm:"column:due_date"`
-	Amount             float64    `json:"amount" gorm:"column:amount"`
-	Status             string     `json:"status" gorm:"column:status;default:'pending'"`
-	ShopifyOrderID     string     `json:"shopifyOrderId" gorm:"column:shopify_order_id"`
-	ShopifyCheckoutURL string     `json:"shopifyCheckoutUrl" gorm:"column:shopify_checkout_url"`
-	PaidAt             *time.Time `json:"paidAt,omitempty" gorm:"column:paid_at"`
-	CreatedAt          time.Time  `json:"createdAt" gorm:"column:created_at;default:now()"`
-	UpdatedAt          time.Time  `json:"updatedAt" gorm:"column:updated_at;default:now()"`
+	ID                  string                   `json:"id" gorm:"column:id;default:gen_random_uuid()"`
+	InstallmentNumber   int                      `json:"installmentNumber" gorm:"column:installment_number"`
+	DueDate             time.Time                `json:"dueDate" gorm:"column:due_date"`
+	Amount              money.Amount             `json:"amount" gorm:"column:amount"`
+	Status              string                   `json:"status" gorm:"column:status;default:'pending'"`
+	ShopifyOrderID      string                   `json:"shopifyOrderId" gorm:"column:shopify_order_id"`
+	ShopifyOrderName    string                   `json:"shopifyOrderName" gorm:"column:shopify_order_name"`
+	ShopifyCheckoutURL  string                   `json:"shopifyCheckoutUrl" gorm:"column:shopify_checkout_url"`
+	PaidAt              *time.Time               `json:"paidAt,omitempty" gorm:"column:paid_at"`
+	DiscountAmount      money.Amount             `json:"discountAmount" gorm:"column:discount_amount;default:0"`
+	DiscountCode        *string                  `json:"discountCode,omitempty" gorm:"column:discount_code"`
+	Currency            string                   `json:"currency" gorm:"column:currency;default:'USD'"`
+	PresentmentAmount   *money.Amount            `json:"presentmentAmount,omitempty" gorm:"column:presentment_amount"`
+	PresentmentCurrency *string                  `json:"presentmentCurrency,omitempty" gorm:"column:presentment_currency"`
+	ExchangeRate        *float64                 `json:"exchangeRate,omitempty" gorm:"column:exchange_rate"`
+	ExchangeRateSource  *string                  `json:"exchangeRateSource,omitempty" gorm:"column:exchange_rate_source"`
+	ExchangeRateDate    *time.Time               `json:"exchangeRateDate,omitempty" gorm:"column:exchange_rate_date"`
+	CreatedAt           time.Time                `json:"createdAt" gorm:"column:created_at;default:now()"`
+	UpdatedAt           time.Time                `json:"updatedAt" gorm:"column:updated_at;default:now()"`
+	Items               []PaymentInstallmentItem `json:"items,omitempty" gorm:"foreignKey:PaymentInstallmentID;references:ID"`
 }
 
 func (PaymentInstallment) TableName() string {
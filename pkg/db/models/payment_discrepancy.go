@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"appa_subscriptions/pkg/money"
+)
+
+// Payment discrepancy kinds found by the reconciliation with Shopify
+const (
+	DiscrepancyAmountMismatch          = "amount_mismatch"
+	DiscrepancyStatusMismatch          = "status_mismatch"
+	DiscrepancyOrderMissingLocally     = "order_missing_locally"
+	DiscrepancyInstallmentWithoutOrder = "installment_without_order"
+)
+
+// PaymentDiscrepancy is a difference between an installment and its Shopify order that the
+// nightly reconciliation could not fix on its own
+type PaymentDiscrepancy struct {
+	ID                   string        `gorm:"primaryKey;column:id;default:gen_random_uuid()" json:"id"`
+	RunDate              time.Time     `gorm:"column:run_date" json:"runDate"`
+	Kind                 string        `gorm:"column:kind" json:"kind"`
+	PaymentInstallmentID *string       `gorm:"column:payment_installment_id" json:"paymentInstallmentId,omitempty"`
+	ShopifyOrderID       *string       `gorm:"column:shopify_order_id" json:"shopifyOrderId,omitempty"`
+	ShopifyOrderName     *string       `gorm:"column:shopify_order_name" json:"shopifyOrderName,omitempty"`
+	LocalStatus          *string       `gorm:"column:local_status" json:"localStatus,omitempty"`
+	ShopifyStatus        *string       `gorm:"column:shopify_status" json:"shopifyStatus,omitempty"`
+	LocalAmount          *money.Amount `gorm:"column:local_amount" json:"localAmount,omitempty"`
+	ShopifyAmount        *money.Amount `gorm:"column:shopify_amount" json:"shopifyAmount,omitempty"`
+	Currency             *string       `gorm:"column:currency" json:"currency,omitempty"`
+	CreatedAt            time.Time     `gorm:"column:created_at;autoCreateTime" json:"createdAt"`
+}
+
+func (PaymentDiscrepancy) TableName() string {
+	return "payment_discrepancies"
+}
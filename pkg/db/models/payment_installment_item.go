@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"appa_subscriptions/pkg/money"
+)
+
+// PaymentInstallmentItem is the part of an installment billed for a policy, or for an
+// adjustment such as a plan change proration when PolicyID is nil
+type PaymentInstallmentItem struct {
+	ID                   string       `json:"id" gorm:"column:id;default:gen_random_uuid()"`
+	PaymentInstallmentID string       `json:"paymentInstallmentId" gorm:"column:payment_installment_id"`
+	PolicyID             *string      `json:"policyId,omitempty" gorm:"column:policy_id"`
+	ShopifyLineItemID    string       `json:"shopifyLineItemId" gorm:"column:shopify_line_item_id"`
+	ShopifyVariantID     *string      `json:"shopifyVariantId,omitempty" gorm:"column:shopify_variant_id"`
+	Description          string       `json:"description" gorm:"column:description"`
+	Quantity             int          `json:"quantity" gorm:"column:quantity"`
+	UnitPrice            money.Amount `json:"unitPrice" gorm:"column:unit_price"`
+	DiscountAmount       money.Amount `json:"discountAmount" gorm:"column:discount_amount"`
+	TaxAmount            money.Amount `json:"taxAmount" gorm:"column:tax_amount"`
+	Total                money.Amount `json:"total" gorm:"column:total"`
+	CreatedAt            time.Time    `json:"createdAt" gorm:"column:created_at;autoCreateTime"`
+}
+
+func (PaymentInstallmentItem) TableName() string {
+	return "payment_installment_items"
+}
@@ -10,6 +10,8 @@ type Pet struct {
 	Gender      string        `gorm:"column:gender" json:"gender"`
 	Weight      float64       `gorm:"column:weight" json:"weight"`
 	MicrochipID string        `gorm:"column:microchip_id" json:"microchipID"`
+	Birthday    *time.Time    `gorm:"column:birthday;type:date" json:"birthday,omitempty"`
+	Neutered    *bool         `gorm:"column:neutered" json:"neutered,omitempty"`
 	AgeRangeID  string        `gorm:"column:age_range_id" json:"ageRangeID"`
 	ConditionID string        `gorm:"column:condition_id" json:"conditionID"`
 	SizeID      string        `gorm:"column:size_id" json:"sizeID"`
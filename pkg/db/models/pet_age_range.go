@@ -1,9 +1,12 @@
 package models
 
 type PetAgeRange struct {
-	ID        string `gorm:"primaryKey;column:id;default:gen_random_uuid()" json:"id"`
-	Name      string `gorm:"column:name" json:"name"`
-	PetTypeID string `gorm:"column:pet_type_id" json:"petTypeID"`
+	ID           string `gorm:"primaryKey;column:id;default:gen_random_uuid()" json:"id"`
+	Name         string `gorm:"column:name" json:"name"`
+	PetTypeID    string `gorm:"column:pet_type_id" json:"petTypeID"`
+	ShopifyID    string `gorm:"column:shopify_id" json:"shopifyID"`
+	MinAgeMonths *int   `gorm:"column:min_age_months" json:"minAgeMonths,omitempty"`
+	MaxAgeMonths *int   `gorm:"column:max_age_months" json:"maxAgeMonths,omitempty"`
 }
 
 func (PetAgeRange) TableName() string {
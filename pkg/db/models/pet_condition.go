@@ -1,9 +1,12 @@
 package models
 
 type PetCondition struct {
-	ID        string `gorm:"primaryKey;column:id" json:"id"`
-	Name      string `gorm:"column:name" json:"name"`
-	PetTypeID string `gorm:"column:pet_type_id" json:"petTypeID"`
+	ID        string  `gorm:"primaryKey;column:id" json:"id"`
+	Name      string  `gorm:"column:name" json:"name"`
+	PetTypeID string  `gorm:"column:pet_type_id" json:"petTypeID"`
+	ShopifyID string  `gorm:"column:shopify_id" json:"shopifyID"`
+	Gender    *string `gorm:"column:gender" json:"gender,omitempty"`
+	Neutered  bool    `gorm:"column:neutered" json:"neutered"`
 }
 
 func (PetCondition) TableName() string {
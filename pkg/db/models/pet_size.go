@@ -4,6 +4,7 @@ type PetSize struct {
 	ID        string `gorm:"primaryKey;column:id;default:gen_random_uuid()" json:"id"`
 	Name      string `gorm:"column:name" json:"name"`
 	PetTypeID string `gorm:"column:pet_type_id" json:"petTypeID"`
+	ShopifyID string `gorm:"column:shopify_id" json:"shopifyID"`
 }
 
 func (PetSize) TableName() string {
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"appa_subscriptions/pkg/money"
+)
+
+type PlanPrice struct {
+	ID               string        `gorm:"primaryKey;column:id;default:gen_random_uuid()" json:"id"`
+	PlanID           string        `gorm:"column:plan_id" json:"planID"`
+	AgeRangeID       string        `gorm:"column:age_range_id" json:"ageRangeID"`
+	SizeID           *string       `gorm:"column:size_id" json:"sizeID,omitempty"`
+	ConditionID      string        `gorm:"column:condition_id" json:"conditionID"`
+	MonthlyPrice     money.Amount  `gorm:"column:monthly_price" json:"monthlyPrice"`
+	ShopifyVariantID string        `gorm:"column:shopify_variant_id" json:"shopifyVariantID"`
+	EffectiveFrom    time.Time     `gorm:"column:effective_from" json:"effectiveFrom"`
+	EffectiveTo      *time.Time    `gorm:"column:effective_to" json:"effectiveTo,omitempty"`
+	CreatedAt        time.Time     `gorm:"column:created_at;autoCreateTime" json:"createdAt"`
+	Plan             *Plan         `gorm:"foreignKey:PlanID;references:ID" json:"plan,omitempty"`
+	AgeRange         *PetAgeRange  `gorm:"foreignKey:AgeRangeID;references:ID" json:"ageRange,omitempty"`
+	Size             *PetSize      `gorm:"foreignKey:SizeID;references:ID" json:"size,omitempty"`
+	Condition        *PetCondition `gorm:"foreignKey:ConditionID;references:ID" json:"condition,omitempty"`
+}
+
+func (PlanPrice) TableName() string {
+	return "plan_prices"
+}
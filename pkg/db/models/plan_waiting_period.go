@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Coverage types with their own waiting period
+const (
+	CoverageAccident = "accident"
+	CoverageIllness  = "illness"
+)
+
+type PlanWaitingPeriod struct {
+	ID           string    `gorm:"primaryKey;column:id;default:gen_random_uuid()" json:"id"`
+	PlanID       string    `gorm:"column:plan_id" json:"planID"`
+	CoverageType string    `gorm:"column:coverage_type" json:"coverageType"`
+	Days         int       `gorm:"column:days" json:"days"`
+	CreatedAt    time.Time `gorm:"column:created_at;autoCreateTime" json:"createdAt"`
+}
+
+func (PlanWaitingPeriod) TableName() string {
+	return "plan_waiting_periods"
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+type PolicyCoverageEligibility struct {
+	ID           string    `gorm:"primaryKey;column:id;default:gen_random_uuid()" json:"id"`
+	PolicyID     string    `gorm:"column:policy_id" json:"policyID"`
+	CoverageType string    `gorm:"column:coverage_type" json:"coverageType"`
+	WaitingDays  int       `gorm:"column:waiting_days" json:"waitingDays"`
+	EligibleFrom time.Time `gorm:"column:eligible_from;type:date" json:"eligibleFrom"`
+	CreatedAt    time.Time `gorm:"column:created_at;autoCreateTime" json:"createdAt"`
+	UpdatedAt    time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updatedAt"`
+}
+
+func (PolicyCoverageEligibility) TableName() string {
+	return "policy_coverage_eligibilities"
+}
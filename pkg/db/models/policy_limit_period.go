@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"appa_subscriptions/pkg/money"
+)
+
+// Outcomes of a closed limit period
+const (
+	LimitPeriodRenewed = "renewed"
+	LimitPeriodExpired = "expired"
+)
+
+// PolicyLimitPeriod is the snapshot of a closed annual limit period of a policy
+type PolicyLimitPeriod struct {
+	ID             string       `gorm:"primaryKey;column:id;default:gen_random_uuid()" json:"id"`
+	PolicyID       string       `gorm:"column:policy_id" json:"policyID"`
+	PlanID         string       `gorm:"column:plan_id" json:"planID"`
+	PeriodStart    time.Time    `gorm:"column:period_start;type:date" json:"periodStart"`
+	PeriodEnd      time.Time    `gorm:"column:period_end;type:date" json:"periodEnd"`
+	AnnualLimit    money.Amount `gorm:"column:annual_limit" json:"annualLimit"`
+	UsedAmount     money.Amount `gorm:"column:used_amount" json:"usedAmount"`
+	ClosingBalance money.Amount `gorm:"column:closing_balance" json:"closingBalance"`
+	ClaimsCount    int          `gorm:"column:claims_count" json:"claimsCount"`
+	PolicyStatus   string       `gorm:"column:policy_status" json:"policyStatus"`
+	Outcome        string       `gorm:"column:outcome" json:"outcome"`
+	CreatedAt      time.Time    `gorm:"column:created_at;autoCreateTime" json:"createdAt"`
+}
+
+func (PolicyLimitPeriod) TableName() string {
+	return "policy_limit_periods"
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"appa_subscriptions/pkg/money"
+)
+
+// Plan change effective dates
+const (
+	PlanChangeImmediate   = "immediate"
+	PlanChangeNextBilling = "next_billing"
+)
+
+// Plan change states
+const (
+	PlanChangeScheduled = "scheduled"
+	PlanChangeApplied   = "applied"
+	PlanChangeCancelled = "cancelled"
+)
+
+// PolicyPlanChange is a plan change of a policy. ProrationAmount is charged, or credited when
+// negative, on the next installment and ProrationInstallmentID records which one.
+type PolicyPlanChange struct {
+	ID                     string        `gorm:"primaryKey;column:id;default:gen_random_uuid()" json:"id"`
+	PolicyID               string        `gorm:"column:policy_id" json:"policyID"`
+	FromPlanID             string        `gorm:"column:from_plan_id" json:"fromPlanID"`
+	ToPlanID               string        `gorm:"column:to_plan_id" json:"toPlanID"`
+	FromVariantID          string        `gorm:"column:from_variant_id" json:"fromVariantID"`
+	ToVariantID            string        `gorm:"column:to_variant_id" json:"toVariantID"`
+	FromMonthlyPrice       money.Amount  `gorm:"column:from_monthly_price" json:"fromMonthlyPrice"`
+	ToMonthlyPrice         money.Amount  `gorm:"column:to_monthly_price" json:"toMonthlyPrice"`
+	Effective              string        `gorm:"column:effective" json:"effective"`
+	EffectiveDate          time.Time     `gorm:"column:effective_date;type:date" json:"effectiveDate"`
+	Status                 string        `gorm:"column:status" json:"status"`
+	ProrationAmount        money.Amount  `gorm:"column:proration_amount" json:"prorationAmount"`
+	ProrationInstallmentID *string       `gorm:"column:proration_installment_id" json:"prorationInstallmentID,omitempty"`
+	BalanceBefore          *money.Amount `gorm:"column:balance_before" json:"balanceBefore,omitempty"`
+	BalanceAfter           *money.Amount `gorm:"column:balance_after" json:"balanceAfter,omitempty"`
+	RequestedBy            string        `gorm:"column:requested_by" json:"requestedBy"`
+	AppliedAt              *time.Time    `gorm:"column:applied_at" json:"appliedAt,omitempty"`
+	CreatedAt              time.Time     `gorm:"column:created_at;autoCreateTime" json:"createdAt"`
+	UpdatedAt              time.Time     `gorm:"column:updated_at;autoUpdateTime" json:"updatedAt"`
+}
+
+func (PolicyPlanChange) TableName() string {
+	return "policy_plan_changes"
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// PolicyReactivation records the reactivation of a cancelled policy
+type PolicyReactivation struct {
+	ID                    string     `gorm:"primaryKey;column:id;default:gen_random_uuid()" json:"id"`
+	PolicyID              string     `gorm:"column:policy_id" json:"policyID"`
+	PaymentInstallmentID  string     `gorm:"column:payment_installment_id" json:"paymentInstallmentID"`
+	CancelledAt           *time.Time `gorm:"column:cancelled_at" json:"cancelledAt,omitempty"`
+	CancellationReason    *string    `gorm:"column:cancellation_reason" json:"cancellationReason,omitempty"`
+	PreexistingConditions []string   `gorm:"column:preexisting_conditions;type:jsonb;serializer:json" json:"preexistingConditions"`
+	KeptStartDate         bool       `gorm:"column:kept_start_date" json:"keptStartDate"`
+	RequestedBy           string     `gorm:"column:requested_by" json:"requestedBy"`
+	CreatedAt             time.Time  `gorm:"column:created_at;autoCreateTime" json:"createdAt"`
+}
+
+func (PolicyReactivation) TableName() string {
+	return "policy_reactivations"
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"appa_subscriptions/pkg/money"
+)
+
+type PriceDiscrepancy struct {
+	ID               string        `gorm:"primaryKey;column:id;default:gen_random_uuid()" json:"id"`
+	ShopifyOrderID   string        `gorm:"column:shopify_order_id" json:"shopifyOrderID"`
+	ShopifyVariantID string        `gorm:"column:shopify_variant_id" json:"shopifyVariantID"`
+	PlanPriceID      *string       `gorm:"column:plan_price_id" json:"planPriceID,omitempty"`
+	ExpectedPrice    *money.Amount `gorm:"column:expected_price" json:"expectedPrice,omitempty"`
+	ChargedPrice     money.Amount  `gorm:"column:charged_price" json:"chargedPrice"`
+	Reason           string        `gorm:"column:reason" json:"reason"`
+	CreatedAt        time.Time     `gorm:"column:created_at;autoCreateTime" json:"createdAt"`
+}
+
+func (PriceDiscrepancy) TableName() string {
+	return "price_discrepancies"
+}
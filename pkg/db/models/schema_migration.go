@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Schema migration kinds
+const (
+	// SchemaMigrationVersioned is a numbered migration, applied once and rolled back with its down file
+	SchemaMigrationVersioned = "versioned"
+	// SchemaMigrationSeed is a repeatable seed, applied again whenever its file changes
+	SchemaMigrationSeed = "seed"
+)
+
+// SchemaMigration records a migration or seed applied to the database
+type SchemaMigration struct {
+	Kind      string    `gorm:"primaryKey;column:kind" json:"kind"`
+	Version   string    `gorm:"primaryKey;column:version" json:"version"`
+	Name      string    `gorm:"column:name" json:"name"`
+	Checksum  string    `gorm:"column:checksum" json:"checksum"`
+	AppliedAt time.Time `gorm:"column:applied_at;autoCreateTime" json:"appliedAt"`
+}
+
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
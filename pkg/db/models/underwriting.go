@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// Underwriting rule types
+const (
+	RuleMaxEntryAgeMonths   = "max_entry_age_months"
+	RuleExcludedBreed       = "excluded_breed"
+	RuleRequiredNeutered    = "required_neutered"
+	RuleMaxPetsPerHousehold = "max_pets_per_household"
+)
+
+// Underwriting decisions, from the most to the least favourable
+const (
+	DecisionAccepted               = "accepted"
+	DecisionAcceptedWithExclusions = "accepted_with_exclusions"
+	DecisionManualReview           = "manual_review"
+	DecisionRejected               = "rejected"
+)
+
+type UnderwritingRule struct {
+	ID        string    `gorm:"primaryKey;column:id;default:gen_random_uuid()" json:"id"`
+	PetTypeID string    `gorm:"column:pet_type_id" json:"petTypeID"`
+	PlanID    *string   `gorm:"column:plan_id" json:"planID,omitempty"`
+	RuleType  string    `gorm:"column:rule_type" json:"ruleType"`
+	Value     string    `gorm:"column:value" json:"value"`
+	Outcome   string    `gorm:"column:outcome" json:"outcome"`
+	Exclusion *string   `gorm:"column:exclusion" json:"exclusion,omitempty"`
+	Active    bool      `gorm:"column:active;default:true" json:"active"`
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime" json:"createdAt"`
+}
+
+func (UnderwritingRule) TableName() string {
+	return "underwriting_rules"
+}
+
+type PolicyUnderwritingDecision struct {
+	ID          string     `gorm:"primaryKey;column:id;default:gen_random_uuid()" json:"id"`
+	PolicyID    string     `gorm:"column:policy_id" json:"policyID"`
+	Decision    string     `gorm:"column:decision" json:"decision"`
+	Reasons     []string   `gorm:"column:reasons;type:jsonb;serializer:json" json:"reasons"`
+	Exclusions  []string   `gorm:"column:exclusions;type:jsonb;serializer:json" json:"exclusions"`
+	ReviewedBy  *string    `gorm:"column:reviewed_by" json:"reviewedBy,omitempty"`
+	ReviewNotes *string    `gorm:"column:review_notes" json:"reviewNotes,omitempty"`
+	ReviewedAt  *time.Time `gorm:"column:reviewed_at" json:"reviewedAt,omitempty"`
+	CreatedAt   time.Time  `gorm:"column:created_at;autoCreateTime" json:"createdAt"`
+}
+
+func (PolicyUnderwritingDecision) TableName() string {
+	return "policy_underwriting_decisions"
+}
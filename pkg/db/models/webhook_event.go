@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Webhook topics received from Shopify
+const (
+	WebhookTopicOrderCreated = "orders/create"
+	WebhookTopicOrderPaid    = "orders/paid"
+)
+
+// WebhookEvent is a Shopify webhook as it was received
+type WebhookEvent struct {
+	ID               string     `gorm:"primaryKey;column:id;default:gen_random_uuid()" json:"id"`
+	Topic            string     `gorm:"column:topic" json:"topic"`
+	ShopifyWebhookID *string    `gorm:"column:shopify_webhook_id" json:"shopifyWebhookId,omitempty"`
+	ShopifyOrderID   *string    `gorm:"column:shopify_order_id" json:"shopifyOrderId,omitempty"`
+	Payload          string     `gorm:"column:payload;type:jsonb" json:"-"`
+	ReceivedAt       time.Time  `gorm:"column:received_at;autoCreateTime" json:"receivedAt"`
+	ReplayedAt       *time.Time `gorm:"column:replayed_at" json:"replayedAt,omitempty"`
+}
+
+func (WebhookEvent) TableName() string {
+	return "webhook_events"
+}
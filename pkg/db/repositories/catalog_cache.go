@@ -0,0 +1,274 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	dbModels "appa_subscriptions/pkg/db/models"
+)
+
+// attributeKey identifies a pet attribute by its pet type and normalised name
+type attributeKey struct {
+	petTypeID string
+	name      string
+}
+
+// catalogSnapshot holds the pet attributes and plans loaded at one time
+type catalogSnapshot struct {
+	loadedAt         time.Time
+	types            map[string]dbModels.PetType
+	ageRanges        map[attributeKey]dbModels.PetAgeRange
+	sizes            map[attributeKey]dbModels.PetSize
+	conditions       map[attributeKey]dbModels.PetCondition
+	plans            map[string]dbModels.Plan
+	plansByShopifyID map[string]dbModels.Plan
+}
+
+// CatalogCache is an in-memory read-through cache of the pet attributes and plans, which change
+// a few times a year. The whole catalog is loaded on first use and again once it is older than
+// the TTL or after Invalidate. A lookup the cache misses is read from the database and kept.
+// Lookups inside a transaction are never cached.
+type CatalogCache struct {
+	db            *gorm.DB
+	petAttributes PetAttributeRepository
+	plans         PlanRepository
+	ttl           time.Duration
+	logger        *zap.Logger
+
+	mu       sync.RWMutex
+	snapshot *catalogSnapshot
+}
+
+func NewCatalogCache(
+	db *gorm.DB,
+	petAttributeRepo PetAttributeRepository,
+	planRepo PlanRepository,
+	ttl time.Duration,
+	logger *zap.Logger,
+) *CatalogCache {
+	return &CatalogCache{
+		db:            db,
+		petAttributes: petAttributeRepo,
+		plans:         planRepo,
+		ttl:           ttl,
+		logger:        logger,
+	}
+}
+
+// PetAttributes returns the pet attribute lookups served from the cache
+func (c *CatalogCache) PetAttributes() PetAttributeRepository {
+	return &cachedPetAttributeRepository{cache: c}
+}
+
+// Plans returns the plan lookups served from the cache
+func (c *CatalogCache) Plans() PlanRepository {
+	return &cachedPlanRepository{cache: c}
+}
+
+// Invalidate drops the cached catalog, the next lookup loads it again. It is called after the
+// catalog is changed.
+func (c *CatalogCache) Invalidate() {
+	c.mu.Lock()
+	c.snapshot = nil
+	c.mu.Unlock()
+}
+
+// current returns the cached catalog, loading it when there is none or it is stale. When the
+// load fails the stale catalog is kept, or nil returned so lookups go to the database.
+func (c *CatalogCache) current(ctx context.Context) *catalogSnapshot {
+	c.mu.RLock()
+	snapshot := c.snapshot
+	c.mu.RUnlock()
+	if snapshot != nil && time.Since(snapshot.loadedAt) < c.ttl {
+		return snapshot
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.snapshot != nil && time.Since(c.snapshot.loadedAt) < c.ttl {
+		return c.snapshot
+	}
+
+	loaded, err := c.load(ctx)
+	if err != nil {
+		c.logger.Error("loading catalog cache", zap.Error(err))
+		return c.snapshot
+	}
+	c.snapshot = loaded
+
+	return c.snapshot
+}
+
+// load reads the whole catalog. Rows are read in id order and the first one of a name is kept,
+// like the database lookups do.
+func (c *CatalogCache) load(ctx context.Context) (*catalogSnapshot, error) {
+	var (
+		types      []dbModels.PetType
+		ageRanges  []dbModels.PetAgeRange
+		sizes      []dbModels.PetSize
+		conditions []dbModels.PetCondition
+		plans      []dbModels.Plan
+		db         = c.db.WithContext(ctx)
+	)
+	if err := db.Order("id").Find(&types).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Order("id").Find(&ageRanges).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Order("id").Find(&sizes).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Order("id").Find(&conditions).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Order("id").Find(&plans).Error; err != nil {
+		return nil, err
+	}
+
+	snapshot := &catalogSnapshot{
+		loadedAt:         time.Now(),
+		types:            make(map[string]dbModels.PetType, len(types)),
+		ageRanges:        make(map[attributeKey]dbModels.PetAgeRange, len(ageRanges)),
+		sizes:            make(map[attributeKey]dbModels.PetSize, len(sizes)),
+		conditions:       make(map[attributeKey]dbModels.PetCondition, len(conditions)),
+		plans:            make(map[string]dbModels.Plan, len(plans)),
+		plansByShopifyID: make(map[string]dbModels.Plan, len(plans)),
+	}
+	for _, petType := range types {
+		keepFirst(snapshot.types, NormalizeName(petType.Name), petType)
+	}
+	for _, ageRange := range ageRanges {
+		keepFirst(snapshot.ageRanges, attributeKey{ageRange.PetTypeID, NormalizeName(ageRange.Name)}, ageRange)
+	}
+	for _, size := range sizes {
+		keepFirst(snapshot.sizes, attributeKey{size.PetTypeID, NormalizeName(size.Name)}, size)
+	}
+	for _, condition := range conditions {
+		keepFirst(snapshot.conditions, attributeKey{condition.PetTypeID, NormalizeName(condition.Name)}, condition)
+	}
+	for _, plan := range plans {
+		snapshot.plans[plan.ID] = plan
+		keepFirst(snapshot.plansByShopifyID, plan.ShopifyID, plan)
+	}
+
+	c.logger.Info("catalog cache loaded",
+		zap.Int("pet_types", len(types)),
+		zap.Int("age_ranges", len(ageRanges)),
+		zap.Int("sizes", len(sizes)),
+		zap.Int("conditions", len(conditions)),
+		zap.Int("plans", len(plans)))
+
+	return snapshot, nil
+}
+
+// lookup returns the cached value of the key, or reads it with miss and caches it
+func lookup[K comparable, V any](
+	ctx context.Context,
+	c *CatalogCache,
+	entries func(*catalogSnapshot) map[K]V,
+	key K,
+	miss func() (*V, error),
+) (*V, error) {
+	snapshot := c.current(ctx)
+	if snapshot != nil {
+		c.mu.RLock()
+		value, ok := entries(snapshot)[key]
+		c.mu.RUnlock()
+		if ok {
+			return &value, nil
+		}
+	}
+
+	value, err := miss()
+	if err != nil {
+		return nil, err
+	}
+	if snapshot != nil {
+		c.mu.Lock()
+		entries(snapshot)[key] = *value
+		c.mu.Unlock()
+	}
+
+	return value, nil
+}
+
+func keepFirst[K comparable, V any](entries map[K]V, key K, value V) {
+	if _, ok := entries[key]; !ok {
+		entries[key] = value
+	}
+}
+
+type cachedPetAttributeRepository struct {
+	cache *CatalogCache
+}
+
+// WithTx returns the uncached repository running on the transaction
+func (r *cachedPetAttributeRepository) WithTx(tx *gorm.DB) PetAttributeRepository {
+	return r.cache.petAttributes.WithTx(tx)
+}
+
+func (r *cachedPetAttributeRepository) GetTypeByName(ctx context.Context, name string) (*dbModels.PetType, error) {
+	return lookup(ctx, r.cache,
+		func(s *catalogSnapshot) map[string]dbModels.PetType { return s.types },
+		NormalizeName(name),
+		func() (*dbModels.PetType, error) { return r.cache.petAttributes.GetTypeByName(ctx, name) },
+	)
+}
+
+func (r *cachedPetAttributeRepository) GetAgeRangeByName(ctx context.Context, petTypeID, name string) (*dbModels.PetAgeRange, error) {
+	return lookup(ctx, r.cache,
+		func(s *catalogSnapshot) map[attributeKey]dbModels.PetAgeRange { return s.ageRanges },
+		attributeKey{petTypeID, NormalizeName(name)},
+		func() (*dbModels.PetAgeRange, error) {
+			return r.cache.petAttributes.GetAgeRangeByName(ctx, petTypeID, name)
+		},
+	)
+}
+
+func (r *cachedPetAttributeRepository) GetSizeByName(ctx context.Context, petTypeID, name string) (*dbModels.PetSize, error) {
+	return lookup(ctx, r.cache,
+		func(s *catalogSnapshot) map[attributeKey]dbModels.PetSize { return s.sizes },
+		attributeKey{petTypeID, NormalizeName(name)},
+		func() (*dbModels.PetSize, error) { return r.cache.petAttributes.GetSizeByName(ctx, petTypeID, name) },
+	)
+}
+
+func (r *cachedPetAttributeRepository) GetConditionByName(ctx context.Context, petTypeID, name string) (*dbModels.PetCondition, error) {
+	return lookup(ctx, r.cache,
+		func(s *catalogSnapshot) map[attributeKey]dbModels.PetCondition { return s.conditions },
+		attributeKey{petTypeID, NormalizeName(name)},
+		func() (*dbModels.PetCondition, error) {
+			return r.cache.petAttributes.GetConditionByName(ctx, petTypeID, name)
+		},
+	)
+}
+
+type cachedPlanRepository struct {
+	cache *CatalogCache
+}
+
+// WithTx returns the uncached repository running on the transaction
+func (r *cachedPlanRepository) WithTx(tx *gorm.DB) PlanRepository {
+	return r.cache.plans.WithTx(tx)
+}
+
+func (r *cachedPlanRepository) GetByID(ctx context.Context, planID string) (*dbModels.Plan, error) {
+	return lookup(ctx, r.cache,
+		func(s *catalogSnapshot) map[string]dbModels.Plan { return s.plans },
+		planID,
+		func() (*dbModels.Plan, error) { return r.cache.plans.GetByID(ctx, planID) },
+	)
+}
+
+func (r *cachedPlanRepository) GetByShopifyID(ctx context.Context, productID string) (*dbModels.Plan, error) {
+	return lookup(ctx, r.cache,
+		func(s *catalogSnapshot) map[string]dbModels.Plan { return s.plansByShopifyID },
+		productID,
+		func() (*dbModels.Plan, error) { return r.cache.plans.GetByShopifyID(ctx, productID) },
+	)
+}
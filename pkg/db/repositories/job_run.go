@@ -0,0 +1,105 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	dbModels "appa_subscriptions/pkg/db/models"
+)
+
+// JobRunRepository claims the runs of the scheduled jobs so each one executes on a single
+// instance. TryLock serializes the instances firing the same run; the run record keeps an
+// instance firing it after the lock was released from running it again.
+type JobRunRepository interface {
+	TryLock(ctx context.Context, job string, scheduledAt time.Time) (unlock func(), locked bool, err error)
+	Start(ctx context.Context, run *dbModels.JobRun) (bool, error)
+	Finish(ctx context.Context, run *dbModels.JobRun) error
+}
+
+type jobRunRepository struct {
+	db     *gorm.DB
+	logger *zap.Logger
+}
+
+func NewJobRunRepository(
+	db *gorm.DB,
+	logger *zap.Logger,
+) JobRunRepository {
+	return &jobRunRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// TryLock takes the session advisory lock of the run on a connection of its own, keyed by the
+// job and the scheduled time. It returns false when another instance holds it, otherwise the
+// lock is held until unlock is called.
+func (r *jobRunRepository) TryLock(ctx context.Context, job string, scheduledAt time.Time) (func(), bool, error) {
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return nil, false, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		r.logger.Error("getting connection for job lock", zap.Error(err), zap.String("job", job))
+		return nil, false, err
+	}
+
+	key := scheduledAt.UTC().Format(time.RFC3339)
+	var locked bool
+	err = conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock(hashtext($1), hashtext($2))", job, key).Scan(&locked)
+	if err != nil || !locked {
+		if err != nil {
+			r.logger.Error("taking job lock", zap.Error(err), zap.String("job", job))
+		}
+		if closeErr := conn.Close(); closeErr != nil {
+			r.logger.Warn("closing job lock connection", zap.Error(closeErr))
+		}
+		return nil, false, err
+	}
+
+	unlock := func() {
+		// a background context, the lock is released even when the run was cancelled
+		_, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock(hashtext($1), hashtext($2))", job, key)
+		if err != nil {
+			r.logger.Error("releasing job lock", zap.Error(err), zap.String("job", job))
+		}
+		// closing the connection ends the session, which releases the lock anyway
+		if err := conn.Close(); err != nil {
+			r.logger.Warn("closing job lock connection", zap.Error(err))
+		}
+	}
+
+	return unlock, true, nil
+}
+
+// Start records the run and reports whether it was recorded, false when it already ran
+func (r *jobRunRepository) Start(ctx context.Context, run *dbModels.JobRun) (bool, error) {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(run)
+	if result.Error != nil {
+		r.logger.Error("recording job run", zap.Error(result.Error), zap.String("job", run.Job))
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// Finish records when the run ended
+func (r *jobRunRepository) Finish(ctx context.Context, run *dbModels.JobRun) error {
+	now := time.Now()
+	err := r.db.WithContext(ctx).
+		Model(&dbModels.JobRun{}).
+		Where("job = ? AND scheduled_at = ?", run.Job, run.ScheduledAt).
+		Update("finished_at", now).Error
+	if err != nil {
+		r.logger.Error("finishing job run", zap.Error(err), zap.String("job", run.Job))
+		return err
+	}
+	run.FinishedAt = &now
+
+	return nil
+}
@@ -1,9 +1,9 @@
-package PaymentInstallment
+package repositories
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"strconv"
 	"strings"
 	"time"
 
@@ -11,16 +11,87 @@ import (
 	"gorm.io/gorm"
 
 	dbModels "appa_subscriptions/pkg/db/models"
+	"appa_subscriptions/pkg/money"
 )
 
-type Repository interface {
+// defaultCurrency is the shop currency of installments created without one
+const defaultCurrency = "USD"
+
+// CreateInstallmentInput describes the installment of a Shopify order
+type CreateInstallmentInput struct {
+	OrderID   string
+	OrderName string
+	Status    string
+	// Amount is in the shop currency, PresentmentAmount in the currency the customer pays in
+	Amount              string
+	Currency            string
+	PresentmentAmount   string
+	PresentmentCurrency string
+	// ExchangeRate is the official rate snapshotted for the installment, if one is available
+	ExchangeRate *dbModels.ExchangeRate
+	// DueDate is the billing date the installment pays
+	DueDate time.Time
+	// PolicyIDs are the policies billed in the installment, they number it after their last
+	// installment. New policies have none and start at one.
+	PolicyIDs []string
+}
+
+type PaymentInstallmentRepository interface {
 	Create(
 		tx *gorm.DB,
 		ctx context.Context,
-		orderID string,
-		status string,
-		orderName, amountStr string,
+		input CreateInstallmentInput,
 	) (*dbModels.PaymentInstallment, error)
+	CreateItems(
+		tx *gorm.DB,
+		ctx context.Context,
+		installmentID string,
+		items []dbModels.PaymentInstallmentItem,
+	) error
+	GetByID(
+		tx *gorm.DB,
+		ctx context.Context,
+		installmentID string,
+	) (*dbModels.PaymentInstallment, error)
+	FindByShopifyOrderID(
+		tx *gorm.DB,
+		ctx context.Context,
+		orderID string,
+		statuses ...string,
+	) ([]dbModels.PaymentInstallment, error)
+	FindByPolicy(
+		tx *gorm.DB,
+		ctx context.Context,
+		policyID string,
+		statuses ...string,
+	) ([]dbModels.PaymentInstallment, error)
+	FindByStatus(
+		tx *gorm.DB,
+		ctx context.Context,
+		statuses ...string,
+	) ([]dbModels.PaymentInstallment, error)
+	ReferenceReported(
+		tx *gorm.DB,
+		ctx context.Context,
+		method string,
+		reference string,
+	) (bool, error)
+	MarkPaid(
+		tx *gorm.DB,
+		ctx context.Context,
+		installmentIDs []string,
+		paidAt time.Time,
+	) (int64, error)
+	MarkCancelled(
+		tx *gorm.DB,
+		ctx context.Context,
+		installmentIDs []string,
+	) (int64, error)
+	MarkOverdue(
+		tx *gorm.DB,
+		ctx context.Context,
+		installmentIDs []string,
+	) (int64, error)
 }
 
 type repository struct {
@@ -31,7 +102,7 @@ type repository struct {
 func NewPaymentInstallmentRepository(
 	loc *time.Location,
 	logger *zap.Logger,
-) Repository {
+) PaymentInstallmentRepository {
 	return &repository{
 		loc:    loc,
 		logger: logger,
@@ -42,39 +113,63 @@ func NewPaymentInstallmentRepository(
 func (r *repository) Create(
 	tx *gorm.DB,
 	ctx context.Context,
-	orderID string,
-	status string,
-	orderName, amountStr string,
+	input CreateInstallmentInput,
 ) (*dbModels.PaymentInstallment, error) {
 	if tx == nil {
 		r.logger.Error("transaction is nil")
 		return nil, fmt.Errorf("transaction is nil")
 	}
 
-	installmentNumber, err := strconv.Atoi(strings.ReplaceAll(orderName, "#", ""))
+	amount, err := money.Parse(input.Amount)
 	if err != nil {
-		r.logger.Error(err.Error(), zap.String("Name", strings.ReplaceAll(orderName, "#", "")))
+		r.logger.Error(err.Error(), zap.String("Amount", input.Amount))
 		return nil, err
 	}
 
-	amount, err := strconv.ParseFloat(amountStr, 64)
+	var presentmentAmount *money.Amount
+	if input.PresentmentAmount != "" {
+		value, err := money.Parse(input.PresentmentAmount)
+		if err != nil {
+			r.logger.Error(err.Error(), zap.String("PresentmentAmount", input.PresentmentAmount))
+			return nil, err
+		}
+		presentmentAmount = &value
+	}
+
+	installmentNumber, err := r.nextInstallmentNumber(tx, ctx, input.PolicyIDs)
 	if err != nil {
-		r.logger.Error(err.Error(), zap.String("Amount", amountStr))
 		return nil, err
 	}
 
-	orderID = strings.TrimPrefix(orderID, "gid://shopify/Order/")
+	dueDate := input.DueDate
+	if dueDate.IsZero() {
+		dueDate = time.Now().In(r.loc)
+	}
 
 	// Pre-create PaymentInstallment
 	paymentInstallment := dbModels.PaymentInstallment{
 		InstallmentNumber: installmentNumber,
-		DueDate:           time.Now().AddDate(0, 1, 0).In(r.loc),
+		DueDate:           dueDate,
 		Amount:            amount,
-		Status:            status,
-		ShopifyOrderID:    orderID,
+		Status:            input.Status,
+		ShopifyOrderID:    legacyOrderID(input.OrderID),
+		ShopifyOrderName:  input.OrderName,
+		Currency:          input.Currency,
+		PresentmentAmount: presentmentAmount,
 		CreatedAt:         time.Now().In(r.loc),
 		UpdatedAt:         time.Now().In(r.loc),
 	}
+	if paymentInstallment.Currency == "" {
+		paymentInstallment.Currency = defaultCurrency
+	}
+	if input.PresentmentCurrency != "" {
+		paymentInstallment.PresentmentCurrency = &input.PresentmentCurrency
+	}
+	if input.ExchangeRate != nil {
+		paymentInstallment.ExchangeRate = &input.ExchangeRate.Rate
+		paymentInstallment.ExchangeRateSource = &input.ExchangeRate.Source
+		paymentInstallment.ExchangeRateDate = &input.ExchangeRate.EffectiveDate
+	}
 	if err := tx.WithContext(ctx).Create(&paymentInstallment).Error; err != nil {
 		r.logger.Error("creating payment installment", zap.Error(err), zap.Any("req", paymentInstallment))
 		return nil, err
@@ -82,3 +177,226 @@ func (r *repository) Create(
 
 	return &paymentInstallment, nil
 }
+
+// CreateItems stores the breakdown of an installment
+func (r *repository) CreateItems(
+	tx *gorm.DB,
+	ctx context.Context,
+	installmentID string,
+	items []dbModels.PaymentInstallmentItem,
+) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	for i := range items {
+		items[i].PaymentInstallmentID = installmentID
+	}
+	if err := tx.WithContext(ctx).Create(&items).Error; err != nil {
+		r.logger.Error("creating payment installment items", zap.Error(err), zap.String("installment_id", installmentID))
+		return err
+	}
+
+	return nil
+}
+
+// GetByID returns the installment, gorm.ErrRecordNotFound when there is none
+func (r *repository) GetByID(
+	tx *gorm.DB,
+	ctx context.Context,
+	installmentID string,
+) (*dbModels.PaymentInstallment, error) {
+	var installment dbModels.PaymentInstallment
+	err := tx.WithContext(ctx).Where("id = ?", installmentID).First(&installment).Error
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			r.logger.Error("getting payment installment", zap.Error(err), zap.String("installment_id", installmentID))
+		}
+		return nil, err
+	}
+
+	return &installment, nil
+}
+
+// FindByShopifyOrderID returns the installments of a Shopify order, in the given states when
+// any are given. The order id may be numeric or a GraphQL global id.
+func (r *repository) FindByShopifyOrderID(
+	tx *gorm.DB,
+	ctx context.Context,
+	orderID string,
+	statuses ...string,
+) ([]dbModels.PaymentInstallment, error) {
+	var installments []dbModels.PaymentInstallment
+	err := withStatuses(tx.WithContext(ctx), statuses).
+		Where("shopify_order_id = ?", legacyOrderID(orderID)).
+		Order("installment_number").
+		Find(&installments).Error
+	if err != nil {
+		r.logger.Error("getting payment installments by shopify order id", zap.Error(err), zap.String("order_id", orderID))
+		return nil, err
+	}
+
+	return installments, nil
+}
+
+// FindByPolicy returns the installments billing a policy, in the given states when any are
+// given, oldest first
+func (r *repository) FindByPolicy(
+	tx *gorm.DB,
+	ctx context.Context,
+	policyID string,
+	statuses ...string,
+) ([]dbModels.PaymentInstallment, error) {
+	var installments []dbModels.PaymentInstallment
+	err := withStatuses(tx.WithContext(ctx), statuses).
+		Where("id IN (?)", tx.Model(&dbModels.PolicyPayment{}).
+			Select("payment_installment_id").
+			Where("policy_id = ?", policyID)).
+		Order("due_date, installment_number").
+		Find(&installments).Error
+	if err != nil {
+		r.logger.Error("getting payment installments by policy", zap.Error(err), zap.String("policy_id", policyID))
+		return nil, err
+	}
+
+	return installments, nil
+}
+
+// FindByStatus returns the installments in the given states, oldest due first
+func (r *repository) FindByStatus(
+	tx *gorm.DB,
+	ctx context.Context,
+	statuses ...string,
+) ([]dbModels.PaymentInstallment, error) {
+	if len(statuses) == 0 {
+		return nil, fmt.Errorf("no installment status given")
+	}
+
+	var installments []dbModels.PaymentInstallment
+	err := withStatuses(tx.WithContext(ctx), statuses).
+		Order("due_date, installment_number").
+		Find(&installments).Error
+	if err != nil {
+		r.logger.Error("getting payment installments by status", zap.Error(err), zap.Strings("statuses", statuses))
+		return nil, err
+	}
+
+	return installments, nil
+}
+
+// ReferenceReported reports whether a manual payment of an installment already used the bank
+// reference of the payment method. References of rejected payments can be reported again.
+func (r *repository) ReferenceReported(
+	tx *gorm.DB,
+	ctx context.Context,
+	method string,
+	reference string,
+) (bool, error) {
+	var reported int64
+	err := tx.WithContext(ctx).
+		Model(&dbModels.ManualPayment{}).
+		Where("method = ? AND reference = ? AND status <> ?", method, reference, dbModels.ManualPaymentRejected).
+		Count(&reported).Error
+	if err != nil {
+		r.logger.Error("checking payment reference", zap.Error(err), zap.String("reference", reference))
+		return false, err
+	}
+
+	return reported > 0, nil
+}
+
+// MarkPaid marks the open installments paid. Installments already paid or cancelled are left
+// as they are, the number of installments marked is returned.
+func (r *repository) MarkPaid(
+	tx *gorm.DB,
+	ctx context.Context,
+	installmentIDs []string,
+	paidAt time.Time,
+) (int64, error) {
+	return r.transition(tx, ctx, installmentIDs, dbModels.OpenInstallmentStatuses, map[string]any{
+		"status":  dbModels.InstallmentPaid,
+		"paid_at": paidAt,
+	})
+}
+
+// MarkCancelled cancels the open installments, the number of installments cancelled is returned
+func (r *repository) MarkCancelled(
+	tx *gorm.DB,
+	ctx context.Context,
+	installmentIDs []string,
+) (int64, error) {
+	return r.transition(tx, ctx, installmentIDs, dbModels.OpenInstallmentStatuses, map[string]any{
+		"status": dbModels.InstallmentCancelled,
+	})
+}
+
+// MarkOverdue marks the pending installments overdue, the number of installments marked is
+// returned
+func (r *repository) MarkOverdue(
+	tx *gorm.DB,
+	ctx context.Context,
+	installmentIDs []string,
+) (int64, error) {
+	return r.transition(tx, ctx, installmentIDs, []string{dbModels.InstallmentPending}, map[string]any{
+		"status": dbModels.InstallmentOverdue,
+	})
+}
+
+// transition applies the update to the installments that are in one of the from states
+func (r *repository) transition(
+	tx *gorm.DB,
+	ctx context.Context,
+	installmentIDs []string,
+	from []string,
+	updates map[string]any,
+) (int64, error) {
+	if len(installmentIDs) == 0 {
+		return 0, nil
+	}
+
+	result := tx.WithContext(ctx).
+		Model(&dbModels.PaymentInstallment{}).
+		Where("id IN ? AND status IN ?", installmentIDs, from).
+		Updates(updates)
+	if result.Error != nil {
+		r.logger.Error("updating payment installments status", zap.Error(result.Error),
+			zap.Strings("installment_ids", installmentIDs), zap.Any("status", updates["status"]))
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
+
+// nextInstallmentNumber returns the number following the last installment of the policies
+func (r *repository) nextInstallmentNumber(tx *gorm.DB, ctx context.Context, policyIDs []string) (int, error) {
+	if len(policyIDs) == 0 {
+		return 1, nil
+	}
+
+	var last int
+	err := tx.WithContext(ctx).
+		Model(&dbModels.PaymentInstallment{}).
+		Select("COALESCE(MAX(payment_installments.installment_number), 0)").
+		Joins("JOIN policies_payments pp ON pp.payment_installment_id = payment_installments.id").
+		Where("pp.policy_id IN ?", policyIDs).
+		Scan(&last).Error
+	if err != nil {
+		r.logger.Error("getting last installment number", zap.Error(err), zap.Strings("policy_ids", policyIDs))
+		return 0, err
+	}
+
+	return last + 1, nil
+}
+
+// withStatuses filters the query by the installment states when any are given
+func withStatuses(query *gorm.DB, statuses []string) *gorm.DB {
+	if len(statuses) == 0 {
+		return query
+	}
+	return query.Where("status IN ?", statuses)
+}
+
+// legacyOrderID returns the numeric id installments store for a Shopify order
+func legacyOrderID(orderID string) string {
+	return strings.TrimPrefix(orderID, "gid://shopify/Order/")
+}
@@ -0,0 +1,455 @@
+package repositories_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"appa_subscriptions/pkg/db"
+	"appa_subscriptions/pkg/db/migrations"
+	dbModels "appa_subscriptions/pkg/db/models"
+	"appa_subscriptions/pkg/db/repositories"
+)
+
+// testDSNEnv names the connection string of the database the repository tests migrate and
+// run against. The tests are skipped when it is not set.
+const testDSNEnv = "TEST_DATABASE_DSN"
+
+var (
+	migrateOnce sync.Once
+	testGormDB  *gorm.DB
+	migrateErr  error
+)
+
+// testTx returns a transaction on the migrated test database, rolled back when the test ends
+func testTx(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv(testDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", testDSNEnv)
+	}
+
+	migrateOnce.Do(func() {
+		testGormDB, migrateErr = db.NewDBSQLHandler(dsn)
+		if migrateErr != nil {
+			return
+		}
+		var migrator *migrations.Migrator
+		migrator, migrateErr = migrations.NewMigrator(testGormDB, zap.NewNop())
+		if migrateErr != nil {
+			return
+		}
+		_, _, migrateErr = migrator.Up(context.Background())
+	})
+	if migrateErr != nil {
+		t.Fatalf("migrating test database: %v", migrateErr)
+	}
+
+	tx := testGormDB.Begin()
+	t.Cleanup(func() { tx.Rollback() })
+	return tx
+}
+
+func newTestRepository(t *testing.T) repositories.PaymentInstallmentRepository {
+	t.Helper()
+
+	loc, err := time.LoadLocation("America/Caracas")
+	if err != nil {
+		t.Fatalf("loading location: %v", err)
+	}
+	return repositories.NewPaymentInstallmentRepository(loc, zap.NewNop())
+}
+
+// createInstallment creates an installment of the order in the status
+func createInstallment(
+	t *testing.T,
+	tx *gorm.DB,
+	repo repositories.PaymentInstallmentRepository,
+	orderID, status string,
+) *dbModels.PaymentInstallment {
+	t.Helper()
+
+	installment, err := repo.Create(tx, context.Background(), repositories.CreateInstallmentInput{
+		OrderID:   orderID,
+		OrderName: "#" + orderID,
+		Status:    status,
+		Amount:    "25.00",
+		DueDate:   time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("creating %s installment: %v", status, err)
+	}
+	return installment
+}
+
+// getStatus reads the status of the installment back from the database
+func getStatus(t *testing.T, tx *gorm.DB, id string) string {
+	t.Helper()
+
+	var installment dbModels.PaymentInstallment
+	if err := tx.Where("id = ?", id).First(&installment).Error; err != nil {
+		t.Fatalf("getting installment %s: %v", id, err)
+	}
+	return installment.Status
+}
+
+// createPolicy creates a canine policy, with its user and pet, on the seeded catalog
+func createPolicy(t *testing.T, tx *gorm.DB) string {
+	t.Helper()
+
+	var policyID string
+	err := tx.Raw(`WITH owner AS (
+		INSERT INTO public.users (id, name, email, shopify_id)
+		VALUES (gen_random_uuid(), 'Test Owner', gen_random_uuid()::text || '@example.com', gen_random_uuid()::text)
+		RETURNING id
+	), pet AS (
+		INSERT INTO public.pets (user_id, name, breed, gender, age_range_id, condition_id, size_id, type_id)
+		SELECT owner.id, 'Toby', 'mestizo', 'male', pets_age_ranges.id, pets_conditions.id, pets_sizes.id, pets_types.id
+		FROM owner, public.pets_types
+		JOIN public.pets_age_ranges ON pets_age_ranges.pet_type_id = pets_types.id
+		JOIN public.pets_conditions ON pets_conditions.pet_type_id = pets_types.id
+		JOIN public.pets_sizes ON pets_sizes.pet_type_id = pets_types.id
+		WHERE pets_types.name = 'canine'
+		LIMIT 1
+		RETURNING id, user_id, type_id
+	)
+	INSERT INTO public.policies (user_id, pet_id, plan_id, start_date, next_payment, remaining_balance)
+	SELECT pet.user_id, pet.id, plans.id, CURRENT_DATE, CURRENT_DATE, plans.annual_limit
+	FROM pet JOIN public.plans ON plans.pet_type_id = pet.type_id
+	LIMIT 1
+	RETURNING id`).Scan(&policyID).Error
+	if err != nil || policyID == "" {
+		t.Fatalf("creating policy: %v", err)
+	}
+	return policyID
+}
+
+// linkPolicies records the installment as billing the policies
+func linkPolicies(t *testing.T, tx *gorm.DB, installmentID string, policyIDs ...string) {
+	t.Helper()
+
+	for _, policyID := range policyIDs {
+		err := tx.Create(&dbModels.PolicyPayment{PolicyID: policyID, PaymentInstallmentID: installmentID}).Error
+		if err != nil {
+			t.Fatalf("linking installment %s to policy %s: %v", installmentID, policyID, err)
+		}
+	}
+}
+
+func installmentIDs(installments []dbModels.PaymentInstallment) []string {
+	ids := make([]string, 0, len(installments))
+	for _, installment := range installments {
+		ids = append(ids, installment.ID)
+	}
+	return ids
+}
+
+func TestGetByID(t *testing.T) {
+	tx := testTx(t)
+	repo := newTestRepository(t)
+	ctx := context.Background()
+
+	installment := createInstallment(t, tx, repo, "9000000061", dbModels.InstallmentOverdue)
+
+	found, err := repo.GetByID(tx, ctx, installment.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if found.ID != installment.ID || found.Status != dbModels.InstallmentOverdue {
+		t.Errorf("got installment %s in %s, want %s in %s", found.ID, found.Status, installment.ID, dbModels.InstallmentOverdue)
+	}
+
+	_, err = repo.GetByID(tx, ctx, "00000000-0000-4000-8000-000000000000")
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("unknown installment: got %v, want gorm.ErrRecordNotFound", err)
+	}
+}
+
+func TestReferenceReported(t *testing.T) {
+	tx := testTx(t)
+	repo := newTestRepository(t)
+	ctx := context.Background()
+
+	installment := createInstallment(t, tx, repo, "9000000071", dbModels.InstallmentPending)
+	for reference, status := range map[string]string{
+		"REF-PENDING":  dbModels.ManualPaymentPending,
+		"REF-APPROVED": dbModels.ManualPaymentApproved,
+		"REF-REJECTED": dbModels.ManualPaymentRejected,
+	} {
+		err := tx.Create(&dbModels.ManualPayment{
+			PaymentInstallmentID: installment.ID,
+			Method:               dbModels.ManualPaymentPagoMovil,
+			Reference:            reference,
+			Amount:               installment.Amount,
+			Currency:             "USD",
+			PaidOn:               time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC),
+			ProofKey:             "proofs/" + reference,
+			ProofContentType:     "image/png",
+			SubmittedBy:          "customer",
+			Status:               status,
+		}).Error
+		if err != nil {
+			t.Fatalf("creating %s manual payment: %v", status, err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		method    string
+		reference string
+		want      bool
+	}{
+		{"pending payment", dbModels.ManualPaymentPagoMovil, "REF-PENDING", true},
+		{"approved payment", dbModels.ManualPaymentPagoMovil, "REF-APPROVED", true},
+		{"rejected payment", dbModels.ManualPaymentPagoMovil, "REF-REJECTED", false},
+		{"other method", dbModels.ManualPaymentZelle, "REF-PENDING", false},
+		{"unknown reference", dbModels.ManualPaymentPagoMovil, "REF-UNKNOWN", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.ReferenceReported(tx, ctx, tt.method, tt.reference)
+			if err != nil {
+				t.Fatalf("ReferenceReported: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindByShopifyOrderID(t *testing.T) {
+	tx := testTx(t)
+	repo := newTestRepository(t)
+	ctx := context.Background()
+
+	pending := createInstallment(t, tx, repo, "9000000001", dbModels.InstallmentPending)
+	paid := createInstallment(t, tx, repo, "9000000001", dbModels.InstallmentPaid)
+	other := createInstallment(t, tx, repo, "9000000002", dbModels.InstallmentPending)
+
+	tests := []struct {
+		name     string
+		orderID  string
+		statuses []string
+		want     []string
+	}{
+		{"numeric id, every state", "9000000001", nil, []string{pending.ID, paid.ID}},
+		{"global id", "gid://shopify/Order/9000000001", nil, []string{pending.ID, paid.ID}},
+		{"filtered by state", "9000000001", []string{dbModels.InstallmentPaid}, []string{paid.ID}},
+		{"other order", "9000000002", nil, []string{other.ID}},
+		{"unknown order", "9000000003", nil, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, err := repo.FindByShopifyOrderID(tx, ctx, tt.orderID, tt.statuses...)
+			if err != nil {
+				t.Fatalf("FindByShopifyOrderID: %v", err)
+			}
+
+			got := installmentIDs(found)
+			slices.Sort(got)
+			want := slices.Clone(tt.want)
+			slices.Sort(want)
+			if !slices.Equal(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestFindByPolicy(t *testing.T) {
+	tx := testTx(t)
+	repo := newTestRepository(t)
+	ctx := context.Background()
+
+	first, second := createPolicy(t, tx), createPolicy(t, tx)
+
+	pending := createInstallment(t, tx, repo, "9000000041", dbModels.InstallmentPending)
+	paid := createInstallment(t, tx, repo, "9000000042", dbModels.InstallmentPaid)
+	shared := createInstallment(t, tx, repo, "9000000043", dbModels.InstallmentOverdue)
+	other := createInstallment(t, tx, repo, "9000000044", dbModels.InstallmentPending)
+	linkPolicies(t, tx, pending.ID, first)
+	linkPolicies(t, tx, paid.ID, first)
+	linkPolicies(t, tx, shared.ID, first, second)
+	linkPolicies(t, tx, other.ID, second)
+
+	tests := []struct {
+		name     string
+		policyID string
+		statuses []string
+		want     []string
+	}{
+		{"every state", first, nil, []string{pending.ID, paid.ID, shared.ID}},
+		{"open states", first, dbModels.OpenInstallmentStatuses, []string{pending.ID, shared.ID}},
+		{"shared installment", second, nil, []string{shared.ID, other.ID}},
+		{"no match in state", second, []string{dbModels.InstallmentPaid}, []string{}},
+		{"unknown policy", "00000000-0000-4000-8000-000000000000", nil, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, err := repo.FindByPolicy(tx, ctx, tt.policyID, tt.statuses...)
+			if err != nil {
+				t.Fatalf("FindByPolicy: %v", err)
+			}
+
+			got := installmentIDs(found)
+			slices.Sort(got)
+			want := slices.Clone(tt.want)
+			slices.Sort(want)
+			if !slices.Equal(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestFindByStatus(t *testing.T) {
+	tx := testTx(t)
+	repo := newTestRepository(t)
+	ctx := context.Background()
+
+	pending := createInstallment(t, tx, repo, "9000000011", dbModels.InstallmentPending)
+	overdue := createInstallment(t, tx, repo, "9000000012", dbModels.InstallmentOverdue)
+	paid := createInstallment(t, tx, repo, "9000000013", dbModels.InstallmentPaid)
+	cancelled := createInstallment(t, tx, repo, "9000000014", dbModels.InstallmentCancelled)
+
+	found, err := repo.FindByStatus(tx, ctx, dbModels.OpenInstallmentStatuses...)
+	if err != nil {
+		t.Fatalf("FindByStatus: %v", err)
+	}
+
+	// the database may hold other installments, only the ones created here are checked
+	got := installmentIDs(found)
+	for _, id := range []string{pending.ID, overdue.ID} {
+		if !slices.Contains(got, id) {
+			t.Errorf("open installment %s not found", id)
+		}
+	}
+	for _, id := range []string{paid.ID, cancelled.ID} {
+		if slices.Contains(got, id) {
+			t.Errorf("closed installment %s found", id)
+		}
+	}
+
+	if _, err := repo.FindByStatus(tx, ctx); err == nil {
+		t.Error("FindByStatus without states: want an error")
+	}
+}
+
+func TestMarkPaid(t *testing.T) {
+	tests := []struct {
+		from       string
+		wantMarked int64
+		wantStatus string
+	}{
+		{dbModels.InstallmentPending, 1, dbModels.InstallmentPaid},
+		{dbModels.InstallmentOverdue, 1, dbModels.InstallmentPaid},
+		{dbModels.InstallmentPaid, 0, dbModels.InstallmentPaid},
+		{dbModels.InstallmentCancelled, 0, dbModels.InstallmentCancelled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.from, func(t *testing.T) {
+			tx := testTx(t)
+			repo := newTestRepository(t)
+
+			installment := createInstallment(t, tx, repo, "9000000021", tt.from)
+			marked, err := repo.MarkPaid(tx, context.Background(), []string{installment.ID}, time.Now())
+			if err != nil {
+				t.Fatalf("MarkPaid: %v", err)
+			}
+			if marked != tt.wantMarked {
+				t.Errorf("marked %d, want %d", marked, tt.wantMarked)
+			}
+			if status := getStatus(t, tx, installment.ID); status != tt.wantStatus {
+				t.Errorf("status %q, want %q", status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestMarkCancelled(t *testing.T) {
+	tests := []struct {
+		from       string
+		wantMarked int64
+		wantStatus string
+	}{
+		{dbModels.InstallmentPending, 1, dbModels.InstallmentCancelled},
+		{dbModels.InstallmentOverdue, 1, dbModels.InstallmentCancelled},
+		{dbModels.InstallmentPaid, 0, dbModels.InstallmentPaid},
+		{dbModels.InstallmentCancelled, 0, dbModels.InstallmentCancelled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.from, func(t *testing.T) {
+			tx := testTx(t)
+			repo := newTestRepository(t)
+
+			installment := createInstallment(t, tx, repo, "9000000031", tt.from)
+			marked, err := repo.MarkCancelled(tx, context.Background(), []string{installment.ID})
+			if err != nil {
+				t.Fatalf("MarkCancelled: %v", err)
+			}
+			if marked != tt.wantMarked {
+				t.Errorf("marked %d, want %d", marked, tt.wantMarked)
+			}
+			if status := getStatus(t, tx, installment.ID); status != tt.wantStatus {
+				t.Errorf("status %q, want %q", status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestMarkOverdue(t *testing.T) {
+	tests := []struct {
+		from       string
+		wantMarked int64
+		wantStatus string
+	}{
+		{dbModels.InstallmentPending, 1, dbModels.InstallmentOverdue},
+		{dbModels.InstallmentOverdue, 0, dbModels.InstallmentOverdue},
+		{dbModels.InstallmentPaid, 0, dbModels.InstallmentPaid},
+		{dbModels.InstallmentCancelled, 0, dbModels.InstallmentCancelled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.from, func(t *testing.T) {
+			tx := testTx(t)
+			repo := newTestRepository(t)
+
+			installment := createInstallment(t, tx, repo, "9000000051", tt.from)
+			marked, err := repo.MarkOverdue(tx, context.Background(), []string{installment.ID})
+			if err != nil {
+				t.Fatalf("MarkOverdue: %v", err)
+			}
+			if marked != tt.wantMarked {
+				t.Errorf("marked %d, want %d", marked, tt.wantMarked)
+			}
+			if status := getStatus(t, tx, installment.ID); status != tt.wantStatus {
+				t.Errorf("status %q, want %q", status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestTransitionWithoutInstallments(t *testing.T) {
+	tx := testTx(t)
+	repo := newTestRepository(t)
+	ctx := context.Background()
+
+	if marked, err := repo.MarkPaid(tx, ctx, nil, time.Now()); err != nil || marked != 0 {
+		t.Errorf("MarkPaid without ids: marked %d, err %v", marked, err)
+	}
+	if marked, err := repo.MarkCancelled(tx, ctx, nil); err != nil || marked != 0 {
+		t.Errorf("MarkCancelled without ids: marked %d, err %v", marked, err)
+	}
+	if marked, err := repo.MarkOverdue(tx, ctx, nil); err != nil || marked != 0 {
+		t.Errorf("MarkOverdue without ids: marked %d, err %v", marked, err)
+	}
+}
@@ -0,0 +1,54 @@
+package repositories
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	dbModels "appa_subscriptions/pkg/db/models"
+)
+
+// PetRepository reads and writes pets. WithTx returns a repository running on a transaction.
+type PetRepository interface {
+	WithTx(tx *gorm.DB) PetRepository
+	FirstOrCreateByName(ctx context.Context, pet *dbModels.Pet) error
+}
+
+type petRepository struct {
+	db     *gorm.DB
+	logger *zap.Logger
+}
+
+func NewPetRepository(
+	db *gorm.DB,
+	logger *zap.Logger,
+) PetRepository {
+	return &petRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// WithTx returns a copy of the repository running on the transaction
+func (r *petRepository) WithTx(tx *gorm.DB) PetRepository {
+	return &petRepository{
+		db:     tx,
+		logger: r.logger,
+	}
+}
+
+// FirstOrCreateByName loads the pet of the user with the name of pet into it, or creates pet
+// when the user has none by that name
+func (r *petRepository) FirstOrCreateByName(ctx context.Context, pet *dbModels.Pet) error {
+	err := r.db.WithContext(ctx).
+		Where(dbModels.Pet{Name: pet.Name, UserID: pet.UserID}).
+		Omit("MicrochipID").
+		FirstOrCreate(pet).Error
+	if err != nil {
+		r.logger.Error("creating pet", zap.Error(err), zap.String("user_id", pet.UserID))
+		return err
+	}
+
+	return nil
+}
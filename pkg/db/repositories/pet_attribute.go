@@ -0,0 +1,104 @@
+package repositories
+
+import (
+	"context"
+	"strings"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	dbModels "appa_subscriptions/pkg/db/models"
+)
+
+// PetAttributeRepository looks up the pet types, and the age ranges, sizes and conditions of a
+// pet type, by their names. The same names are used by several pet types, so attributes are
+// always matched within one. Names are matched normalised, the way they are stored.
+type PetAttributeRepository interface {
+	WithTx(tx *gorm.DB) PetAttributeRepository
+	GetTypeByName(ctx context.Context, name string) (*dbModels.PetType, error)
+	GetAgeRangeByName(ctx context.Context, petTypeID, name string) (*dbModels.PetAgeRange, error)
+	GetSizeByName(ctx context.Context, petTypeID, name string) (*dbModels.PetSize, error)
+	GetConditionByName(ctx context.Context, petTypeID, name string) (*dbModels.PetCondition, error)
+}
+
+type petAttributeRepository struct {
+	db     *gorm.DB
+	logger *zap.Logger
+}
+
+func NewPetAttributeRepository(
+	db *gorm.DB,
+	logger *zap.Logger,
+) PetAttributeRepository {
+	return &petAttributeRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// WithTx returns a copy of the repository running on the transaction
+func (r *petAttributeRepository) WithTx(tx *gorm.DB) PetAttributeRepository {
+	return &petAttributeRepository{
+		db:     tx,
+		logger: r.logger,
+	}
+}
+
+// GetTypeByName returns the pet type, gorm.ErrRecordNotFound when there is none
+func (r *petAttributeRepository) GetTypeByName(ctx context.Context, name string) (*dbModels.PetType, error) {
+	var petType dbModels.PetType
+	err := r.db.WithContext(ctx).Where("name = ?", NormalizeName(name)).Order("id").First(&petType).Error
+	if err != nil {
+		r.logger.Error("getting pet type", zap.Error(err), zap.String("name", name))
+		return nil, err
+	}
+	return &petType, nil
+}
+
+// GetAgeRangeByName returns the age range of the pet type, gorm.ErrRecordNotFound when there is none
+func (r *petAttributeRepository) GetAgeRangeByName(ctx context.Context, petTypeID, name string) (*dbModels.PetAgeRange, error) {
+	var ageRange dbModels.PetAgeRange
+	if err := r.getByName(ctx, &ageRange, "pet age range", petTypeID, name); err != nil {
+		return nil, err
+	}
+	return &ageRange, nil
+}
+
+// GetSizeByName returns the size of the pet type, gorm.ErrRecordNotFound when there is none
+func (r *petAttributeRepository) GetSizeByName(ctx context.Context, petTypeID, name string) (*dbModels.PetSize, error) {
+	var size dbModels.PetSize
+	if err := r.getByName(ctx, &size, "pet size", petTypeID, name); err != nil {
+		return nil, err
+	}
+	return &size, nil
+}
+
+// GetConditionByName returns the condition of the pet type, gorm.ErrRecordNotFound when there is none
+func (r *petAttributeRepository) GetConditionByName(ctx context.Context, petTypeID, name string) (*dbModels.PetCondition, error) {
+	var condition dbModels.PetCondition
+	if err := r.getByName(ctx, &condition, "pet condition", petTypeID, name); err != nil {
+		return nil, err
+	}
+	return &condition, nil
+}
+
+// getByName loads the attribute of the pet type with the name into dest, a pointer to an
+// attribute model
+func (r *petAttributeRepository) getByName(ctx context.Context, dest any, attribute, petTypeID, name string) error {
+	err := r.db.WithContext(ctx).
+		Where("pet_type_id = ? AND name = ?", petTypeID, NormalizeName(name)).
+		Order("id").
+		First(dest).Error
+	if err != nil {
+		r.logger.Error("getting "+attribute, zap.Error(err), zap.String("pet_type_id", petTypeID), zap.String("name", name))
+		return err
+	}
+
+	return nil
+}
+
+// NormalizeName returns a pet type or attribute name the way it is stored, trimmed and in
+// lower case
+func NormalizeName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	dbModels "appa_subscriptions/pkg/db/models"
+)
+
+// PlanRepository reads plans. WithTx returns a repository running on a transaction.
+type PlanRepository interface {
+	WithTx(tx *gorm.DB) PlanRepository
+	GetByID(ctx context.Context, planID string) (*dbModels.Plan, error)
+	GetByShopifyID(ctx context.Context, productID string) (*dbModels.Plan, error)
+}
+
+type planRepository struct {
+	db     *gorm.DB
+	logger *zap.Logger
+}
+
+func NewPlanRepository(
+	db *gorm.DB,
+	logger *zap.Logger,
+) PlanRepository {
+	return &planRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// WithTx returns a copy of the repository running on the transaction
+func (r *planRepository) WithTx(tx *gorm.DB) PlanRepository {
+	return &planRepository{
+		db:     tx,
+		logger: r.logger,
+	}
+}
+
+// GetByID returns the plan, gorm.ErrRecordNotFound when there is none
+func (r *planRepository) GetByID(ctx context.Context, planID string) (*dbModels.Plan, error) {
+	var plan dbModels.Plan
+	if err := r.db.WithContext(ctx).Where("id = ?", planID).First(&plan).Error; err != nil {
+		r.logger.Error("getting plan", zap.Error(err), zap.String("plan_id", planID))
+		return nil, err
+	}
+
+	return &plan, nil
+}
+
+// GetByShopifyID returns the plan of a Shopify product, gorm.ErrRecordNotFound when there is none
+func (r *planRepository) GetByShopifyID(ctx context.Context, productID string) (*dbModels.Plan, error) {
+	var plan dbModels.Plan
+	if err := r.db.WithContext(ctx).Where(dbModels.Plan{ShopifyID: productID}).First(&plan).Error; err != nil {
+		r.logger.Error("getting plan by shopify id", zap.Error(err), zap.String("product_id", productID))
+		return nil, err
+	}
+
+	return &plan, nil
+}
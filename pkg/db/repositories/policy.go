@@ -0,0 +1,215 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	dbModels "appa_subscriptions/pkg/db/models"
+	"appa_subscriptions/pkg/money"
+)
+
+// Policy states the billing queries filter on
+const (
+	policyStatusActive         = "active"
+	policyStatusPendingPayment = "payment_pending"
+)
+
+// PolicyRepository reads and writes policies and the installments billing them. WithTx returns
+// a repository running on a transaction.
+type PolicyRepository interface {
+	WithTx(tx *gorm.DB) PolicyRepository
+	Create(ctx context.Context, policy *dbModels.Policy) error
+	FindDueForBilling(ctx context.Context, date time.Time) ([]dbModels.Policy, error)
+	FindByUser(ctx context.Context, userID string) ([]dbModels.Policy, error)
+	FindByInstallments(ctx context.Context, installmentIDs []string) ([]dbModels.Policy, error)
+	FindAwaitingPayment(ctx context.Context) ([]dbModels.PolicyPayment, error)
+	PlanSubtotal(ctx context.Context, policyIDs []string) (money.Amount, error)
+	UpdateStatus(ctx context.Context, policyIDs []string, status string) error
+	AdvanceNextPayment(ctx context.Context, policyIDs []string, status string) error
+	LinkInstallment(ctx context.Context, policyIDs []string, installmentID string) error
+}
+
+type policyRepository struct {
+	db     *gorm.DB
+	loc    *time.Location
+	logger *zap.Logger
+}
+
+func NewPolicyRepository(
+	db *gorm.DB,
+	loc *time.Location,
+	logger *zap.Logger,
+) PolicyRepository {
+	return &policyRepository{
+		db:     db,
+		loc:    loc,
+		logger: logger,
+	}
+}
+
+// WithTx returns a copy of the repository running on the transaction
+func (r *policyRepository) WithTx(tx *gorm.DB) PolicyRepository {
+	return &policyRepository{
+		db:     tx,
+		loc:    r.loc,
+		logger: r.logger,
+	}
+}
+
+// Create stores a new policy
+func (r *policyRepository) Create(ctx context.Context, policy *dbModels.Policy) error {
+	if err := r.db.WithContext(ctx).Create(policy).Error; err != nil {
+		r.logger.Error("creating policy", zap.Error(err), zap.String("user_id", policy.UserID))
+		return err
+	}
+
+	return nil
+}
+
+// FindDueForBilling returns the active manual policies whose next payment is due by the date,
+// with their pet and user
+func (r *policyRepository) FindDueForBilling(ctx context.Context, date time.Time) ([]dbModels.Policy, error) {
+	var policies []dbModels.Policy
+	err := r.db.WithContext(ctx).
+		Select("policies.*").
+		InnerJoins("Pet", r.db.Select("Name").Model(&dbModels.Pet{})).
+		InnerJoins("User", r.db.Select("ID", "ShopifyID", "Email", "Name").Model(&dbModels.User{})).
+		Where("next_payment <= ? AND status = ? AND is_manual = ?", date, policyStatusActive, true).
+		Find(&policies).Error
+	if err != nil {
+		r.logger.Error("getting policies due for billing", zap.Error(err), zap.Time("date", date))
+		return nil, err
+	}
+
+	return policies, nil
+}
+
+// FindByUser returns the policies of the user with their pet, oldest first
+func (r *policyRepository) FindByUser(ctx context.Context, userID string) ([]dbModels.Policy, error) {
+	var policies []dbModels.Policy
+	err := r.db.WithContext(ctx).
+		Preload("Pet").
+		Where("user_id = ?", userID).
+		Order("created_at").
+		Find(&policies).Error
+	if err != nil {
+		r.logger.Error("getting policies of user", zap.Error(err), zap.String("user_id", userID))
+		return nil, err
+	}
+
+	return policies, nil
+}
+
+// FindByInstallments returns the policies billed in the installments
+func (r *policyRepository) FindByInstallments(ctx context.Context, installmentIDs []string) ([]dbModels.Policy, error) {
+	var policies []dbModels.Policy
+	err := r.db.WithContext(ctx).
+		Where("id IN (?)", r.db.Model(&dbModels.PolicyPayment{}).
+			Select("policy_id").
+			Where("payment_installment_id IN ?", installmentIDs)).
+		Find(&policies).Error
+	if err != nil {
+		r.logger.Error("getting policies of installments", zap.Error(err), zap.Strings("installment_ids", installmentIDs))
+		return nil, err
+	}
+
+	return policies, nil
+}
+
+// FindAwaitingPayment returns the installments of the manual policies waiting for their payment,
+// with the policy, its user and pet
+func (r *policyRepository) FindAwaitingPayment(ctx context.Context) ([]dbModels.PolicyPayment, error) {
+	var policyPayments []dbModels.PolicyPayment
+	err := r.db.WithContext(ctx).
+		Select("policies_payments.*").
+		InnerJoins("PaymentInstallment", r.db.Select("ShopifyOrderID").Model(&dbModels.PaymentInstallment{})).
+		InnerJoins("Policy", r.db.Select("ID", "NextPayment").Where(&dbModels.Policy{
+			Status:   policyStatusPendingPayment,
+			IsManual: true,
+		})).
+		Joins("Policy.User", r.db.Select("Email", "Name").Model(&dbModels.User{})).
+		Joins("Policy.Pet", r.db.Select("Name").Model(&dbModels.Pet{})).
+		Find(&policyPayments).Error
+	if err != nil {
+		r.logger.Error("getting policies awaiting payment", zap.Error(err))
+		return nil, err
+	}
+
+	return policyPayments, nil
+}
+
+// PlanSubtotal returns the monthly price of the plans of the policies, at their current matrix
+// price or the plan price
+func (r *policyRepository) PlanSubtotal(ctx context.Context, policyIDs []string) (money.Amount, error) {
+	var subtotal money.Amount
+	err := r.db.WithContext(ctx).
+		Table("policies").
+		Select("COALESCE(SUM(COALESCE(pp.monthly_price, plans.monthly_price)), 0)").
+		Joins("JOIN plans ON plans.id = policies.plan_id").
+		Joins("LEFT JOIN plan_prices pp ON pp.shopify_variant_id = regexp_replace(policies.shopify_id, '^.*/', '') AND pp.effective_to IS NULL").
+		Where("policies.id IN ?", policyIDs).
+		Scan(&subtotal).Error
+	if err != nil {
+		r.logger.Error("getting plan subtotal of policies", zap.Error(err), zap.Strings("policy_ids", policyIDs))
+		return money.Zero, err
+	}
+
+	return subtotal, nil
+}
+
+// UpdateStatus sets the status of the policies
+func (r *policyRepository) UpdateStatus(ctx context.Context, policyIDs []string, status string) error {
+	err := r.db.WithContext(ctx).
+		Model(&dbModels.Policy{}).
+		Where("id IN ?", policyIDs).
+		Update("status", status).Error
+	if err != nil {
+		r.logger.Error("updating policies status", zap.Error(err), zap.Strings("policy_ids", policyIDs), zap.String("status", status))
+		return err
+	}
+
+	return nil
+}
+
+// AdvanceNextPayment moves the next payment of the policies a month ahead and sets their status
+func (r *policyRepository) AdvanceNextPayment(ctx context.Context, policyIDs []string, status string) error {
+	err := r.db.WithContext(ctx).
+		Model(&dbModels.Policy{}).
+		Where("id IN ?", policyIDs).
+		Updates(map[string]any{
+			"next_payment": gorm.Expr("next_payment + interval '1 month'"),
+			"status":       status,
+		}).Error
+	if err != nil {
+		r.logger.Error("advancing policies next payment", zap.Error(err), zap.Strings("policy_ids", policyIDs))
+		return err
+	}
+
+	return nil
+}
+
+// LinkInstallment records that the installment bills the policies
+func (r *policyRepository) LinkInstallment(ctx context.Context, policyIDs []string, installmentID string) error {
+	if len(policyIDs) == 0 {
+		return nil
+	}
+
+	policyPayments := make([]dbModels.PolicyPayment, 0, len(policyIDs))
+	for _, policyID := range policyIDs {
+		policyPayments = append(policyPayments, dbModels.PolicyPayment{
+			PolicyID:             policyID,
+			PaymentInstallmentID: installmentID,
+			CreatedAt:            time.Now().In(r.loc),
+		})
+	}
+
+	if err := r.db.WithContext(ctx).Create(&policyPayments).Error; err != nil {
+		r.logger.Error("creating policy payments", zap.Error(err), zap.String("installment_id", installmentID))
+		return err
+	}
+
+	return nil
+}
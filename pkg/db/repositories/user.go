@@ -0,0 +1,91 @@
+package repositories
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	dbModels "appa_subscriptions/pkg/db/models"
+)
+
+// UserRepository reads and writes users. WithTx returns a repository running on a transaction.
+type UserRepository interface {
+	WithTx(tx *gorm.DB) UserRepository
+	EmailExists(ctx context.Context, email string) (bool, error)
+	FindByEmail(ctx context.Context, email string) (*dbModels.User, error)
+	FirstOrCreateByShopifyID(ctx context.Context, user *dbModels.User) error
+	Save(ctx context.Context, user *dbModels.User) error
+}
+
+type userRepository struct {
+	db     *gorm.DB
+	logger *zap.Logger
+}
+
+func NewUserRepository(
+	db *gorm.DB,
+	logger *zap.Logger,
+) UserRepository {
+	return &userRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// WithTx returns a copy of the repository running on the transaction
+func (r *userRepository) WithTx(tx *gorm.DB) UserRepository {
+	return &userRepository{
+		db:     tx,
+		logger: r.logger,
+	}
+}
+
+// EmailExists reports whether a user has the email
+func (r *userRepository) EmailExists(ctx context.Context, email string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&dbModels.User{}).Where("email = ?", email).Count(&count).Error
+	if err != nil {
+		r.logger.Error("checking user email", zap.Error(err), zap.String("email", email))
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// FindByEmail returns the user with the email and their pets, or nil when there is none
+func (r *userRepository) FindByEmail(ctx context.Context, email string) (*dbModels.User, error) {
+	var users []dbModels.User
+	err := r.db.WithContext(ctx).Preload("Pets").Where("email = ?", email).Limit(1).Find(&users).Error
+	if err != nil {
+		r.logger.Error("getting user by email", zap.Error(err), zap.String("email", email))
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, nil
+	}
+
+	return &users[0], nil
+}
+
+// FirstOrCreateByShopifyID loads the user with the Shopify customer id of user into it, or
+// creates user when there is none
+func (r *userRepository) FirstOrCreateByShopifyID(ctx context.Context, user *dbModels.User) error {
+	err := r.db.WithContext(ctx).Where(dbModels.User{ShopifyID: user.ShopifyID}).FirstOrCreate(user).Error
+	if err != nil {
+		r.logger.Error("creating user", zap.Error(err), zap.String("shopify_id", user.ShopifyID))
+		return err
+	}
+
+	return nil
+}
+
+// Save updates every field of the user
+func (r *userRepository) Save(ctx context.Context, user *dbModels.User) error {
+	if err := r.db.WithContext(ctx).Save(user).Error; err != nil {
+		r.logger.Error("updating user", zap.Error(err), zap.String("user_id", user.ID))
+		return err
+	}
+
+	return nil
+}
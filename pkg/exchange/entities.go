@@ -0,0 +1,33 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Currencies the store bills in
+const (
+	USD = "USD"
+	VES = "VES"
+)
+
+// ErrRateNotAvailable is returned when a source has no rate for the requested date
+var ErrRateNotAvailable = errors.New("exchange rate not available")
+
+// Rate is the official price of one unit of Base in Quote on Date
+type Rate struct {
+	Base   string
+	Quote  string
+	Value  float64
+	Date   time.Time
+	Source string
+}
+
+// Source loads official exchange rates
+type Source interface {
+	// Name identifies the source on the stored rates
+	Name() string
+	// Rate returns the rate in force on the date
+	Rate(ctx context.Context, base, quote string, date time.Time) (*Rate, error)
+}
@@ -4,12 +4,16 @@ import (
 	"appa_subscriptions/internal/models"
 	"fmt"
 	"strings"
+	"time"
 )
 
 const (
 	recurringAppleOrderTagPrefix = "appstle_subscription_recurring_order"
 )
 
+// birthdayLayouts are the date formats accepted for pet birthdays
+var birthdayLayouts = []string{"2006-01-02", "02/01/2006", "02-01-2006", time.RFC3339}
+
 // FindRecurringAppleFirstOrderID
 func FindRecurringAppleFirstOrderID(tagsStr string) *string {
 	tags := strings.SplitSeq(tagsStr, ",")
@@ -42,3 +46,40 @@ func GetVarsForConfirmationOrderEmail(
 
 	return vars
 }
+
+// ParseBirthday parses a pet birthday in any of the accepted formats
+func ParseBirthday(birthday string, loc *time.Location) (time.Time, error) {
+	birthday = strings.TrimSpace(birthday)
+	for _, layout := range birthdayLayouts {
+		if date, err := time.ParseInLocation(layout, birthday, loc); err == nil {
+			return date, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid birthday %q", birthday)
+}
+
+// AgeInMonths returns the number of whole months between the birthday and the given date
+func AgeInMonths(birthday, date time.Time) int {
+	months := (date.Year()-birthday.Year())*12 + int(date.Month()) - int(birthday.Month())
+	if date.Day() < birthday.Day() {
+		months--
+	}
+
+	return months
+}
+
+// ParseNeutered parses the neutered answer of the pet form, nil when it is unknown
+func ParseNeutered(neutered string) *bool {
+	var value bool
+	switch strings.ToLower(strings.TrimSpace(neutered)) {
+	case "true", "yes", "si", "sí", "1":
+		value = true
+	case "false", "no", "0":
+		value = false
+	default:
+		return nil
+	}
+
+	return &value
+}
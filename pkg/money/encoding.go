@@ -0,0 +1,94 @@
+package money
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// MarshalJSON encodes the amount as a JSON number with Scale decimals, like 1250.00
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON decodes a JSON number or a string holding one, Shopify sends amounts as strings
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	s := string(data)
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+	}
+
+	parsed, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// UnmarshalParam decodes an amount from a form or query parameter
+func (a *Amount) UnmarshalParam(param string) error {
+	parsed, err := Parse(param)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// Scan reads a Postgres numeric
+func (a *Amount) Scan(value any) error {
+	var (
+		parsed Amount
+		err    error
+	)
+
+	switch v := value.(type) {
+	case nil:
+		parsed = Zero
+	case []byte:
+		parsed, err = Parse(string(v))
+	case string:
+		parsed, err = Parse(v)
+	case int64:
+		parsed = FromInt(v)
+	case float64:
+		parsed, err = Parse(strconv.FormatFloat(v, 'f', -1, 64))
+	default:
+		return fmt.Errorf("%w: cannot scan %T", ErrInvalidAmount, value)
+	}
+	if err != nil {
+		return err
+	}
+
+	*a = parsed
+	return nil
+}
+
+// Value writes the amount as a decimal string so numeric columns store it exactly
+func (a Amount) Value() (driver.Value, error) {
+	return a.String(), nil
+}
+
+// GormDataType maps amounts to Postgres numeric columns
+func (Amount) GormDataType() string {
+	return "numeric"
+}
+
+// ValidationValue exposes amounts to the request validator as numbers, so binding tags like
+// gt=0 apply to them
+func ValidationValue(field reflect.Value) any {
+	if amount, ok := field.Interface().(Amount); ok {
+		return amount.Float64()
+	}
+	return nil
+}
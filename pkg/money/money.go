@@ -0,0 +1,312 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Scale is the number of decimals kept by an Amount, the precision of the store currencies
+const Scale = 2
+
+const unit = 100
+
+var (
+	// ErrInvalidAmount is returned when a string is not a decimal amount
+	ErrInvalidAmount = errors.New("invalid money amount")
+	// ErrPrecision is returned when an amount has more decimals than Scale
+	ErrPrecision = errors.New("money amount has more than 2 decimals")
+	// ErrOverflow is returned when an amount does not fit in an Amount
+	ErrOverflow = errors.New("money amount out of range")
+)
+
+// Zero is the zero amount
+var Zero = Amount{}
+
+// Amount is a fixed-point decimal amount of money with Scale decimals. The zero value is zero.
+// Amounts carry no currency, the record holding them does.
+type Amount struct {
+	cents int64
+}
+
+// FromCents returns the amount of the given number of hundredths
+func FromCents(cents int64) Amount {
+	return Amount{cents: cents}
+}
+
+// FromInt returns the amount of the given number of units
+func FromInt(units int64) Amount {
+	return Amount{cents: units * unit}
+}
+
+// FromFloat returns the amount nearest to f, rounding half away from zero. It is meant for
+// values that are floats by nature, like a rate times an amount; never for parsing.
+func FromFloat(f float64) Amount {
+	return Amount{cents: int64(math.Round(f * unit))}
+}
+
+// Parse reads a decimal amount like "12", "-3.5" or "1250.00" exactly. Shopify amounts and
+// Postgres numerics use this format. Trailing zeros past Scale are accepted, other digits are not.
+func Parse(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Zero, fmt.Errorf("%w: empty", ErrInvalidAmount)
+	}
+
+	negative := false
+	switch s[0] {
+	case '-':
+		negative = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	whole, fraction, _ := strings.Cut(s, ".")
+	if whole == "" && fraction == "" {
+		return Zero, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	if !isDigits(whole) || !isDigits(fraction) {
+		return Zero, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	if len(fraction) > Scale {
+		if strings.Trim(fraction[Scale:], "0") != "" {
+			return Zero, fmt.Errorf("%w: %q", ErrPrecision, s)
+		}
+		fraction = fraction[:Scale]
+	}
+	fraction += strings.Repeat("0", Scale-len(fraction))
+
+	if whole == "" {
+		whole = "0"
+	}
+	cents, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return Zero, fmt.Errorf("%w: %q", ErrOverflow, s)
+	}
+	if negative {
+		cents = -cents
+	}
+
+	return Amount{cents: cents}, nil
+}
+
+// MustParse is like Parse but panics on invalid amounts. It is meant for constants.
+func MustParse(s string) Amount {
+	a, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+// Cents returns the amount in hundredths
+func (a Amount) Cents() int64 {
+	return a.cents
+}
+
+// Float64 returns the nearest float to the amount, for display and float-based math only
+func (a Amount) Float64() float64 {
+	return float64(a.cents) / unit
+}
+
+// String formats the amount with exactly Scale decimals, like "1250.00"
+func (a Amount) String() string {
+	cents := a.cents
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+	}
+	abs := uint64(cents)
+	if cents < 0 {
+		abs = uint64(-cents)
+	}
+
+	return fmt.Sprintf("%s%d.%02d", sign, abs/unit, abs%unit)
+}
+
+// Add returns a + b
+func (a Amount) Add(b Amount) Amount {
+	return Amount{cents: a.cents + b.cents}
+}
+
+// Sub returns a - b
+func (a Amount) Sub(b Amount) Amount {
+	return Amount{cents: a.cents - b.cents}
+}
+
+// Neg returns -a
+func (a Amount) Neg() Amount {
+	return Amount{cents: -a.cents}
+}
+
+// Abs returns the absolute value of a
+func (a Amount) Abs() Amount {
+	if a.cents < 0 {
+		return a.Neg()
+	}
+	return a
+}
+
+// Mul returns a times n
+func (a Amount) Mul(n int64) Amount {
+	return Amount{cents: a.cents * n}
+}
+
+// MulRatio returns a * num / den rounded half away from zero, the way prorations and rescaled
+// balances are computed. It returns zero when den is zero.
+func (a Amount) MulRatio(num, den int64) Amount {
+	if den == 0 {
+		return Zero
+	}
+	return Amount{cents: roundRat(new(big.Rat).SetFrac(
+		new(big.Int).Mul(big.NewInt(a.cents), big.NewInt(num)),
+		big.NewInt(den),
+	))}
+}
+
+// Rescale returns a * by / of rounded half away from zero, like a balance rescaled from one
+// plan limit to another. It returns zero when of is zero.
+func (a Amount) Rescale(by, of Amount) Amount {
+	return a.MulRatio(by.cents, of.cents)
+}
+
+// Percent returns pct percent of a rounded half away from zero. The percentage is read from
+// its shortest decimal representation, so 12.5 is exactly twelve and a half percent.
+func (a Amount) Percent(pct float64) Amount {
+	return a.mulDecimal(pct, 100)
+}
+
+// Convert returns a at the given exchange rate rounded half away from zero. The rate is read
+// from its shortest decimal representation.
+func (a Amount) Convert(rate float64) Amount {
+	return a.mulDecimal(rate, 1)
+}
+
+// ConvertInverse returns a converted back at the given exchange rate, a / rate rounded half
+// away from zero. It returns zero when the rate is not positive.
+func (a Amount) ConvertInverse(rate float64) Amount {
+	r, ok := decimalRat(rate)
+	if !ok || r.Sign() <= 0 {
+		return Zero
+	}
+	return Amount{cents: roundRat(r.Quo(new(big.Rat).SetInt64(a.cents), r))}
+}
+
+// mulDecimal returns a * f / den, reading f from its shortest decimal representation
+func (a Amount) mulDecimal(f float64, den int64) Amount {
+	r, ok := decimalRat(f)
+	if !ok {
+		return Zero
+	}
+	r.Mul(r, new(big.Rat).SetInt64(a.cents))
+	r.Quo(r, new(big.Rat).SetInt64(den))
+	return Amount{cents: roundRat(r)}
+}
+
+// Cmp compares a and b and returns -1, 0 or +1
+func (a Amount) Cmp(b Amount) int {
+	switch {
+	case a.cents < b.cents:
+		return -1
+	case a.cents > b.cents:
+		return 1
+	}
+	return 0
+}
+
+// Equal reports whether a == b
+func (a Amount) Equal(b Amount) bool {
+	return a.cents == b.cents
+}
+
+// LessThan reports whether a < b
+func (a Amount) LessThan(b Amount) bool {
+	return a.cents < b.cents
+}
+
+// GreaterThan reports whether a > b
+func (a Amount) GreaterThan(b Amount) bool {
+	return a.cents > b.cents
+}
+
+// IsZero reports whether a is zero
+func (a Amount) IsZero() bool {
+	return a.cents == 0
+}
+
+// IsPositive reports whether a > 0
+func (a Amount) IsPositive() bool {
+	return a.cents > 0
+}
+
+// IsNegative reports whether a < 0
+func (a Amount) IsNegative() bool {
+	return a.cents < 0
+}
+
+// Min returns the smaller of a and b
+func Min(a, b Amount) Amount {
+	if b.cents < a.cents {
+		return b
+	}
+	return a
+}
+
+// Max returns the larger of a and b
+func Max(a, b Amount) Amount {
+	if b.cents > a.cents {
+		return b
+	}
+	return a
+}
+
+// Sum returns the total of the amounts
+func Sum(amounts ...Amount) Amount {
+	var total Amount
+	for _, amount := range amounts {
+		total = total.Add(amount)
+	}
+	return total
+}
+
+// Ptr returns a pointer to a copy of a, for nullable fields
+func Ptr(a Amount) *Amount {
+	return &a
+}
+
+// decimalRat reads f from its shortest decimal representation, so 0.1 is exactly one tenth
+func decimalRat(f float64) (*big.Rat, bool) {
+	return new(big.Rat).SetString(strconv.FormatFloat(f, 'f', -1, 64))
+}
+
+// roundRat rounds r to the nearest integer, half away from zero
+func roundRat(r *big.Rat) int64 {
+	num := new(big.Int).Set(r.Num())
+	den := r.Denom()
+
+	negative := num.Sign() < 0
+	num.Abs(num)
+
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if rem.Mul(rem, big.NewInt(2)).Cmp(den) >= 0 {
+		quo.Add(quo, big.NewInt(1))
+	}
+	if negative {
+		quo.Neg(quo)
+	}
+
+	return quo.Int64()
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
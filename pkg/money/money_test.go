@@ -0,0 +1,321 @@
+package money_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"appa_subscriptions/pkg/money"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int64
+		wantErr error
+	}{
+		{"12", 1200, nil},
+		{"1250.00", 125000, nil},
+		{"-3.5", -350, nil},
+		{"+3.5", 350, nil},
+		{".75", 75, nil},
+		{"4.", 400, nil},
+		{" 9.99 ", 999, nil},
+		{"1.500", 150, nil},
+		{"-0.01", -1, nil},
+		{"1.005", 0, money.ErrPrecision},
+		{"0.001", 0, money.ErrPrecision},
+		{"92233720368547758.07", 9223372036854775807, nil},
+		{"92233720368547758.08", 0, money.ErrOverflow},
+		{"", 0, money.ErrInvalidAmount},
+		{"-", 0, money.ErrInvalidAmount},
+		{".", 0, money.ErrInvalidAmount},
+		{"1,50", 0, money.ErrInvalidAmount},
+		{"1.2.3", 0, money.ErrInvalidAmount},
+		{"--1", 0, money.ErrInvalidAmount},
+		{"1e3", 0, money.ErrInvalidAmount},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := money.Parse(tt.in)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Parse(%q) error %v, want %v", tt.in, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.in, err)
+			}
+			if got.Cents() != tt.want {
+				t.Errorf("Parse(%q) = %d cents, want %d", tt.in, got.Cents(), tt.want)
+			}
+		})
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		cents int64
+		want  string
+	}{
+		{0, "0.00"},
+		{5, "0.05"},
+		{125000, "1250.00"},
+		{-5, "-0.05"},
+		{-350, "-3.50"},
+		{-125099, "-1250.99"},
+	}
+	for _, tt := range tests {
+		if got := money.FromCents(tt.cents).String(); got != tt.want {
+			t.Errorf("FromCents(%d).String() = %q, want %q", tt.cents, got, tt.want)
+		}
+	}
+}
+
+func TestMulRatio(t *testing.T) {
+	tests := []struct {
+		amount   string
+		num, den int64
+		want     string
+	}{
+		{"10.00", 1, 3, "3.33"},
+		{"10.00", 2, 3, "6.67"},
+		{"0.05", 1, 2, "0.03"},
+		{"-0.05", 1, 2, "-0.03"},
+		{"0.03", 1, 2, "0.02"},
+		{"30.00", 15, 30, "15.00"},
+		{"10.00", 1, 0, "0.00"},
+	}
+	for _, tt := range tests {
+		got := money.MustParse(tt.amount).MulRatio(tt.num, tt.den)
+		if got.String() != tt.want {
+			t.Errorf("%s.MulRatio(%d, %d) = %s, want %s", tt.amount, tt.num, tt.den, got, tt.want)
+		}
+	}
+}
+
+func TestRescale(t *testing.T) {
+	tests := []struct {
+		amount, by, of string
+		want           string
+	}{
+		{"100.00", "50.00", "200.00", "25.00"},
+		{"100.00", "1000.00", "3000.00", "33.33"},
+		{"0.01", "1.00", "2.00", "0.01"},
+		{"-0.01", "1.00", "2.00", "-0.01"},
+		{"100.00", "50.00", "0", "0.00"},
+	}
+	for _, tt := range tests {
+		got := money.MustParse(tt.amount).Rescale(money.MustParse(tt.by), money.MustParse(tt.of))
+		if got.String() != tt.want {
+			t.Errorf("%s.Rescale(%s, %s) = %s, want %s", tt.amount, tt.by, tt.of, got, tt.want)
+		}
+	}
+}
+
+func TestPercent(t *testing.T) {
+	tests := []struct {
+		amount string
+		pct    float64
+		want   string
+	}{
+		{"10.00", 12.5, "1.25"},
+		{"200.00", 10, "20.00"},
+		{"0.10", 5, "0.01"},
+		{"-0.10", 5, "-0.01"},
+		{"0.30", 0.1, "0.00"},
+		{"19.99", 100, "19.99"},
+	}
+	for _, tt := range tests {
+		got := money.MustParse(tt.amount).Percent(tt.pct)
+		if got.String() != tt.want {
+			t.Errorf("%s.Percent(%v) = %s, want %s", tt.amount, tt.pct, got, tt.want)
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		amount string
+		rate   float64
+		want   string
+	}{
+		{"1.00", 36.5, "36.50"},
+		{"10.00", 0.1, "1.00"},
+		{"0.01", 0.5, "0.01"},
+		{"-0.01", 0.5, "-0.01"},
+		{"25.00", 36.1234, "903.09"},
+	}
+	for _, tt := range tests {
+		got := money.MustParse(tt.amount).Convert(tt.rate)
+		if got.String() != tt.want {
+			t.Errorf("%s.Convert(%v) = %s, want %s", tt.amount, tt.rate, got, tt.want)
+		}
+	}
+}
+
+func TestConvertInverse(t *testing.T) {
+	tests := []struct {
+		amount string
+		rate   float64
+		want   string
+	}{
+		{"36.50", 36.5, "1.00"},
+		{"1.00", 3, "0.33"},
+		{"2.00", 3, "0.67"},
+		{"0.05", 10, "0.01"},
+		{"-0.05", 10, "-0.01"},
+		{"1.00", 0, "0.00"},
+		{"1.00", -36.5, "0.00"},
+	}
+	for _, tt := range tests {
+		got := money.MustParse(tt.amount).ConvertInverse(tt.rate)
+		if got.String() != tt.want {
+			t.Errorf("%s.ConvertInverse(%v) = %s, want %s", tt.amount, tt.rate, got, tt.want)
+		}
+	}
+}
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   any
+		want    string
+		wantErr error
+	}{
+		{"string", "1250.00", "1250.00", nil},
+		{"bytes", []byte("-3.5"), "-3.50", nil},
+		{"float", 12.5, "12.50", nil},
+		{"float tenth", 0.1, "0.10", nil},
+		{"int", int64(7), "7.00", nil},
+		{"null", nil, "0.00", nil},
+		{"string precision", "1.005", "", money.ErrPrecision},
+		{"float precision", 1.005, "", money.ErrPrecision},
+		{"unsupported type", true, "", money.ErrInvalidAmount},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got money.Amount
+			err := got.Scan(tt.value)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Scan(%v) error %v, want %v", tt.value, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Scan(%v): %v", tt.value, err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("Scan(%v) = %s, want %s", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValueRoundTrip(t *testing.T) {
+	for _, in := range []string{"0", "0.05", "-0.05", "1250.00", "-1250.99"} {
+		amount := money.MustParse(in)
+
+		value, err := amount.Value()
+		if err != nil {
+			t.Fatalf("%s.Value(): %v", in, err)
+		}
+		var scanned money.Amount
+		if err := scanned.Scan(value); err != nil {
+			t.Fatalf("Scan(%v): %v", value, err)
+		}
+		if !scanned.Equal(amount) {
+			t.Errorf("%s: scanned %s back", in, scanned)
+		}
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		data    string
+		want    string
+		wantErr error
+	}{
+		{`12.5`, "12.50", nil},
+		{`"12.50"`, "12.50", nil},
+		{`"-0.05"`, "-0.05", nil},
+		{`-3`, "-3.00", nil},
+		{`"1.005"`, "", money.ErrPrecision},
+		{`"abc"`, "", money.ErrInvalidAmount},
+		{`""`, "", money.ErrInvalidAmount},
+	}
+	for _, tt := range tests {
+		t.Run(tt.data, func(t *testing.T) {
+			var got money.Amount
+			err := json.Unmarshal([]byte(tt.data), &got)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("unmarshal %s error %v, want %v", tt.data, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unmarshal %s: %v", tt.data, err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("unmarshal %s = %s, want %s", tt.data, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("null", func(t *testing.T) {
+		got := money.MustParse("9.99")
+		if err := json.Unmarshal([]byte(`null`), &got); err != nil {
+			t.Fatalf("unmarshal null: %v", err)
+		}
+		if got.String() != "9.99" {
+			t.Errorf("unmarshal null changed the amount to %s", got)
+		}
+	})
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	type body struct {
+		Amount money.Amount  `json:"amount"`
+		Refund *money.Amount `json:"refund"`
+	}
+
+	in := body{Amount: money.MustParse("-1250.05"), Refund: money.Ptr(money.MustParse("0.10"))}
+	data, err := json.Marshal(in)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if string(data) != `{"amount":-1250.05,"refund":0.10}` {
+		t.Errorf("marshal = %s", data)
+	}
+
+	var out body
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("unmarshal %s: %v", data, err)
+	}
+	if !out.Amount.Equal(in.Amount) || out.Refund == nil || !out.Refund.Equal(*in.Refund) {
+		t.Errorf("round trip of %s = %+v", data, out)
+	}
+}
+
+func TestUnmarshalParam(t *testing.T) {
+	var got money.Amount
+	if err := got.UnmarshalParam("7.25"); err != nil {
+		t.Fatalf("UnmarshalParam: %v", err)
+	}
+	if got.String() != "7.25" {
+		t.Errorf("UnmarshalParam(7.25) = %s", got)
+	}
+
+	for _, param := range []string{"", "7,25", "7.255"} {
+		before := got
+		if err := got.UnmarshalParam(param); err == nil {
+			t.Errorf("UnmarshalParam(%q): want an error", param)
+		}
+		if !got.Equal(before) {
+			t.Errorf("UnmarshalParam(%q) changed the amount to %s", param, got)
+		}
+	}
+}
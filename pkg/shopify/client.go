@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
@@ -91,3 +92,8 @@ func (g *GraphQLClient) Do(
 func GID(kind string, id string) string {
 	return fmt.Sprintf("gid://shopify/%s/%s", kind, id)
 }
+
+// LegacyID returns the numeric id of a Shopify GraphQL global ID
+func LegacyID(gid string) string {
+	return gid[strings.LastIndex(gid, "/")+1:]
+}
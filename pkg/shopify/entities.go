@@ -2,6 +2,10 @@ package shopify
 
 import (
 	"encoding/json"
+	"fmt"
+	"strings"
+
+	"appa_subscriptions/pkg/money"
 )
 
 type gqlRequest struct {
@@ -20,9 +24,20 @@ type gqlResponse struct {
 const (
 	orderKind          = "Order"
 	CustomerKind       = "Customer"
+	ProductKind        = "Product"
 	ProductVariantKind = "ProductVariant"
 )
 
+// Product option names used by the plan variants
+const (
+	AgeOptionName       = "Edad"
+	SizeOptionName      = "Tamaño"
+	ConditionOptionName = "Condición"
+)
+
+// ShopCurrencyCode is the currency the store sells in
+const ShopCurrencyCode = "USD"
+
 // GetOrderByIDResponse constructs a global ID for Shopify entities
 type GetOrderByIDResponse struct {
 	Order *Order `json:"order"`
@@ -63,6 +78,10 @@ type Order struct {
 	SubtotalPriceSet         ShopMoney     `json:"subtotalPriceSet"`
 	LineItems                LineItemsEdge `json:"lineItems"`
 	Customer                 Customer      `json:"customer"`
+	Tags                     []string      `json:"tags"`
+	// TaxesIncluded and BilledLineItems are only requested by the order search
+	TaxesIncluded   bool           `json:"taxesIncluded"`
+	BilledLineItems OrderLineItems `json:"billedLineItems"`
 }
 
 // LineItemsEdge represents the edge of line items in an order
@@ -87,18 +106,57 @@ type LineItem struct {
 type Variant struct {
 	ID              string           `json:"id"`
 	Title           string           `json:"title"`
+	Price           string           `json:"price,omitempty"`
 	SelectedOptions []SelectedOption `json:"selectedOptions"`
 }
 
 // SelectedOption represents a selected option for a product variant
 type SelectedOption struct {
-	Name  string `json:"name"`
-	Value string `json:"value"`
+	Name        string              `json:"name"`
+	Value       string              `json:"value"`
+	OptionValue *ProductOptionValue `json:"optionValue,omitempty"`
+}
+
+// Product represents a Shopify product with its options and variants
+type Product struct {
+	ID          string          `json:"id"`
+	Title       string          `json:"title"`
+	Status      string          `json:"status"`
+	Tags        []string        `json:"tags"`
+	Options     []ProductOption `json:"options"`
+	Variants    VariantsNodes   `json:"variants"`
+	AnnualLimit *Metafield      `json:"annualLimit"`
+}
+
+// ProductOption represents an option of a product, such as "Edad"
+type ProductOption struct {
+	ID           string               `json:"id"`
+	Name         string               `json:"name"`
+	OptionValues []ProductOptionValue `json:"optionValues"`
+}
+
+// ProductOptionValue represents one of the values of a product option
+type ProductOptionValue struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// VariantsNodes represents a list of variant nodes
+type VariantsNodes struct {
+	Nodes []Variant `json:"nodes"`
+}
+
+type GetProductsResponse struct {
+	Products struct {
+		Nodes    []Product `json:"nodes"`
+		PageInfo PageInfo  `json:"pageInfo"`
+	} `json:"products"`
 }
 
 // ShopMoney represents the total or subtotal price set of an order
 type ShopMoney struct {
-	ShopMoney ShopMoneyProps `json:"shopMoney"`
+	ShopMoney        ShopMoneyProps  `json:"shopMoney"`
+	PresentmentMoney *ShopMoneyProps `json:"presentmentMoney,omitempty"`
 }
 
 // ShopMoneyProps represents an amount of money in a specific currency
@@ -176,25 +234,144 @@ type GetVariantByIDResponse struct {
 
 type CreateOrderResponse struct {
 	OrderCreate struct {
-		Order OrderCreateResponse `json:"order"`
+		Order      *OrderCreateResponse `json:"order"`
+		UserErrors []UserErrors         `json:"userErrors"`
 	} `json:"orderCreate"`
-	UserErrors []UserErrors `json:"userErrors"`
 }
 
 type OrderCreateResponse struct {
-	ID            string    `json:"id"`
-	Name          string    `json:"name"`
-	StatusPageURL string    `json:"statusPageUrl"`
-	TotalPriceSet ShopMoney `json:"totalPriceSet"`
+	ID              string         `json:"id"`
+	Name            string         `json:"name"`
+	StatusPageURL   string         `json:"statusPageUrl"`
+	TotalPriceSet   ShopMoney      `json:"totalPriceSet"`
+	TaxesIncluded   bool           `json:"taxesIncluded"`
+	BilledLineItems OrderLineItems `json:"billedLineItems"`
+}
+
+// OrderLineItems are the line items of an order with the amounts Shopify billed for them
+type OrderLineItems struct {
+	Nodes []OrderLineItem `json:"nodes"`
+}
+
+// OrderLineItem is a billed line item. Custom line items have no variant.
+type OrderLineItem struct {
+	ID                   string         `json:"id"`
+	Title                string         `json:"title"`
+	Quantity             int            `json:"quantity"`
+	Variant              *Variant       `json:"variant"`
+	OriginalUnitPriceSet ShopMoney      `json:"originalUnitPriceSet"`
+	TotalDiscountSet     ShopMoney      `json:"totalDiscountSet"`
+	TaxLines             []OrderTaxLine `json:"taxLines"`
+}
+
+// OrderTaxLine is a tax charged on a line item
+type OrderTaxLine struct {
+	Title    string    `json:"title"`
+	Rate     float64   `json:"rate"`
+	PriceSet ShopMoney `json:"priceSet"`
 }
 
 type MarkOrderAsPaidResponse struct {
-	Order      Order       `json:"order"`
-	UserErrors *UserErrors `json:"userErrors"`
+	OrderMarkAsPaid struct {
+		Order      *Order       `json:"order"`
+		UserErrors []UserErrors `json:"userErrors"`
+	} `json:"orderMarkAsPaid"`
+}
+
+// Order cancel reasons accepted by Shopify
+const (
+	CancelReasonCustomer  = "CUSTOMER"
+	CancelReasonDeclined  = "DECLINED"
+	CancelReasonFraud     = "FRAUD"
+	CancelReasonInventory = "INVENTORY"
+	CancelReasonStaff     = "STAFF"
+	CancelReasonOther     = "OTHER"
+)
+
+// CancelOrderRequest represents the options to cancel an order
+type CancelOrderRequest struct {
+	OrderID        string
+	Reason         string
+	Refund         bool
+	Restock        bool
+	NotifyCustomer bool
+	StaffNote      string
+}
+
+type CancelOrderResponse struct {
+	OrderCancel struct {
+		Job        *Job         `json:"job"`
+		UserErrors []UserErrors `json:"orderCancelUserErrors"`
+	} `json:"orderCancel"`
+}
+
+// Job represents an asynchronous Shopify job
+type Job struct {
+	ID   string `json:"id"`
+	Done bool   `json:"done"`
+}
+
+// RefundOrderRequest represents the options to refund an order.
+// A nil Amount refunds every successful payment of the order.
+type RefundOrderRequest struct {
+	OrderID string
+	Amount  *money.Amount
+	Note    string
+	Notify  bool
+}
+
+type GetOrderTransactionsResponse struct {
+	Order *struct {
+		ID           string             `json:"id"`
+		Transactions []OrderTransaction `json:"transactions"`
+	} `json:"order"`
+}
+
+// OrderTransaction represents a payment transaction of an order
+type OrderTransaction struct {
+	ID        string    `json:"id"`
+	Kind      string    `json:"kind"`
+	Status    string    `json:"status"`
+	Gateway   string    `json:"gateway"`
+	AmountSet ShopMoney `json:"amountSet"`
+}
+
+type RefundCreateResponse struct {
+	RefundCreate struct {
+		Refund     *Refund      `json:"refund"`
+		UserErrors []UserErrors `json:"userErrors"`
+	} `json:"refundCreate"`
+}
+
+// Refund represents a refund created for an order
+type Refund struct {
+	ID               string    `json:"id"`
+	TotalRefundedSet ShopMoney `json:"totalRefundedSet"`
 }
 
 type UserErrors struct {
-	Message string `json:"message"`
+	Field   []string `json:"field,omitempty"`
+	Message string   `json:"message"`
+	Code    string   `json:"code,omitempty"`
+}
+
+// MutationError is returned when a Shopify mutation responds with userErrors
+type MutationError struct {
+	Mutation string
+	Errors   []UserErrors
+}
+
+func (e *MutationError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, userErr := range e.Errors {
+		if len(userErr.Field) > 0 {
+			messages = append(messages, fmt.Sprintf("%s: %s", strings.Join(userErr.Field, "."), userErr.Message))
+			continue
+		}
+		messages = append(messages, userErr.Message)
+	}
+
+	return fmt.Sprintf("shopify %s user errors: %s", e.Mutation, strings.Join(messages, "; "))
 }
 
 type CreateOrderInShopifyRequest struct {
@@ -208,11 +385,38 @@ type CreateOrderInput struct {
 	LineItems       []LineItemsNodeRequest `json:"lineItems"`
 	Note            string                 `json:"note,omitempty"`
 	FinancialStatus string                 `json:"financialStatus,omitempty"`
+	DiscountCode    *DiscountCodeInput     `json:"discountCode,omitempty"`
 }
 
+// LineItemsNodeRequest is a line item of a new order. Custom line items have no variant and
+// carry their own title and price.
 type LineItemsNodeRequest struct {
-	VariantID string `json:"variantId"`
-	Quantity  int    `json:"quantity"`
+	VariantID        string     `json:"variantId,omitempty"`
+	Quantity         int        `json:"quantity"`
+	Title            string     `json:"title,omitempty"`
+	PriceSet         *ShopMoney `json:"priceSet,omitempty"`
+	RequiresShipping *bool      `json:"requiresShipping,omitempty"`
+}
+
+// DiscountCodeInput is the discount code applied to a new order
+type DiscountCodeInput struct {
+	ItemFixedDiscountCode *FixedDiscountCodeInput `json:"itemFixedDiscountCode,omitempty"`
+}
+
+// FixedDiscountCodeInput is a fixed amount discount code
+type FixedDiscountCodeInput struct {
+	Code      string    `json:"code"`
+	AmountSet ShopMoney `json:"amountSet"`
+}
+
+// NewShopMoney builds a money amount in the shop currency
+func NewShopMoney(amount money.Amount) ShopMoney {
+	return ShopMoney{
+		ShopMoney: ShopMoneyProps{
+			Amount:       amount.String(),
+			CurrencyCode: ShopCurrencyCode,
+		},
+	}
 }
 
 type ShippingAddress struct {
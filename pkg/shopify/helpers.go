@@ -3,7 +3,7 @@ package shopify
 // GetDogDataAgeOption retrieves the "Edad" option value from the selected options
 func GetDogDataAgeOption(options []SelectedOption) string {
 	for _, option := range options {
-		if option.Name == "Edad" {
+		if option.Name == AgeOptionName {
 			return option.Value
 		}
 	}
@@ -14,7 +14,7 @@ func GetDogDataAgeOption(options []SelectedOption) string {
 // GetDogDataSizeOption retrieves the "Tamaño" option value from the selected options
 func GetDogDataSizeOption(options []SelectedOption) string {
 	for _, option := range options {
-		if option.Name == "Tamaño" {
+		if option.Name == SizeOptionName {
 			return option.Value
 		}
 	}
@@ -25,10 +25,21 @@ func GetDogDataSizeOption(options []SelectedOption) string {
 // GetDogDataConditionOption retrieves the "Condición" option value from the selected options
 func GetDogDataConditionOption(options []SelectedOption) string {
 	for _, option := range options {
-		if option.Name == "Condición" {
+		if option.Name == ConditionOptionName {
 			return option.Value
 		}
 	}
 
 	return ""
 }
+
+// GetSelectedOption retrieves the selected option with the given name
+func GetSelectedOption(options []SelectedOption, name string) *SelectedOption {
+	for i := range options {
+		if options[i].Name == name {
+			return &options[i]
+		}
+	}
+
+	return nil
+}
@@ -147,17 +147,228 @@ mutation orderCreate($order: OrderCreateOrderInput!, $options: OrderCreateOption
       id
       name
       statusPageUrl
+      taxesIncluded
 	  totalPriceSet {
 		shopMoney {
 			amount
 			currencyCode
 		}
+		presentmentMoney {
+			amount
+			currencyCode
+		}
 	  }
+      billedLineItems: lineItems(first: 50) {
+        nodes {
+          id
+          title
+          quantity
+          variant {
+            id
+          }
+          originalUnitPriceSet {
+            shopMoney {
+              amount
+              currencyCode
+            }
+          }
+          totalDiscountSet {
+            shopMoney {
+              amount
+              currencyCode
+            }
+          }
+          taxLines {
+            title
+            rate
+            priceSet {
+              shopMoney {
+                amount
+                currencyCode
+              }
+            }
+          }
+        }
+      }
     }
     userErrors {
       field
       message
+      code
     }
   }
 }
 `
+
+const getOrdersByQuery = `
+query ordersByQuery($query: String!, $first: Int!) {
+  orders(first: $first, query: $query, sortKey: CREATED_AT, reverse: true) {
+    nodes {
+      id
+      name
+      createdAt
+      displayFinancialStatus
+      tags
+      taxesIncluded
+      totalPriceSet {
+        shopMoney {
+          amount
+          currencyCode
+        }
+        presentmentMoney {
+          amount
+          currencyCode
+        }
+      }
+      billedLineItems: lineItems(first: 50) {
+        nodes {
+          id
+          title
+          quantity
+          variant {
+            id
+          }
+          originalUnitPriceSet {
+            shopMoney {
+              amount
+              currencyCode
+            }
+          }
+          totalDiscountSet {
+            shopMoney {
+              amount
+              currencyCode
+            }
+          }
+          taxLines {
+            title
+            rate
+            priceSet {
+              shopMoney {
+                amount
+                currencyCode
+              }
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+
+const markOrderAsPaid = `
+mutation orderMarkAsPaid($input: OrderMarkAsPaidInput!) {
+  orderMarkAsPaid(input: $input) {
+    order {
+      id
+      name
+      displayFinancialStatus
+      totalPriceSet {
+        shopMoney {
+          amount
+          currencyCode
+        }
+      }
+    }
+    userErrors {
+      field
+      message
+    }
+  }
+}`
+
+const cancelOrder = `
+mutation orderCancel($orderId: ID!, $reason: OrderCancelReason!, $refund: Boolean!, $restock: Boolean!, $notifyCustomer: Boolean, $staffNote: String) {
+  orderCancel(orderId: $orderId, reason: $reason, refund: $refund, restock: $restock, notifyCustomer: $notifyCustomer, staffNote: $staffNote) {
+    job {
+      id
+      done
+    }
+    orderCancelUserErrors {
+      field
+      message
+      code
+    }
+  }
+}`
+
+const getOrderTransactions = `
+query orderTransactions($id: ID!) {
+  order(id: $id) {
+    id
+    transactions {
+      id
+      kind
+      status
+      gateway
+      amountSet {
+        shopMoney {
+          amount
+          currencyCode
+        }
+      }
+    }
+  }
+}`
+
+const refundCreate = `
+mutation refundCreate($input: RefundInput!) {
+  refundCreate(input: $input) {
+    refund {
+      id
+      totalRefundedSet {
+        shopMoney {
+          amount
+          currencyCode
+        }
+      }
+    }
+    userErrors {
+      field
+      message
+    }
+  }
+}`
+
+const getProducts = `
+query products($query: String, $first: Int!, $after: String) {
+  products(first: $first, after: $after, query: $query) {
+    nodes {
+      id
+      title
+      status
+      tags
+      annualLimit: metafield(namespace: "custom", key: "annual_limit") {
+        key
+        value
+      }
+      options {
+        id
+        name
+        optionValues {
+          id
+          name
+        }
+      }
+      variants(first: 100) {
+        nodes {
+          id
+          title
+          price
+          selectedOptions {
+            name
+            value
+            optionValue {
+              id
+              name
+            }
+          }
+        }
+      }
+    }
+    pageInfo {
+      hasNextPage
+      endCursor
+    }
+  }
+}`
@@ -4,10 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 
 	"go.uber.org/zap"
+
+	"appa_subscriptions/pkg/money"
 )
 
 const (
@@ -22,6 +25,11 @@ type Repository interface {
 	GetVariantByID(ctx context.Context, gid string) (*Variant, error)
 	CreateOrder(ctx context.Context, input any) (*OrderCreateResponse, error)
 	GetUserData(ctx context.Context, gid string) (*User, error)
+	GetOrdersByQuery(ctx context.Context, query string, first int) ([]Order, error)
+	MarkOrderAsPaid(ctx context.Context, gid string) (*Order, error)
+	CancelOrder(ctx context.Context, req CancelOrderRequest) (*Job, error)
+	RefundOrder(ctx context.Context, req RefundOrderRequest) (*Refund, error)
+	GetProducts(ctx context.Context, query string) ([]Product, error)
 }
 
 // Repository is a Shopify API repository
@@ -150,10 +158,201 @@ func (r *repository) CreateOrder(
 		return nil, err
 	}
 
-	if resp.UserErrors != nil {
-		r.Logger.Error("failed to create draft order", zap.Any("errors", resp.UserErrors))
-		return nil, errors.New("failed to create draft order")
+	if len(resp.OrderCreate.UserErrors) > 0 {
+		r.Logger.Error("failed to create order", zap.Any("errors", resp.OrderCreate.UserErrors))
+		return nil, &MutationError{Mutation: "orderCreate", Errors: resp.OrderCreate.UserErrors}
+	}
+
+	if resp.OrderCreate.Order == nil {
+		r.Logger.Error("order create returned no order", zap.Any("input", input))
+		return nil, errors.New("order create returned no order")
+	}
+
+	return resp.OrderCreate.Order, nil
+}
+
+// GetOrdersByQuery searches orders using the Shopify search syntax, newest first.
+func (r *repository) GetOrdersByQuery(
+	ctx context.Context, query string, first int,
+) ([]Order, error) {
+	vars := map[string]any{
+		"query": query,
+		"first": first,
+	}
+
+	var resp GetOrderByQueryResponse
+	if err := r.gql.Do(ctx, getOrdersByQuery, vars, &resp); err != nil {
+		r.Logger.Error("failed to search orders", zap.Error(err), zap.Any("vars", vars))
+		return nil, err
+	}
+
+	return resp.Orders.Nodes, nil
+}
+
+// MarkOrderAsPaid marks an order as paid, recording a payment received outside Shopify checkout.
+func (r *repository) MarkOrderAsPaid(
+	ctx context.Context, gid string,
+) (*Order, error) {
+	if !strings.Contains(gid, orderKind) {
+		gid = GID(orderKind, gid)
+	}
+	vars := map[string]any{
+		"input": map[string]any{"id": gid},
+	}
+
+	var resp MarkOrderAsPaidResponse
+	if err := r.gql.Do(ctx, markOrderAsPaid, vars, &resp); err != nil {
+		r.Logger.Error("failed to mark order as paid", zap.Error(err), zap.String("order_id", gid))
+		return nil, err
+	}
+
+	if len(resp.OrderMarkAsPaid.UserErrors) > 0 {
+		r.Logger.Error("failed to mark order as paid", zap.Any("errors", resp.OrderMarkAsPaid.UserErrors), zap.String("order_id", gid))
+		return nil, &MutationError{Mutation: "orderMarkAsPaid", Errors: resp.OrderMarkAsPaid.UserErrors}
+	}
+
+	return resp.OrderMarkAsPaid.Order, nil
+}
+
+// CancelOrder cancels an order. Shopify processes the cancellation asynchronously and returns its job.
+func (r *repository) CancelOrder(
+	ctx context.Context, req CancelOrderRequest,
+) (*Job, error) {
+	if !strings.Contains(req.OrderID, orderKind) {
+		req.OrderID = GID(orderKind, req.OrderID)
+	}
+	if req.Reason == "" {
+		req.Reason = CancelReasonOther
+	}
+	vars := map[string]any{
+		"orderId":        req.OrderID,
+		"reason":         req.Reason,
+		"refund":         req.Refund,
+		"restock":        req.Restock,
+		"notifyCustomer": req.NotifyCustomer,
+	}
+	if req.StaffNote != "" {
+		vars["staffNote"] = req.StaffNote
+	}
+
+	var resp CancelOrderResponse
+	if err := r.gql.Do(ctx, cancelOrder, vars, &resp); err != nil {
+		r.Logger.Error("failed to cancel order", zap.Error(err), zap.Any("vars", vars))
+		return nil, err
+	}
+
+	if len(resp.OrderCancel.UserErrors) > 0 {
+		r.Logger.Error("failed to cancel order", zap.Any("errors", resp.OrderCancel.UserErrors), zap.Any("vars", vars))
+		return nil, &MutationError{Mutation: "orderCancel", Errors: resp.OrderCancel.UserErrors}
+	}
+
+	return resp.OrderCancel.Job, nil
+}
+
+// RefundOrder refunds the successful payments of an order up to the requested amount.
+func (r *repository) RefundOrder(
+	ctx context.Context, req RefundOrderRequest,
+) (*Refund, error) {
+	if !strings.Contains(req.OrderID, orderKind) {
+		req.OrderID = GID(orderKind, req.OrderID)
+	}
+
+	var txResp GetOrderTransactionsResponse
+	if err := r.gql.Do(ctx, getOrderTransactions, map[string]any{"id": req.OrderID}, &txResp); err != nil {
+		r.Logger.Error("failed to get order transactions", zap.Error(err), zap.String("order_id", req.OrderID))
+		return nil, err
+	}
+	if txResp.Order == nil {
+		return nil, fmt.Errorf("order not found: %s", req.OrderID)
+	}
+
+	var remaining money.Amount
+	if req.Amount != nil {
+		remaining = *req.Amount
+	}
+
+	transactions := make([]map[string]any, 0)
+	for _, transaction := range txResp.Order.Transactions {
+		if req.Amount != nil && !remaining.IsPositive() {
+			break
+		}
+		if transaction.Status != "SUCCESS" || (transaction.Kind != "SALE" && transaction.Kind != "CAPTURE") {
+			continue
+		}
+
+		amount, err := money.Parse(transaction.AmountSet.ShopMoney.Amount)
+		if err != nil {
+			r.Logger.Error(err.Error(), zap.String("amount", transaction.AmountSet.ShopMoney.Amount))
+			return nil, err
+		}
+		if req.Amount != nil {
+			amount = money.Min(amount, remaining)
+			remaining = remaining.Sub(amount)
+		}
+		transactions = append(transactions, map[string]any{
+			"orderId":  req.OrderID,
+			"parentId": transaction.ID,
+			"gateway":  transaction.Gateway,
+			"kind":     "REFUND",
+			"amount":   amount.String(),
+		})
+	}
+
+	if len(transactions) == 0 {
+		return nil, fmt.Errorf("order %s has no successful payments to refund", req.OrderID)
+	}
+	if req.Amount != nil && remaining.IsPositive() {
+		return nil, fmt.Errorf("refund amount %s exceeds the amount paid for order %s", req.Amount, req.OrderID)
+	}
+
+	input := map[string]any{
+		"orderId":      req.OrderID,
+		"notify":       req.Notify,
+		"transactions": transactions,
+	}
+	if req.Note != "" {
+		input["note"] = req.Note
+	}
+
+	var resp RefundCreateResponse
+	if err := r.gql.Do(ctx, refundCreate, map[string]any{"input": input}, &resp); err != nil {
+		r.Logger.Error("failed to refund order", zap.Error(err), zap.Any("input", input))
+		return nil, err
+	}
+
+	if len(resp.RefundCreate.UserErrors) > 0 {
+		r.Logger.Error("failed to refund order", zap.Any("errors", resp.RefundCreate.UserErrors), zap.Any("input", input))
+		return nil, &MutationError{Mutation: "refundCreate", Errors: resp.RefundCreate.UserErrors}
+	}
+
+	return resp.RefundCreate.Refund, nil
+}
+
+// GetProducts retrieves every product matching the query, following pagination.
+func (r *repository) GetProducts(
+	ctx context.Context, query string,
+) ([]Product, error) {
+	vars := map[string]any{
+		"first": 50,
+	}
+	if query != "" {
+		vars["query"] = query
+	}
+
+	products := make([]Product, 0)
+	for {
+		var resp GetProductsResponse
+		if err := r.gql.Do(ctx, getProducts, vars, &resp); err != nil {
+			r.Logger.Error("failed to get products", zap.Error(err), zap.Any("vars", vars))
+			return nil, err
+		}
+
+		products = append(products, resp.Products.Nodes...)
+		if !resp.Products.PageInfo.HasNextPage {
+			break
+		}
+		vars["after"] = resp.Products.PageInfo.EndCursor
 	}
 
-	return &resp.OrderCreate.Order, nil
+	return products, nil
 }